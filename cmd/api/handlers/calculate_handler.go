@@ -2,8 +2,12 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -13,53 +17,338 @@ import (
 )
 
 type CalculateHandler struct {
-	svc    *service.CalculateService
-	logger *slog.Logger
+	svc                 *service.CalculateService
+	logger              *slog.Logger
+	maxBatchItems       int
+	maxSimulationOrders int
 }
 
-// NewCalculateHandler builds a handler for POST /api/v1/calculate.
-func NewCalculateHandler(svc *service.CalculateService, logger *slog.Logger) *CalculateHandler {
-	return &CalculateHandler{svc: svc, logger: logger}
+// NewCalculateHandler builds a handler for the calculate and simulation endpoints.
+// maxBatchItems caps how many items one batch request may carry, and
+// maxSimulationOrders how many orders one simulation may replay.
+func NewCalculateHandler(svc *service.CalculateService, logger *slog.Logger, maxBatchItems, maxSimulationOrders int) *CalculateHandler {
+	return &CalculateHandler{svc: svc, logger: logger, maxBatchItems: maxBatchItems, maxSimulationOrders: maxSimulationOrders}
 }
 
-// Handle processes POST /api/v1/calculate and returns only the packs array.
+// Handle processes POST /api/v1/calculate and returns only the packs array,
+// or the packs with their cost for the min_cost objective. Each pack line
+// carries the metadata of its size.
 // @Summary Calculate pack breakdown
-// @Description Returns the optimal pack allocation for the requested amount.
+// @Description Returns the optimal pack allocation for the requested amount, using the pack sizes of the given SKU or the default profile. The config version active now is used, or the one active at as_of. The calculation is stored, and its ID is sent in X-Calculation-ID.
 // @Tags Calculate
 // @Accept json
 // @Produce json
 // @Param request body CalculateRequest true "Calculation payload"
-// @Success 200 {array} domain.PackBreakdown
+// @Success 200 {array} PackLineResponse
+// @Success 200 {object} CostCalculationResponse "objective=min_cost"
+// @Header 200 {integer} X-Calculation-ID "ID of the stored calculation"
 // @Failure 400 {object} httpx.ErrorResponse
 // @Failure 409 {object} httpx.ErrorResponse
 // @Failure 500 {object} httpx.ErrorResponse
 // @Router /api/v1/calculate [post]
 func (h *CalculateHandler) Handle(c *gin.Context) {
-	var req CalculateRequest
+	in, ok := calculateInputFromRequest(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.svc.Calculate(c.Request.Context(), in)
+	if err != nil {
+		h.writeCalculateError(c, err)
+		return
+	}
+	writeCalculationID(c, result)
+
+	// Cost results keep the packs array but add the itemized cost next to it.
+	if result.Cost != nil {
+		c.JSON(http.StatusOK, CostCalculationResponse{Packs: packLines(result.Packs, result.SizeDetails), Cost: *result.Cost})
+		return
+	}
+
+	c.JSON(http.StatusOK, packLines(result.Packs, result.SizeDetails))
+}
+
+// HandleV2 processes POST /api/v2/calculate and returns the breakdown with its
+// totals and an explanation of the choice.
+// @Summary Calculate pack breakdown with totals and explanation
+// @Description Accepts the same body as v1. The response adds shipped total, overfill, pack count, config version and solver, and explains which rule decided. With explain=true it also lists the runner-up breakdowns that lost, and alternatives=K returns the K best distinct breakdowns ranked by the active rules. The calculation is stored; its ID is in calculation_id and X-Calculation-ID.
+// @Tags Calculate
+// @Accept json
+// @Produce json
+// @Param explain query bool false "List the runner-up breakdowns"
+// @Param alternatives query int false "Return the K best breakdowns (1-10)"
+// @Param request body CalculateRequest true "Calculation payload"
+// @Success 200 {object} CalculationResponse
+// @Header 200 {integer} X-Calculation-ID "ID of the stored calculation"
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 409 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v2/calculate [post]
+func (h *CalculateHandler) HandleV2(c *gin.Context) {
+	in, ok := calculateInputFromRequest(c)
+	if !ok {
+		return
+	}
+	in.Explain = true
+	in.RunnersUp = c.Query("explain") == "true"
+	if raw := c.Query("alternatives"); raw != "" {
+		k, err := strconv.Atoi(raw)
+		if err != nil || k < 1 || k > service.MaxAlternatives {
+			httpx.WriteError(c, http.StatusBadRequest, "INVALID_ALTERNATIVES", fmt.Sprintf("alternatives must be between 1 and %d", service.MaxAlternatives))
+			return
+		}
+		in.TopK = k
+	}
+
+	result, err := h.svc.Calculate(c.Request.Context(), in)
+	if err != nil {
+		h.writeCalculateError(c, err)
+		return
+	}
+	writeCalculationID(c, result)
+
+	c.JSON(http.StatusOK, toCalculationResponse(in.Amount, result))
+}
+
+// HandleBatch processes POST /api/v1/calculate/batch.
+// @Summary Calculate many pack breakdowns
+// @Description Solves every item in parallel and reports results and errors per item, in request order. Item fields override the batch-level sku, objective, rules and stock_limits. Each solved item is stored and carries its calculation_id.
+// @Tags Calculate
+// @Accept json
+// @Produce json
+// @Param request body BatchCalculateRequest true "Batch payload"
+// @Success 200 {object} BatchCalculateResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Router /api/v1/calculate/batch [post]
+func (h *CalculateHandler) HandleBatch(c *gin.Context) {
+	var req BatchCalculateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		httpx.WriteError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
 		return
 	}
-	if req.Amount <= 0 {
-		httpx.WriteError(c, http.StatusBadRequest, "INVALID_AMOUNT", domain.ErrInvalidAmount.Error())
+	if len(req.Items) == 0 || len(req.Items) > h.maxBatchItems {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_BATCH_SIZE", fmt.Sprintf("items must hold between 1 and %d entries", h.maxBatchItems))
 		return
 	}
 
-	packs, err := h.svc.Calculate(c.Request.Context(), req.Amount)
-	if err != nil {
-		switch {
-		// Business rule: calculation requires configured pack sizes.
-		case errors.Is(err, domain.ErrPackSizesNotConfigured):
-			httpx.WriteError(c, http.StatusConflict, "PACK_SIZES_NOT_CONFIGURED", err.Error())
-		case errors.Is(err, domain.ErrCouldNotCalculate):
-			httpx.WriteError(c, http.StatusConflict, "COULD_NOT_CALCULATE", err.Error())
-		default:
-			h.logger.Error("calculate failed", "error", err)
-			httpx.WriteError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+	inputs := make([]service.CalculateInput, len(req.Items))
+	itemErrs := make([]error, len(req.Items))
+	for i, item := range req.Items {
+		inputs[i], itemErrs[i] = batchItemInput(req, item)
+	}
+
+	// Items that failed to parse are not sent; their slot keeps the parse error.
+	valid := make([]service.CalculateInput, 0, len(inputs))
+	for i, in := range inputs {
+		if itemErrs[i] == nil {
+			valid = append(valid, in)
 		}
-		return
 	}
+	results := h.svc.CalculateBatch(c.Request.Context(), valid)
+
+	resp := BatchCalculateResponse{Results: make([]BatchItemResponse, len(req.Items))}
+	next := 0
+	for i, in := range inputs {
+		item := BatchItemResponse{Index: i, Amount: in.Amount}
+		err := itemErrs[i]
+		if err == nil {
+			result := results[next]
+			next++
+			if err = result.Err; err == nil {
+				item.CalculationID = result.Result.CalculationID
+				item.Packs, item.Cost = packLines(result.Result.Packs, result.Result.SizeDetails), result.Result.Cost
+				item.ShippedTotal, item.PackCount = breakdownTotals(result.Result.Packs)
+				item.Overfill = item.ShippedTotal - in.Amount
+			}
+		}
+		if err != nil {
+			_, code, message := h.calculateError(err)
+			item.Error = &httpx.ErrorBody{Code: code, Message: message}
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+		resp.Results[i] = item
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// batchItemInput merges one batch item with the batch-level defaults.
+func batchItemInput(req BatchCalculateRequest, item CalculateRequest) (service.CalculateInput, error) {
+	if item.SKU == "" {
+		item.SKU = req.SKU
+	}
+	if item.Objective == "" {
+		item.Objective = req.Objective
+	}
+	if len(item.Rules) == 0 {
+		item.Rules = req.Rules
+	}
+	if len(item.StockLimits) == 0 {
+		item.StockLimits = req.StockLimits
+	}
+	if item.AsOf == nil {
+		item.AsOf = req.AsOf
+	}
+
+	stockLimits, ok := stockLimitsFromRequest(item.StockLimits)
+	if !ok {
+		return service.CalculateInput{Amount: item.Amount}, domain.ErrInvalidStockLimits
+	}
+
+	return service.CalculateInput{
+		Amount:      item.Amount,
+		SKU:         item.SKU,
+		StockLimits: stockLimits,
+		Objective:   domain.Objective(item.Objective),
+		Rules:       item.Rules,
+		AsOf:        timeOrZero(item.AsOf),
+	}, nil
+}
+
+// calculateInputFromRequest binds the calculation body and writes a 400 when it is invalid.
+func calculateInputFromRequest(c *gin.Context) (service.CalculateInput, bool) {
+	var req CalculateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return service.CalculateInput{}, false
+	}
+	if err := domain.ValidateAmount(req.Amount); err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_AMOUNT", err.Error())
+		return service.CalculateInput{}, false
+	}
+	stockLimits, ok := stockLimitsFromRequest(req.StockLimits)
+	if !ok {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_STOCK_LIMITS", domain.ErrInvalidStockLimits.Error())
+		return service.CalculateInput{}, false
+	}
+
+	return service.CalculateInput{
+		Amount:      req.Amount,
+		SKU:         req.SKU,
+		StockLimits: stockLimits,
+		Objective:   domain.Objective(req.Objective),
+		Rules:       req.Rules,
+		AsOf:        timeOrZero(req.AsOf),
+	}, true
+}
+
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return *t
+}
+
+func (h *CalculateHandler) writeCalculateError(c *gin.Context, err error) {
+	status, code, message := h.calculateError(err)
+	httpx.WriteError(c, status, code, message, validationDetails(err)...)
+}
+
+// calculateError maps a calculation error to its HTTP status, error code and message.
+func (h *CalculateHandler) calculateError(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidAmount):
+		return http.StatusBadRequest, "INVALID_AMOUNT", err.Error()
+	case errors.Is(err, domain.ErrInvalidSKU):
+		return http.StatusBadRequest, "INVALID_SKU", err.Error()
+	case errors.Is(err, domain.ErrInvalidPackSizes):
+		return http.StatusBadRequest, "INVALID_PACK_SIZES", err.Error()
+	case errors.Is(err, domain.ErrInvalidStockLimits):
+		return http.StatusBadRequest, "INVALID_STOCK_LIMITS", err.Error()
+	case errors.Is(err, domain.ErrInvalidObjective):
+		return http.StatusBadRequest, "INVALID_OBJECTIVE", err.Error()
+	case errors.Is(err, domain.ErrInvalidRules):
+		return http.StatusBadRequest, "INVALID_RULES", err.Error()
+	case errors.Is(err, domain.ErrInvalidCalculationFilter):
+		return http.StatusBadRequest, "INVALID_CALCULATION_FILTER", err.Error()
+	case errors.Is(err, domain.ErrCalculationNotFound):
+		return http.StatusNotFound, "CALCULATION_NOT_FOUND", err.Error()
+	case errors.Is(err, domain.ErrPackCostsNotConfigured):
+		return http.StatusConflict, "PACK_COSTS_NOT_CONFIGURED", err.Error()
+	// Business rule: calculation requires configured pack sizes.
+	case errors.Is(err, domain.ErrPackSizesNotConfigured):
+		return http.StatusConflict, "PACK_SIZES_NOT_CONFIGURED", err.Error()
+	// Stock shortages carry the available capacity in the message.
+	case errors.Is(err, domain.ErrCouldNotCalculate):
+		return http.StatusConflict, "COULD_NOT_CALCULATE", err.Error()
+	default:
+		h.logger.Error("calculate failed", "error", err)
+		return http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error"
+	}
+}
+
+func toCalculationResponse(amount int, result *service.CalculateResult) CalculationResponse {
+	resp := CalculationResponse{
+		CalculationID: result.CalculationID,
+		Amount:        amount,
+		Packs:         packLines(result.Packs, result.SizeDetails),
+		ConfigVersion: result.ConfigVersion,
+		Solver:        result.Solver,
+		Cost:          result.Cost,
+		Explanation: CalculationExplanation{
+			DecidedBy: result.DecidedBy,
+			Summary:   result.Explanation,
+			Rules:     result.Rules,
+		},
+	}
+	resp.CandidatesTruncated = result.CandidatesTruncated
+	resp.ShippedTotal, resp.PackCount = breakdownTotals(result.Packs)
+	resp.Overfill = resp.ShippedTotal - amount
+	resp.OverfillPct = overfillPct(resp.Overfill, amount)
+
+	for _, alt := range result.RunnersUp {
+		resp.Explanation.RunnersUp = append(resp.Explanation.RunnersUp, toCandidateResponse(amount, alt, result.SizeDetails))
+	}
+	for _, alt := range result.Alternatives {
+		resp.Alternatives = append(resp.Alternatives, toCandidateResponse(amount, alt, result.SizeDetails))
+	}
+
+	return resp
+}
+
+// toCandidateResponse renders a breakdown; details may be nil to leave out pack metadata.
+func toCandidateResponse(amount int, alt service.Alternative, details map[int64]domain.PackSize) CandidateResponse {
+	return CandidateResponse{
+		Packs:        packLines(alt.Packs, details),
+		ShippedTotal: alt.Total,
+		Overfill:     alt.Overfill,
+		OverfillPct:  overfillPct(alt.Overfill, amount),
+		PackCount:    alt.PackCount,
+		LostOn:       alt.LostOn,
+	}
+}
+
+// packLines adds the metadata of each size in details to a breakdown.
+func packLines(packs []domain.PackBreakdown, details map[int64]domain.PackSize) []PackLineResponse {
+	lines := make([]PackLineResponse, 0, len(packs))
+	for _, p := range packs {
+		line := PackLineResponse{Size: p.Size, Count: p.Count}
+		if pack, ok := details[int64(p.Size)]; ok {
+			line.Label, line.PackagingSKU, line.TareWeightGrams = pack.Label, pack.PackagingSKU, pack.TareWeightGrams
+			line.Dimensions = dimensionsResponse(pack.Dimensions)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func breakdownTotals(packs []domain.PackBreakdown) (int, int) {
+	total, count := 0, 0
+	for _, p := range packs {
+		total += p.Size * p.Count
+		count += p.Count
+	}
+
+	return total, count
+}
 
-	c.JSON(http.StatusOK, packs)
+// overfillPct is overfill as a percentage of the amount, rounded to two decimals.
+func overfillPct(overfill, amount int) float64 {
+	return math.Round(float64(overfill)*10000/float64(amount)) / 100
 }
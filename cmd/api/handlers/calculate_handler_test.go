@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go-packing/internal/domain"
+	"go-packing/internal/infrastructure/memory"
+	"go-packing/internal/service"
+)
+
+// newCalculateRouter serves the calculate routes over a memory store holding
+// the default sizes.
+func newCalculateRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := memory.NewStore()
+	repo := memory.NewPackConfigRepository(store)
+	configs := service.NewPackConfigService(repo, nil, logger, domain.PackSizeLimits{}, false)
+	if _, err := configs.ReplacePackSizes(context.Background(), service.ReplacePackSizesInput{PackSizes: []int64{250, 500, 1000, 2000, 5000}}); err != nil {
+		t.Fatalf("seed config: %v", err)
+	}
+	svc := service.NewCalculateService(repo, service.NewSolverCache(1<<20), 2, domain.PackSizeLimits{}, memory.NewCalculationRepository(store))
+	h := NewCalculateHandler(svc, logger, 100, 100)
+
+	r := gin.New()
+	r.POST("/v1/calculate", h.Handle)
+	r.POST("/v2/calculate", h.HandleV2)
+	return r
+}
+
+func TestCalculateHandler_AmountBounds(t *testing.T) {
+	r := newCalculateRouter(t)
+
+	for _, path := range []string{"/v1/calculate", "/v2/calculate?explain=true&alternatives=3"} {
+		if w := serve(r, http.MethodPost, path, fmt.Sprintf(`{"amount": %d}`, domain.MaxAmount), ""); w.Code != http.StatusOK {
+			t.Fatalf("%s: largest amount: status = %d: %s", path, w.Code, w.Body)
+		}
+		for _, amount := range []int{0, domain.MaxAmount + 1, math.MaxInt - 10} {
+			w := serve(r, http.MethodPost, path, fmt.Sprintf(`{"amount": %d}`, amount), "")
+			assertError(t, w, http.StatusBadRequest, "INVALID_AMOUNT")
+		}
+	}
+}
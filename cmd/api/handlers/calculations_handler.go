@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-packing/internal/domain"
+	"go-packing/internal/service"
+	"go-packing/pkg/httpx"
+)
+
+// GetCalculation handles GET /api/v1/calculations/{id}.
+// @Summary Get a stored calculation
+// @Description Returns a past calculation with its request options, the config version it used, the breakdown, the solver and how long it took.
+// @Tags Calculations
+// @Produce json
+// @Param id path int true "Calculation ID"
+// @Success 200 {object} StoredCalculationResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 404 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/calculations/{id} [get]
+func (h *CalculateHandler) GetCalculation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_CALCULATION_ID", "calculation id must be a positive integer")
+		return
+	}
+
+	calc, err := h.svc.GetCalculation(c.Request.Context(), id)
+	if err != nil {
+		h.writeCalculateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toStoredCalculationResponse(*calc))
+}
+
+// ListCalculations handles GET /api/v1/calculations.
+// @Summary List stored calculations
+// @Description Lists past calculations, newest first. Filters combine; from is inclusive and to exclusive, and both amount bounds are inclusive. Pass next_cursor of a page as cursor to get the next one.
+// @Tags Calculations
+// @Produce json
+// @Param sku query string false "Product code"
+// @Param from query string false "Earliest creation time, RFC 3339"
+// @Param to query string false "Creation time to stop before, RFC 3339"
+// @Param min_amount query int false "Smallest amount"
+// @Param max_amount query int false "Largest amount"
+// @Param limit query int false "Page size (default 50, at most 500)"
+// @Param cursor query int false "next_cursor of the previous page"
+// @Success 200 {object} CalculationListResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/calculations [get]
+func (h *CalculateHandler) ListCalculations(c *gin.Context) {
+	filter, ok := calculationFilterFromQuery(c)
+	if !ok {
+		return
+	}
+
+	page, err := h.svc.ListCalculations(c.Request.Context(), filter)
+	if err != nil {
+		h.writeCalculateError(c, err)
+		return
+	}
+
+	resp := CalculationListResponse{
+		Calculations: make([]StoredCalculationResponse, 0, len(page.Calculations)),
+		NextCursor:   page.NextBeforeID,
+	}
+	for _, calc := range page.Calculations {
+		resp.Calculations = append(resp.Calculations, toStoredCalculationResponse(calc))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// calculationFilterFromQuery parses the listing filters and writes a 400 when
+// one is malformed. The service checks that they fit together.
+func calculationFilterFromQuery(c *gin.Context) (domain.CalculationFilter, bool) {
+	filter := domain.CalculationFilter{SKU: c.Query("sku")}
+
+	times := []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}}
+	for _, p := range times {
+		name, dst := p.name, p.dst
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			httpx.WriteError(c, http.StatusBadRequest, "INVALID_CALCULATION_FILTER", name+" must be an RFC 3339 time")
+			return filter, false
+		}
+		*dst = t
+	}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{{"min_amount", &filter.MinAmount}, {"max_amount", &filter.MaxAmount}, {"limit", &filter.Limit}}
+	for _, p := range ints {
+		name, dst := p.name, p.dst
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			httpx.WriteError(c, http.StatusBadRequest, "INVALID_CALCULATION_FILTER", name+" must be an integer")
+			return filter, false
+		}
+		*dst = n
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || cursor <= 0 {
+			httpx.WriteError(c, http.StatusBadRequest, "INVALID_CALCULATION_FILTER", "cursor must be a positive integer")
+			return filter, false
+		}
+		filter.BeforeID = cursor
+	}
+
+	return filter, true
+}
+
+// writeCalculationID sends the ID of a stored calculation as a header.
+func writeCalculationID(c *gin.Context, result *service.CalculateResult) {
+	if result.CalculationID > 0 {
+		c.Header("X-Calculation-ID", strconv.FormatInt(result.CalculationID, 10))
+	}
+}
+
+func toStoredCalculationResponse(calc domain.Calculation) StoredCalculationResponse {
+	resp := StoredCalculationResponse{
+		ID:            calc.ID,
+		SKU:           calc.SKU,
+		Amount:        calc.Amount,
+		Options:       calc.Options,
+		ConfigVersion: calc.ConfigVersion,
+		Packs:         calc.Packs,
+		Cost:          calc.Cost,
+		Solver:        calc.Solver,
+		DurationMS:    float64(calc.Duration.Microseconds()) / 1000,
+		CreatedAt:     calc.CreatedAt,
+	}
+	if resp.Packs == nil {
+		resp.Packs = make([]domain.PackBreakdown, 0)
+	}
+	resp.ShippedTotal, resp.PackCount = breakdownTotals(calc.Packs)
+	resp.Overfill = resp.ShippedTotal - calc.Amount
+
+	return resp
+}
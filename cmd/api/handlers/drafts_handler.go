@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"go-packing/internal/domain"
+	"go-packing/internal/service"
+	"go-packing/pkg/httpx"
+)
+
+type DraftsHandler struct {
+	svc    *service.DraftService
+	logger *slog.Logger
+}
+
+// NewDraftsHandler builds handlers for the review workflow under
+// /api/v1/pack-sizes/drafts and /api/v1/products/{sku}/pack-sizes/drafts.
+// The X-Actor header identifies authors, commenters and reviewers; see
+// actorFromRequest for why it must come from an authenticating proxy.
+func NewDraftsHandler(svc *service.DraftService, logger *slog.Logger) *DraftsHandler {
+	return &DraftsHandler{svc: svc, logger: logger}
+}
+
+// Create handles POST /api/v1/pack-sizes/drafts.
+// @Summary Create a pack size draft
+// @Description Validates a pack-size change like PUT /pack-sizes and stores it as a draft authored by X-Actor, without changing the config. Send If-Match to base the draft on a known version.
+// @Tags Drafts
+// @Accept json
+// @Produce json
+// @Param X-Actor header string true "Author of the draft"
+// @Param If-Match header string false "ETag of the version the draft is based on"
+// @Param request body PackSizesRequest true "Proposed pack sizes"
+// @Success 201 {object} PackConfigDraftResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 412 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/drafts [post]
+// @Router /api/v1/products/{sku}/pack-sizes/drafts [post]
+func (h *DraftsHandler) Create(c *gin.Context) {
+	expectedVersion, err := httpx.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_IF_MATCH", err.Error())
+		return
+	}
+	in, ok := replaceInputFromRequest(c, expectedVersion)
+	if !ok {
+		return
+	}
+
+	draft, err := h.svc.CreateDraft(c.Request.Context(), in)
+	if err != nil {
+		h.writeDraftError(c, "create pack size draft failed", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toDraftResponse(draft))
+}
+
+// List handles GET /api/v1/pack-sizes/drafts.
+// @Summary List pack size drafts
+// @Description Returns the drafts of a SKU, newest first, without their comments.
+// @Tags Drafts
+// @Produce json
+// @Param status query string false "Only drafts in this status" Enums(draft, approved, rejected, published)
+// @Success 200 {object} PackConfigDraftsResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/drafts [get]
+// @Router /api/v1/products/{sku}/pack-sizes/drafts [get]
+func (h *DraftsHandler) List(c *gin.Context) {
+	status, err := domain.ParseConfigStatus(c.Query("status"))
+	if err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_STATUS", err.Error())
+		return
+	}
+
+	drafts, err := h.svc.ListDrafts(c.Request.Context(), c.Param("sku"), status)
+	if err != nil {
+		h.writeDraftError(c, "list pack size drafts failed", err)
+		return
+	}
+
+	resp := PackConfigDraftsResponse{Drafts: make([]PackConfigDraftResponse, 0, len(drafts))}
+	for i := range drafts {
+		resp.Drafts = append(resp.Drafts, toDraftResponse(&drafts[i]))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Get handles GET /api/v1/pack-sizes/drafts/{id}.
+// @Summary Get a pack size draft
+// @Description Returns one draft with its comments.
+// @Tags Drafts
+// @Produce json
+// @Param id path int true "Draft ID"
+// @Success 200 {object} PackConfigDraftResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 404 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/drafts/{id} [get]
+// @Router /api/v1/products/{sku}/pack-sizes/drafts/{id} [get]
+func (h *DraftsHandler) Get(c *gin.Context) {
+	id, ok := draftIDFromPath(c)
+	if !ok {
+		return
+	}
+
+	draft, err := h.svc.GetDraft(c.Request.Context(), c.Param("sku"), id)
+	if err != nil {
+		h.writeDraftError(c, "get pack size draft failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDraftResponse(draft))
+}
+
+// Update handles PUT /api/v1/pack-sizes/drafts/{id}.
+// @Summary Edit a pack size draft
+// @Description Replaces the proposed config of a draft that is not yet approved and rebases it on the current version. Only the author, sent as X-Actor, may edit a draft.
+// @Tags Drafts
+// @Accept json
+// @Produce json
+// @Param id path int true "Draft ID"
+// @Param X-Actor header string true "Author of the draft"
+// @Param If-Match header string false "ETag of the version the draft is based on"
+// @Param request body PackSizesRequest true "Proposed pack sizes"
+// @Success 200 {object} PackConfigDraftResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 403 {object} httpx.ErrorResponse
+// @Failure 404 {object} httpx.ErrorResponse
+// @Failure 409 {object} httpx.ErrorResponse
+// @Failure 412 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/drafts/{id} [put]
+// @Router /api/v1/products/{sku}/pack-sizes/drafts/{id} [put]
+func (h *DraftsHandler) Update(c *gin.Context) {
+	id, ok := draftIDFromPath(c)
+	if !ok {
+		return
+	}
+	expectedVersion, err := httpx.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_IF_MATCH", err.Error())
+		return
+	}
+	in, ok := replaceInputFromRequest(c, expectedVersion)
+	if !ok {
+		return
+	}
+
+	draft, err := h.svc.UpdateDraft(c.Request.Context(), id, in)
+	if err != nil {
+		h.writeDraftError(c, "update pack size draft failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDraftResponse(draft))
+}
+
+// Comment handles POST /api/v1/pack-sizes/drafts/{id}/comments.
+// @Summary Comment on a pack size draft
+// @Description Attaches a review comment by X-Actor to a draft.
+// @Tags Drafts
+// @Accept json
+// @Produce json
+// @Param id path int true "Draft ID"
+// @Param X-Actor header string true "Author of the comment"
+// @Param request body DraftCommentRequest true "Comment"
+// @Success 201 {object} PackConfigDraftResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 404 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/drafts/{id}/comments [post]
+// @Router /api/v1/products/{sku}/pack-sizes/drafts/{id}/comments [post]
+func (h *DraftsHandler) Comment(c *gin.Context) {
+	var req DraftCommentRequest
+
+	id, ok := draftIDFromPath(c)
+	if !ok {
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	draft, err := h.svc.AddComment(c.Request.Context(), c.Param("sku"), id, actorFromRequest(c), req.Body)
+	if err != nil {
+		h.writeDraftError(c, "comment on pack size draft failed", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toDraftResponse(draft))
+}
+
+// Approve handles POST /api/v1/pack-sizes/drafts/{id}/approve.
+// @Summary Approve a pack size draft
+// @Description Signs a draft off on behalf of X-Actor. The author of a draft cannot approve it.
+// @Tags Drafts
+// @Produce json
+// @Param id path int true "Draft ID"
+// @Param X-Actor header string true "Reviewer"
+// @Success 200 {object} PackConfigDraftResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 403 {object} httpx.ErrorResponse
+// @Failure 404 {object} httpx.ErrorResponse
+// @Failure 409 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/drafts/{id}/approve [post]
+// @Router /api/v1/products/{sku}/pack-sizes/drafts/{id}/approve [post]
+func (h *DraftsHandler) Approve(c *gin.Context) {
+	id, ok := draftIDFromPath(c)
+	if !ok {
+		return
+	}
+
+	draft, err := h.svc.Approve(c.Request.Context(), c.Param("sku"), id, actorFromRequest(c))
+	if err != nil {
+		h.writeDraftError(c, "approve pack size draft failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDraftResponse(draft))
+}
+
+// Reject handles POST /api/v1/pack-sizes/drafts/{id}/reject.
+// @Summary Reject a pack size draft
+// @Description Closes a draft that was not published, on behalf of X-Actor. The optional comment is attached as the reason.
+// @Tags Drafts
+// @Accept json
+// @Produce json
+// @Param id path int true "Draft ID"
+// @Param X-Actor header string true "Reviewer"
+// @Param request body DraftCommentRequest false "Optional reason"
+// @Success 200 {object} PackConfigDraftResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 404 {object} httpx.ErrorResponse
+// @Failure 409 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/drafts/{id}/reject [post]
+// @Router /api/v1/products/{sku}/pack-sizes/drafts/{id}/reject [post]
+func (h *DraftsHandler) Reject(c *gin.Context) {
+	var req DraftCommentRequest
+
+	id, ok := draftIDFromPath(c)
+	if !ok {
+		return
+	}
+	// The body is optional; it only carries a reason.
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httpx.WriteError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+			return
+		}
+	}
+
+	draft, err := h.svc.Reject(c.Request.Context(), c.Param("sku"), id, actorFromRequest(c), req.Body)
+	if err != nil {
+		h.writeDraftError(c, "reject pack size draft failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDraftResponse(draft))
+}
+
+// Publish handles POST /api/v1/pack-sizes/drafts/{id}/publish.
+// @Summary Publish a pack size draft
+// @Description Writes an approved draft as the next config version, on behalf of X-Actor. Fails with 412 when another version was written since the draft was made. The new version is sent as ETag.
+// @Tags Drafts
+// @Produce json
+// @Param id path int true "Draft ID"
+// @Param X-Actor header string true "Publisher"
+// @Success 200 {object} PackConfigDraftResponse
+// @Header 200 {string} ETag "Quoted config version"
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 404 {object} httpx.ErrorResponse
+// @Failure 409 {object} httpx.ErrorResponse
+// @Failure 412 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/drafts/{id}/publish [post]
+// @Router /api/v1/products/{sku}/pack-sizes/drafts/{id}/publish [post]
+func (h *DraftsHandler) Publish(c *gin.Context) {
+	id, ok := draftIDFromPath(c)
+	if !ok {
+		return
+	}
+
+	draft, cfg, err := h.svc.Publish(c.Request.Context(), c.Param("sku"), id, actorFromRequest(c))
+	if err != nil {
+		h.writeDraftError(c, "publish pack size draft failed", err)
+		return
+	}
+
+	c.Header("ETag", httpx.VersionETag(cfg.Version))
+	c.JSON(http.StatusOK, toDraftResponse(draft))
+}
+
+// writeDraftError maps workflow errors to HTTP responses, and everything else
+// like a config write.
+func (h *DraftsHandler) writeDraftError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrDraftNotFound):
+		httpx.WriteError(c, http.StatusNotFound, "DRAFT_NOT_FOUND", err.Error())
+	case errors.Is(err, domain.ErrInvalidDraftTransition):
+		httpx.WriteError(c, http.StatusConflict, "INVALID_DRAFT_STATE", err.Error())
+	case errors.Is(err, domain.ErrSelfApproval):
+		httpx.WriteError(c, http.StatusForbidden, "SELF_APPROVAL", err.Error())
+	case errors.Is(err, domain.ErrNotDraftAuthor):
+		httpx.WriteError(c, http.StatusForbidden, "NOT_DRAFT_AUTHOR", err.Error())
+	case errors.Is(err, domain.ErrActorRequired):
+		httpx.WriteError(c, http.StatusBadRequest, "ACTOR_REQUIRED", err.Error())
+	case errors.Is(err, domain.ErrInvalidComment):
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_COMMENT", err.Error())
+	default:
+		writeConfigWriteError(c, h.logger, msg, err)
+	}
+}
+
+// draftIDFromPath parses the :id path parameter and writes a 400 when it is invalid.
+func draftIDFromPath(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_DRAFT_ID", "draft id must be a positive integer")
+		return 0, false
+	}
+
+	return id, true
+}
+
+func toDraftResponse(draft *domain.PackConfigDraft) PackConfigDraftResponse {
+	cfg := draft.Config
+	resp := PackConfigDraftResponse{
+		ID:               draft.ID,
+		SKU:              cfg.SKU,
+		Status:           string(cfg.Status),
+		BaseVersion:      draft.BaseVersion,
+		Revision:         draft.Revision,
+		PackSizes:        cfg.PackSizes,
+		Packs:            packsResponse(&cfg),
+		StockLimits:      stockLimitsResponse(cfg.StockLimits),
+		PackCosts:        packCostsResponse(cfg.PackCosts),
+		OverfillItemCost: cfg.OverfillItemCost,
+		Rules:            cfg.Rules,
+		EffectiveFrom:    cfg.EffectiveFrom,
+		EffectiveTo:      cfg.EffectiveTo,
+		Reason:           cfg.Reason,
+		CreatedBy:        draft.CreatedBy,
+		CreatedAt:        draft.CreatedAt,
+		UpdatedAt:        cfg.UpdatedAt,
+		ReviewedBy:       draft.ReviewedBy,
+		ReviewedAt:       draft.ReviewedAt,
+		PublishedBy:      draft.PublishedBy,
+		PublishedAt:      draft.PublishedAt,
+		PublishedVersion: draft.PublishedVersion,
+	}
+	for _, comment := range draft.Comments {
+		resp.Comments = append(resp.Comments, DraftCommentResponse{
+			ID:        comment.ID,
+			Author:    comment.Author,
+			Body:      comment.Body,
+			CreatedAt: comment.CreatedAt,
+		})
+	}
+
+	return resp
+}
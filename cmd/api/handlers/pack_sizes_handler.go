@@ -2,8 +2,13 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -13,101 +18,950 @@ import (
 )
 
 type PackSizesHandler struct {
-	svc    *service.PackConfigService
-	logger *slog.Logger
+	svc            *service.PackConfigService
+	logger         *slog.Logger
+	requireIfMatch bool
+	previewAmounts []int
 }
 
-// NewPackSizesHandler builds handlers for /api/v1/pack-sizes endpoints.
-func NewPackSizesHandler(svc *service.PackConfigService, logger *slog.Logger) *PackSizesHandler {
-	return &PackSizesHandler{svc: svc, logger: logger}
+// NewPackSizesHandler builds handlers for /api/v1/pack-sizes and /api/v1/products/{sku}/pack-sizes endpoints.
+// The unscoped routes act on the default profile. When requireIfMatch is set,
+// writes without an If-Match header are rejected. Whether direct writes need
+// review is up to svc. previewAmounts are solved by dry runs that do not pick
+// their own sample.
+func NewPackSizesHandler(svc *service.PackConfigService, logger *slog.Logger, requireIfMatch bool, previewAmounts []int) *PackSizesHandler {
+	return &PackSizesHandler{svc: svc, logger: logger, requireIfMatch: requireIfMatch, previewAmounts: previewAmounts}
 }
 
 // Get handles GET /api/v1/pack-sizes.
 // @Summary Get current pack sizes
-// @Description Returns configured pack sizes.
+// @Description Returns configured pack sizes with their version. The version is also sent as ETag.
 // @Tags Pack Sizes
 // @Produce json
 // @Success 200 {object} PackSizesResponse
+// @Header 200 {string} ETag "Quoted config version"
 // @Failure 500 {object} httpx.ErrorResponse
 // @Router /api/v1/pack-sizes [get]
+// @Router /api/v1/products/{sku}/pack-sizes [get]
 func (h *PackSizesHandler) Get(c *gin.Context) {
-	packCfg, err := h.svc.GetCurrent(c.Request.Context())
+	packCfg, err := h.svc.GetCurrent(c.Request.Context(), c.Param("sku"))
 	if err != nil {
-		h.logger.Error("get pack sizes failed", "error", err)
-		httpx.WriteError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		h.writeReadError(c, "get pack sizes failed", err)
 		return
 	}
 
 	if packCfg == nil {
-		c.JSON(http.StatusOK, gin.H{
-			"pack_sizes": []int64{},
-		})
+		c.JSON(http.StatusOK, PackSizesResponse{PackSizes: []int64{}, Packs: []PackSizeDetails{}})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"pack_sizes": packCfg.PackSizes,
-	})
+	writePackConfig(c, packCfg)
+}
+
+// Active handles GET /api/v1/pack-sizes/active.
+// @Summary Get active pack sizes
+// @Description Returns the pack configuration version that calculations use now, or at as_of. It differs from GET /pack-sizes while a newer version is scheduled.
+// @Tags Pack Sizes
+// @Produce json
+// @Param as_of query string false "RFC 3339 time to resolve the version at (default now)"
+// @Success 200 {object} PackConfigVersionResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 409 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/active [get]
+// @Router /api/v1/products/{sku}/pack-sizes/active [get]
+func (h *PackSizesHandler) Active(c *gin.Context) {
+	asOf, ok := asOfFromQuery(c)
+	if !ok {
+		return
+	}
+
+	cfg, err := h.svc.GetActive(c.Request.Context(), c.Param("sku"), asOf)
+	if err != nil {
+		if errors.Is(err, domain.ErrPackSizesNotConfigured) {
+			httpx.WriteError(c, http.StatusConflict, "PACK_SIZES_NOT_CONFIGURED", err.Error())
+			return
+		}
+		h.writeReadError(c, "get active pack sizes failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toVersionResponse(*cfg))
 }
 
 // Replace handles PUT /api/v1/pack-sizes.
 // @Summary Replace pack sizes
-// @Description Replaces all pack sizes and applies optimistic concurrency rules in persistence.
+// @Description Replaces all pack sizes. Metadata, stock limits, costs and rules left out of the body keep their stored values for the sizes that remain; send an empty list to clear them. Send the ETag from GET as If-Match to reject writes based on a stale read. With effective_from the new version is queued and calculations keep using the active one until then; GET always returns the latest written version. Rejected with 403 REVIEW_REQUIRED unless server.require_review is turned off, except for dry runs; publish an approved draft instead. With analyze=true the response includes an analysis of the new sizes. With dry_run=true nothing is stored; the response is a PackSizesPreviewResponse with the sizes added and removed, the analysis warnings and how the sample amounts would ship.
 // @Tags Pack Sizes
 // @Accept json
 // @Produce json
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Param analyze query bool false "Include an analysis of the new pack sizes"
+// @Param dry_run query bool false "Validate and preview the change without storing it"
+// @Param sample query string false "Comma-separated amounts a dry run solves (default from config)"
 // @Param request body PackSizesRequest true "Pack sizes payload"
 // @Success 200 {object} PackSizesResponse
+// @Success 200 {object} PackSizesPreviewResponse "dry_run=true"
+// @Header 200 {string} ETag "Quoted config version"
 // @Failure 400 {object} httpx.ErrorResponse
+// @Failure 403 {object} httpx.ErrorResponse
 // @Failure 409 {object} httpx.ErrorResponse
+// @Failure 412 {object} httpx.ErrorResponse
+// @Failure 428 {object} httpx.ErrorResponse
 // @Failure 500 {object} httpx.ErrorResponse
 // @Router /api/v1/pack-sizes [put]
+// @Router /api/v1/products/{sku}/pack-sizes [put]
 func (h *PackSizesHandler) Replace(c *gin.Context) {
-	var req PackSizesRequest
+	dryRun := c.Query("dry_run") == "true"
+	expectedVersion, ok := h.expectedVersion(c)
+	if !ok {
+		return
+	}
+	analyze := c.Query("analyze") == "true"
+	sample, ok := previewAmountsFromQuery(c, h.previewAmounts)
+	if !ok {
+		return
+	}
+
+	in, ok := replaceInputFromRequest(c, expectedVersion)
+	if !ok {
+		return
+	}
+	if dryRun {
+		preview, err := h.svc.PreviewReplace(c.Request.Context(), in, sample)
+		if err != nil {
+			h.writeWriteError(c, "preview pack sizes failed", err)
+			return
+		}
+		c.JSON(http.StatusOK, toPreviewResponse(preview))
+		return
+	}
 
+	cfg, err := h.svc.ReplacePackSizes(c.Request.Context(), in)
+	if err != nil {
+		h.writeWriteError(c, "replace pack sizes failed", err)
+		return
+	}
+
+	resp := packSizesResponse(cfg)
+	if analyze {
+		analysis := toAnalysisResponse(h.svc.AnalyzeConfig(cfg, 0))
+		resp.Analysis = &analysis
+	}
+	writePackSizesResponse(c, resp)
+}
+
+// Analysis handles GET /api/v1/pack-sizes/analysis.
+// @Summary Analyze pack sizes
+// @Description Reports the GCD, the Frobenius number and how many amounts cannot be hit exactly. Flags sizes that never lower overfill or are never used up to a bound, and gives the worst overfill per amount range.
+// @Tags Pack Sizes
+// @Produce json
+// @Param up_to query int false "Largest amount to solve (default 10x the largest pack, max 1000000)"
+// @Success 200 {object} PackSizesAnalysisResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 409 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/analysis [get]
+// @Router /api/v1/products/{sku}/pack-sizes/analysis [get]
+func (h *PackSizesHandler) Analysis(c *gin.Context) {
+	upTo, ok := analysisBoundFromQuery(c)
+	if !ok {
+		return
+	}
+
+	analysis, err := h.svc.Analyze(c.Request.Context(), c.Param("sku"), upTo)
+	if err != nil {
+		if errors.Is(err, domain.ErrPackSizesNotConfigured) {
+			httpx.WriteError(c, http.StatusConflict, "PACK_SIZES_NOT_CONFIGURED", err.Error())
+			return
+		}
+		h.writeReadError(c, "analyze pack sizes failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toAnalysisResponse(*analysis))
+}
+
+// Recommend handles POST /api/v1/pack-sizes/recommendations.
+// @Summary Recommend pack sizes
+// @Description Searches for the pack sizes that ship a histogram of order amounts with the least expected overfill, then the fewest expected packs, under the default calculation rules. Returns the best sets and scores the current sizes for comparison.
+// @Tags Pack Sizes
+// @Accept json
+// @Produce json
+// @Param request body RecommendPackSizesRequest true "Demand histogram and search options"
+// @Success 200 {object} PackSizeRecommendationResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/recommendations [post]
+// @Router /api/v1/products/{sku}/pack-sizes/recommendations [post]
+func (h *PackSizesHandler) Recommend(c *gin.Context) {
+	var req RecommendPackSizesRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		httpx.WriteError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
 		return
 	}
-	if !isValidPackSizes(req.PackSizes) {
-		httpx.WriteError(c, http.StatusBadRequest, "INVALID_PACK_SIZES", domain.ErrInvalidPackSizes.Error())
+
+	histogram := make([]service.DemandBucket, 0, len(req.Histogram))
+	for _, b := range req.Histogram {
+		histogram = append(histogram, service.DemandBucket{Amount: b.Amount, Count: b.Count})
+	}
+
+	recommendation, err := h.svc.Recommend(c.Request.Context(), service.RecommendInput{
+		SKU:            c.Param("sku"),
+		Histogram:      histogram,
+		SizeCount:      req.SizeCount,
+		FixedSizes:     req.FixedSizes,
+		CandidateSizes: req.CandidateSizes,
+		Top:            req.Top,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidRecommendation) {
+			httpx.WriteError(c, http.StatusBadRequest, "INVALID_RECOMMENDATION_REQUEST", err.Error())
+			return
+		}
+		h.writeReadError(c, "recommend pack sizes failed", err)
+		return
+	}
+
+	resp := PackSizeRecommendationResponse{
+		Candidates: make([]RecommendedPackSizesResponse, 0, len(recommendation.Candidates)),
+		Evaluated:  recommendation.Evaluated,
+		Exhaustive: recommendation.Exhaustive,
+	}
+	for _, candidate := range recommendation.Candidates {
+		resp.Candidates = append(resp.Candidates, toRecommendedPackSizesResponse(candidate))
+	}
+	if recommendation.Current != nil {
+		current := toRecommendedPackSizesResponse(*recommendation.Current)
+		resp.Current = &current
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Delete handles DELETE /api/v1/products/{sku}/pack-sizes.
+// @Summary Delete product pack sizes
+// @Description Removes the pack configuration of a product together with its history. Rejected with 403 REVIEW_REQUIRED unless server.require_review is turned off.
+// @Tags Products
+// @Param sku path string true "Product code"
+// @Success 204
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 403 {object} httpx.ErrorResponse
+// @Failure 404 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/products/{sku}/pack-sizes [delete]
+func (h *PackSizesHandler) Delete(c *gin.Context) {
+	if err := h.svc.Delete(c.Request.Context(), c.Param("sku")); err != nil {
+		h.writeReadError(c, "delete pack sizes failed", err)
 		return
 	}
 
-	cfg, err := h.svc.ReplacePackSizes(c.Request.Context(), req.PackSizes)
+	c.Status(http.StatusNoContent)
+}
+
+// ListProducts handles GET /api/v1/products.
+// @Summary List product pack sizes
+// @Description Returns the current pack configuration of every product, including the default profile.
+// @Tags Products
+// @Produce json
+// @Success 200 {object} ProductsResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/products [get]
+func (h *PackSizesHandler) ListProducts(c *gin.Context) {
+	configs, err := h.svc.List(c.Request.Context())
+	if err != nil {
+		h.writeReadError(c, "list products failed", err)
+		return
+	}
+
+	resp := ProductsResponse{Products: make([]ProductPackSizesResponse, 0, len(configs))}
+	for _, cfg := range configs {
+		resp.Products = append(resp.Products, ProductPackSizesResponse{
+			SKU:       cfg.SKU,
+			PackSizes: cfg.PackSizes,
+			Version:   cfg.Version,
+			UpdatedAt: cfg.UpdatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// History handles GET /api/v1/pack-sizes/history.
+// @Summary List pack size history
+// @Description Returns the stored pack configuration versions, newest first. Pass next_cursor of a page as cursor to get the next one.
+// @Tags Pack Sizes
+// @Produce json
+// @Param limit query int false "Page size (default 50, at most 500)"
+// @Param cursor query int false "next_cursor of the previous page"
+// @Success 200 {object} PackSizesHistoryResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/history [get]
+// @Router /api/v1/products/{sku}/pack-sizes/history [get]
+func (h *PackSizesHandler) History(c *gin.Context) {
+	var (
+		limit  int
+		cursor int64
+		err    error
+	)
+	if raw := c.Query("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil {
+			limit = -1
+		}
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		if cursor, err = strconv.ParseInt(raw, 10, 64); err != nil || cursor == 0 {
+			cursor = -1
+		}
+	}
+
+	page, err := h.svc.ListVersionsPage(c.Request.Context(), c.Param("sku"), cursor, limit)
+	if err != nil {
+		h.writeReadError(c, "list pack size history failed", err)
+		return
+	}
+
+	resp := PackSizesHistoryResponse{
+		Versions:   make([]PackConfigVersionResponse, 0, len(page.Versions)),
+		NextCursor: page.NextBeforeVersion,
+	}
+	for _, v := range page.Versions {
+		resp.Versions = append(resp.Versions, toVersionResponse(v))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetVersion handles GET /api/v1/pack-sizes/versions/{version}.
+// @Summary Get a pack size version
+// @Description Returns one stored pack configuration version.
+// @Tags Pack Sizes
+// @Produce json
+// @Param version path int true "Config version"
+// @Success 200 {object} PackConfigVersionResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 404 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/versions/{version} [get]
+// @Router /api/v1/products/{sku}/pack-sizes/versions/{version} [get]
+func (h *PackSizesHandler) GetVersion(c *gin.Context) {
+	version, ok := versionFromPath(c)
+	if !ok {
+		return
+	}
+
+	cfg, err := h.svc.GetVersion(c.Request.Context(), c.Param("sku"), version)
+	if err != nil {
+		h.writeReadError(c, "get pack size version failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toVersionResponse(*cfg))
+}
+
+// Rollback handles POST /api/v1/pack-sizes/rollback/{version}.
+// @Summary Roll back pack sizes
+// @Description Creates a new version that restores the pack sizes of an older version. Rejected with 403 REVIEW_REQUIRED unless server.require_review is turned off.
+// @Tags Pack Sizes
+// @Accept json
+// @Produce json
+// @Param version path int true "Version to restore"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Param request body RollbackRequest false "Optional rollback reason"
+// @Success 200 {object} PackSizesResponse
+// @Header 200 {string} ETag "Quoted config version"
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 403 {object} httpx.ErrorResponse
+// @Failure 404 {object} httpx.ErrorResponse
+// @Failure 409 {object} httpx.ErrorResponse
+// @Failure 412 {object} httpx.ErrorResponse
+// @Failure 428 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/rollback/{version} [post]
+// @Router /api/v1/products/{sku}/pack-sizes/rollback/{version} [post]
+func (h *PackSizesHandler) Rollback(c *gin.Context) {
+	var req RollbackRequest
+
+	version, ok := versionFromPath(c)
+	if !ok {
+		return
+	}
+	expectedVersion, ok := h.expectedVersion(c)
+	if !ok {
+		return
+	}
+	// The body is optional; it only carries a reason.
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httpx.WriteError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+			return
+		}
+	}
+
+	cfg, err := h.svc.Rollback(c.Request.Context(), service.RollbackInput{
+		SKU:             c.Param("sku"),
+		Version:         version,
+		ExpectedVersion: expectedVersion,
+		Actor:           actorFromRequest(c),
+		Reason:          req.Reason,
+	})
 	if err != nil {
-		switch {
-		// Conflict means another writer updated config between read and write.
-		case errors.Is(err, domain.ErrConcurrencyConflict):
-			httpx.WriteError(c, http.StatusConflict, "CONCURRENCY_CONFLICT", err.Error())
-		default:
-			h.logger.Error("replace pack sizes failed", "error", err)
-			httpx.WriteError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		h.writeWriteError(c, "rollback pack sizes failed", err)
+		return
+	}
+
+	writePackConfig(c, cfg)
+}
+
+// AddSize handles POST /api/v1/pack-sizes/{size}.
+// @Summary Add one pack size
+// @Description Adds a size, with optional metadata, to the stored sizes and keeps everything else. Without If-Match, a write that loses a race against another writer is applied again to the version that writer stored, so concurrent edits of different sizes all land. Adding a size that is configured already fails with 409 PACK_SIZE_EXISTS. A SKU without a configuration gets one holding only the new size. Rejected with 403 REVIEW_REQUIRED unless server.require_review is turned off.
+// @Tags Pack Sizes
+// @Accept json
+// @Produce json
+// @Param size path int true "Pack size"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Param request body AddPackSizeRequest false "Optional metadata and reason"
+// @Success 200 {object} PackSizesResponse
+// @Header 200 {string} ETag "Quoted config version"
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 403 {object} httpx.ErrorResponse
+// @Failure 409 {object} httpx.ErrorResponse
+// @Failure 412 {object} httpx.ErrorResponse
+// @Failure 428 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/{size} [post]
+// @Router /api/v1/products/{sku}/pack-sizes/{size} [post]
+func (h *PackSizesHandler) AddSize(c *gin.Context) {
+	var req AddPackSizeRequest
+
+	size, ok := sizeFromPath(c)
+	if !ok {
+		return
+	}
+	expectedVersion, ok := h.expectedVersion(c)
+	if !ok {
+		return
+	}
+	// The body is optional; without it the size is active and has no metadata.
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httpx.WriteError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+			return
 		}
+	}
+
+	packs := packsFromRequest([]PackSizeDetails{{
+		Size:            size,
+		Label:           req.Label,
+		PackagingSKU:    req.PackagingSKU,
+		Dimensions:      req.Dimensions,
+		TareWeightGrams: req.TareWeightGrams,
+		Active:          req.Active,
+	}})
+	cfg, err := h.svc.AddPackSize(c.Request.Context(), service.EditPackSizeInput{
+		SKU:             c.Param("sku"),
+		Pack:            packs[0],
+		ExpectedVersion: expectedVersion,
+		Actor:           actorFromRequest(c),
+		Reason:          req.Reason,
+	})
+	if err != nil {
+		h.writeWriteError(c, "add pack size failed", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"pack_sizes": cfg.PackSizes,
+	writePackConfig(c, cfg)
+}
+
+// RemoveSize handles DELETE /api/v1/pack-sizes/{size}.
+// @Summary Remove one pack size
+// @Description Removes a size with its metadata, stock limit and cost, and keeps the other sizes. Concurrent writes are retried like on POST. Removing a size that is not configured fails with 404 PACK_SIZE_NOT_FOUND, and at least one active size must remain. Rejected with 403 REVIEW_REQUIRED unless server.require_review is turned off.
+// @Tags Pack Sizes
+// @Produce json
+// @Param size path int true "Pack size"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Success 200 {object} PackSizesResponse
+// @Header 200 {string} ETag "Quoted config version"
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 403 {object} httpx.ErrorResponse
+// @Failure 404 {object} httpx.ErrorResponse
+// @Failure 409 {object} httpx.ErrorResponse
+// @Failure 412 {object} httpx.ErrorResponse
+// @Failure 428 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/pack-sizes/{size} [delete]
+// @Router /api/v1/products/{sku}/pack-sizes/{size} [delete]
+func (h *PackSizesHandler) RemoveSize(c *gin.Context) {
+	size, ok := sizeFromPath(c)
+	if !ok {
+		return
+	}
+	expectedVersion, ok := h.expectedVersion(c)
+	if !ok {
+		return
+	}
+
+	cfg, err := h.svc.RemovePackSize(c.Request.Context(), service.EditPackSizeInput{
+		SKU:             c.Param("sku"),
+		Pack:            domain.NewPackSize(size),
+		ExpectedVersion: expectedVersion,
+		Actor:           actorFromRequest(c),
 	})
+	if err != nil {
+		h.writeWriteError(c, "remove pack size failed", err)
+		return
+	}
+
+	writePackConfig(c, cfg)
+}
+
+// replaceInputFromRequest binds a pack-sizes body and writes a 400 when it is invalid.
+func replaceInputFromRequest(c *gin.Context, expectedVersion *int64) (service.ReplacePackSizesInput, bool) {
+	var req PackSizesRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return service.ReplacePackSizesInput{}, false
+	}
+	stockLimits, ok := stockLimitsFromRequest(req.StockLimits)
+	if !ok {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_STOCK_LIMITS", domain.ErrInvalidStockLimits.Error())
+		return service.ReplacePackSizesInput{}, false
+	}
+	packCosts, ok := packCostsFromRequest(req.PackCosts)
+	if !ok {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_PACK_COSTS", domain.ErrInvalidPackCosts.Error())
+		return service.ReplacePackSizesInput{}, false
+	}
+
+	return service.ReplacePackSizesInput{
+		SKU:              c.Param("sku"),
+		PackSizes:        req.PackSizes,
+		Packs:            packsFromRequest(req.Packs),
+		StockLimits:      stockLimits,
+		PackCosts:        packCosts,
+		OverfillItemCost: req.OverfillItemCost,
+		Rules:            req.Rules,
+		EffectiveFrom:    req.EffectiveFrom,
+		EffectiveTo:      req.EffectiveTo,
+		ExpectedVersion:  expectedVersion,
+		Actor:            actorFromRequest(c),
+		Reason:           req.Reason,
+	}, true
+}
+
+// expectedVersion reads If-Match and writes the error response when it is missing or malformed.
+func (h *PackSizesHandler) expectedVersion(c *gin.Context) (*int64, bool) {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" && h.requireIfMatch {
+		httpx.WriteError(c, http.StatusPreconditionRequired, "PRECONDITION_REQUIRED", "If-Match header is required")
+		return nil, false
+	}
+
+	// "*" matches any stored version, so it fails only when there is no config.
+	if strings.TrimSpace(ifMatch) == "*" {
+		anyVersion := domain.AnyVersion
+		return &anyVersion, true
+	}
+
+	expectedVersion, err := httpx.ParseIfMatch(ifMatch)
+	if err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_IF_MATCH", err.Error())
+		return nil, false
+	}
+
+	return expectedVersion, true
+}
+
+// writeReadError maps errors from config reads to HTTP responses.
+func (h *PackSizesHandler) writeReadError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidSKU):
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_SKU", err.Error())
+	case errors.Is(err, domain.ErrInvalidHistoryPage):
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_HISTORY_PAGE", err.Error())
+	case errors.Is(err, domain.ErrVersionNotFound):
+		httpx.WriteError(c, http.StatusNotFound, "VERSION_NOT_FOUND", err.Error())
+	case errors.Is(err, domain.ErrPackConfigNotFound):
+		httpx.WriteError(c, http.StatusNotFound, "PACK_CONFIG_NOT_FOUND", err.Error())
+	case errors.Is(err, domain.ErrReviewRequired):
+		httpx.WriteError(c, http.StatusForbidden, "REVIEW_REQUIRED", err.Error())
+	default:
+		h.logger.Error(msg, "error", err, "sku", c.Param("sku"))
+		httpx.WriteError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+	}
+}
+
+// writeWriteError maps errors from config writes to HTTP responses.
+func (h *PackSizesHandler) writeWriteError(c *gin.Context, msg string, err error) {
+	writeConfigWriteError(c, h.logger, msg, err)
+}
+
+// writeConfigWriteError maps errors from config and draft writes to HTTP responses.
+func writeConfigWriteError(c *gin.Context, logger *slog.Logger, msg string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidSKU):
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_SKU", err.Error())
+	case errors.Is(err, domain.ErrInvalidPackSizes):
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_PACK_SIZES", err.Error(), validationDetails(err)...)
+	case errors.Is(err, domain.ErrPackSizeExists):
+		httpx.WriteError(c, http.StatusConflict, "PACK_SIZE_EXISTS", err.Error())
+	case errors.Is(err, domain.ErrPackSizeNotFound):
+		httpx.WriteError(c, http.StatusNotFound, "PACK_SIZE_NOT_FOUND", err.Error())
+	case errors.Is(err, domain.ErrInvalidPackMetadata):
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_PACK_METADATA", err.Error())
+	case errors.Is(err, domain.ErrNoActivePackSizes):
+		httpx.WriteError(c, http.StatusBadRequest, "NO_ACTIVE_PACK_SIZES", err.Error())
+	case errors.Is(err, domain.ErrInvalidStockLimits):
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_STOCK_LIMITS", err.Error())
+	case errors.Is(err, domain.ErrInvalidPackCosts):
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_PACK_COSTS", err.Error())
+	case errors.Is(err, domain.ErrInvalidRules):
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_RULES", err.Error())
+	case errors.Is(err, domain.ErrInvalidSchedule):
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_SCHEDULE", err.Error())
+	case errors.Is(err, domain.ErrVersionNotFound):
+		httpx.WriteError(c, http.StatusNotFound, "VERSION_NOT_FOUND", err.Error())
+	case errors.Is(err, domain.ErrReviewRequired):
+		httpx.WriteError(c, http.StatusForbidden, "REVIEW_REQUIRED", err.Error())
+	// Precondition failed means the client edited a version that is no longer current.
+	case errors.Is(err, domain.ErrVersionMismatch):
+		httpx.WriteError(c, http.StatusPreconditionFailed, "VERSION_MISMATCH", err.Error())
+	// Conflict means another writer updated config between read and write.
+	case errors.Is(err, domain.ErrConcurrencyConflict):
+		httpx.WriteError(c, http.StatusConflict, "CONCURRENCY_CONFLICT", err.Error())
+	default:
+		logger.Error(msg, "error", err, "sku", c.Param("sku"))
+		httpx.WriteError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+	}
+}
+
+// writePackConfig renders a stored config and exposes its version as ETag.
+func writePackConfig(c *gin.Context, cfg *domain.PackConfig) {
+	writePackSizesResponse(c, packSizesResponse(cfg))
+}
+
+func writePackSizesResponse(c *gin.Context, resp PackSizesResponse) {
+	c.Header("ETag", httpx.VersionETag(*resp.Version))
+	c.JSON(http.StatusOK, resp)
+}
+
+func packSizesResponse(cfg *domain.PackConfig) PackSizesResponse {
+	version, updatedAt := cfg.Version, cfg.UpdatedAt
+
+	return PackSizesResponse{
+		PackSizes:        cfg.PackSizes,
+		Packs:            packsResponse(cfg),
+		Version:          &version,
+		UpdatedAt:        &updatedAt,
+		UpdatedBy:        cfg.UpdatedBy,
+		Reason:           cfg.Reason,
+		StockLimits:      stockLimitsResponse(cfg.StockLimits),
+		PackCosts:        packCostsResponse(cfg.PackCosts),
+		OverfillItemCost: cfg.OverfillItemCost,
+		Rules:            cfg.Rules,
+		EffectiveFrom:    cfg.EffectiveFrom,
+		EffectiveTo:      cfg.EffectiveTo,
+	}
+}
+
+func toVersionResponse(cfg domain.PackConfig) PackConfigVersionResponse {
+	return PackConfigVersionResponse{
+		Version:          cfg.Version,
+		PackSizes:        cfg.PackSizes,
+		Packs:            packsResponse(&cfg),
+		ChangedAt:        cfg.UpdatedAt,
+		ChangedBy:        cfg.UpdatedBy,
+		Reason:           cfg.Reason,
+		StockLimits:      stockLimitsResponse(cfg.StockLimits),
+		PackCosts:        packCostsResponse(cfg.PackCosts),
+		OverfillItemCost: cfg.OverfillItemCost,
+		Rules:            cfg.Rules,
+		EffectiveFrom:    cfg.EffectiveFrom,
+		EffectiveTo:      cfg.EffectiveTo,
+	}
+}
+
+// actorFromRequest identifies who made a change. The service has no auth and
+// trusts the header as sent: it records authors and keeps draft authors from
+// approving their own drafts only as far as X-Actor can be trusted, so deploy
+// behind a proxy that authenticates callers and sets the header itself.
+func actorFromRequest(c *gin.Context) string {
+	return strings.TrimSpace(c.GetHeader("X-Actor"))
+}
+
+// asOfFromQuery parses the optional as_of query parameter and writes a 400 when
+// it is not an RFC 3339 time. A missing parameter yields the zero time, meaning now.
+func asOfFromQuery(c *gin.Context) (time.Time, bool) {
+	raw := c.Query("as_of")
+	if raw == "" {
+		return time.Time{}, true
+	}
+
+	asOf, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_AS_OF", "as_of must be an RFC 3339 time")
+		return time.Time{}, false
+	}
+
+	return asOf, true
+}
+
+// versionFromPath parses the :version path parameter and writes a 400 when it is invalid.
+func versionFromPath(c *gin.Context) (int64, bool) {
+	version, err := strconv.ParseInt(c.Param("version"), 10, 64)
+	if err != nil || version < 0 {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_VERSION", "version must be a non-negative integer")
+		return 0, false
+	}
+
+	return version, true
 }
 
-func isValidPackSizes(packSizes []int64) bool {
-	if len(packSizes) == 0 {
-		return false
+// sizeFromPath parses the :size path parameter and writes a 400 when it is invalid.
+func sizeFromPath(c *gin.Context) (int64, bool) {
+	size, err := strconv.ParseInt(c.Param("size"), 10, 64)
+	if err != nil || size <= 0 {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_PACK_SIZE", "pack size must be a positive integer")
+		return 0, false
 	}
 
-	// Validation keeps persistence and solver assumptions simple.
-	seen := make(map[int64]struct{}, len(packSizes))
-	for _, size := range packSizes {
-		if size <= 0 {
-			return false
+	return size, true
+}
+
+// validationDetails lists the field violations of a domain validation error;
+// other errors have none.
+func validationDetails(err error) []httpx.ErrorDetail {
+	var verr *domain.ValidationError
+	if !errors.As(err, &verr) {
+		return nil
+	}
+
+	details := make([]httpx.ErrorDetail, 0, len(verr.Violations))
+	for _, v := range verr.Violations {
+		details = append(details, httpx.ErrorDetail{Field: v.Field, Reason: v.Reason})
+	}
+
+	return details
+}
+
+// packsFromRequest converts request metadata to domain sizes; an omitted
+// active flag means active. The domain rejects unknown and repeated sizes.
+// Omitted metadata stays nil, while an empty list does not.
+func packsFromRequest(packs []PackSizeDetails) []domain.PackSize {
+	if packs == nil {
+		return nil
+	}
+
+	result := make([]domain.PackSize, 0, len(packs))
+	for _, p := range packs {
+		pack := domain.PackSize{
+			Size:            p.Size,
+			Label:           p.Label,
+			PackagingSKU:    p.PackagingSKU,
+			TareWeightGrams: p.TareWeightGrams,
+			Active:          p.Active == nil || *p.Active,
+		}
+		if p.Dimensions != nil {
+			pack.Dimensions = *p.Dimensions
+		}
+		result = append(result, pack)
+	}
+
+	return result
+}
+
+// packsResponse lists every size of a config with its metadata.
+func packsResponse(cfg *domain.PackConfig) []PackSizeDetails {
+	packs := cfg.ListPacks()
+	result := make([]PackSizeDetails, 0, len(packs))
+	for _, p := range packs {
+		active := p.Active
+		result = append(result, PackSizeDetails{
+			Size:            p.Size,
+			Label:           p.Label,
+			PackagingSKU:    p.PackagingSKU,
+			Dimensions:      dimensionsResponse(p.Dimensions),
+			TareWeightGrams: p.TareWeightGrams,
+			Active:          &active,
+		})
+	}
+
+	return result
+}
+
+// dimensionsResponse leaves unknown dimensions out of responses.
+func dimensionsResponse(d domain.PackDimensions) *domain.PackDimensions {
+	if d.IsZero() {
+		return nil
+	}
+
+	return &d
+}
+
+// stockLimitsFromRequest converts request limits to a map; a size listed twice
+// is invalid. Omitted limits stay nil, while an empty list does not.
+func stockLimitsFromRequest(limits []StockLimit) (map[int64]int, bool) {
+	if limits == nil {
+		return nil, true
+	}
+
+	result := make(map[int64]int, len(limits))
+	for _, l := range limits {
+		if _, exists := result[l.Size]; exists {
+			return nil, false
+		}
+		result[l.Size] = l.Available
+	}
+
+	return result, true
+}
+
+func stockLimitsResponse(limits map[int64]int) []StockLimit {
+	result := make([]StockLimit, 0, len(limits))
+	for size, available := range limits {
+		result = append(result, StockLimit{Size: size, Available: available})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Size < result[j].Size
+	})
+
+	return result
+}
+
+// packCostsFromRequest converts request costs to a map; a size listed twice
+// is invalid. Omitted costs stay nil, while an empty list does not.
+func packCostsFromRequest(costs []PackSizeCost) (map[int64]domain.PackCost, bool) {
+	if costs == nil {
+		return nil, true
+	}
+
+	result := make(map[int64]domain.PackCost, len(costs))
+	for _, c := range costs {
+		if _, exists := result[c.Size]; exists {
+			return nil, false
+		}
+		result[c.Size] = domain.PackCost{UnitCost: c.UnitCost, HandlingCost: c.HandlingCost}
+	}
+
+	return result, true
+}
+
+func packCostsResponse(costs map[int64]domain.PackCost) []PackSizeCost {
+	result := make([]PackSizeCost, 0, len(costs))
+	for size, c := range costs {
+		result = append(result, PackSizeCost{Size: size, UnitCost: c.UnitCost, HandlingCost: c.HandlingCost})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Size < result[j].Size
+	})
+
+	return result
+}
+
+// analysisBoundFromQuery parses the optional up_to query parameter and writes a 400 when it is invalid.
+func analysisBoundFromQuery(c *gin.Context) (int, bool) {
+	raw := c.Query("up_to")
+	if raw == "" {
+		return 0, true
+	}
+
+	upTo, err := strconv.Atoi(raw)
+	if err != nil || upTo < 1 || upTo > service.MaxAnalysisBound {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_UP_TO", fmt.Sprintf("up_to must be between 1 and %d", service.MaxAnalysisBound))
+		return 0, false
+	}
+
+	return upTo, true
+}
+
+func toAnalysisResponse(analysis service.PackAnalysis) PackSizesAnalysisResponse {
+	resp := PackSizesAnalysisResponse{
+		GCD:                  analysis.GCD,
+		FrobeniusNumber:      analysis.FrobeniusNumber,
+		UnreachableCount:     analysis.UnreachableCount,
+		RedundantForOverfill: analysis.RedundantForOverfill,
+		UnusedSizes:          analysis.UnusedSizes,
+		UpTo:                 analysis.UpTo,
+		OverfillRanges:       make([]OverfillRangeResponse, 0, len(analysis.OverfillRanges)),
+		Warnings:             analysis.Warnings,
+	}
+	for _, r := range analysis.OverfillRanges {
+		resp.OverfillRanges = append(resp.OverfillRanges, OverfillRangeResponse{
+			From:        r.From,
+			To:          r.To,
+			MaxOverfill: r.MaxOverfill,
+			WorstAmount: r.WorstAmount,
+		})
+	}
+
+	return resp
+}
+
+func toRecommendedPackSizesResponse(set service.RecommendedPackSizes) RecommendedPackSizesResponse {
+	return RecommendedPackSizesResponse{
+		PackSizes:         set.PackSizes,
+		ExpectedOverfill:  set.ExpectedOverfill,
+		ExpectedPackCount: set.ExpectedPackCount,
+		OverfillPct:       set.OverfillPct,
+		ExactShare:        set.ExactShare,
+	}
+}
+
+// previewAmountsFromQuery parses the optional sample query parameter and writes
+// a 400 when it is invalid; without it the configured amounts are used.
+func previewAmountsFromQuery(c *gin.Context, defaults []int) ([]int, bool) {
+	raw := c.Query("sample")
+	if raw == "" {
+		return defaults, true
+	}
+
+	fields := strings.Split(raw, ",")
+	amounts := make([]int, 0, len(fields))
+	for _, field := range fields {
+		amount, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || domain.ValidateAmount(amount) != nil || len(fields) > service.MaxPreviewAmounts {
+			httpx.WriteError(c, http.StatusBadRequest, "INVALID_SAMPLE", fmt.Sprintf("sample must list 1 to %d amounts from 1 to %d", service.MaxPreviewAmounts, domain.MaxAmount))
+			return nil, false
+		}
+		amounts = append(amounts, amount)
+	}
+
+	return amounts, true
+}
+
+func toPreviewResponse(preview *service.ReplacePreview) PackSizesPreviewResponse {
+	resp := PackSizesPreviewResponse{
+		DryRun:    true,
+		SKU:       preview.Proposed.SKU,
+		PackSizes: preview.Proposed.PackSizes,
+		Added:     append([]int64{}, preview.Added...),
+		Removed:   append([]int64{}, preview.Removed...),
+		Warnings:  append([]string{}, preview.Warnings...),
+		Changes:   make([]BreakdownChangeResponse, 0, len(preview.Changes)),
+	}
+	if preview.Current != nil {
+		version := preview.Current.Version
+		resp.CurrentVersion = &version
+		resp.CurrentPackSizes = preview.Current.PackSizes
+	}
+	for _, change := range preview.Changes {
+		item := BreakdownChangeResponse{Amount: change.Amount, Changed: change.Changed}
+		if change.Current != nil {
+			current := toCandidateResponse(change.Amount, *change.Current, preview.Current.Packs)
+			item.Current = &current
+		}
+		if change.Proposed != nil {
+			proposed := toCandidateResponse(change.Amount, *change.Proposed, preview.Proposed.Packs)
+			item.Proposed = &proposed
 		}
-		if _, exists := seen[size]; exists {
-			return false
+		if change.Changed {
+			resp.ChangedCount++
 		}
-		seen[size] = struct{}{}
+		resp.Changes = append(resp.Changes, item)
 	}
 
-	return true
+	return resp
 }
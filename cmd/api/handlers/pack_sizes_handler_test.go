@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go-packing/internal/domain"
+	"go-packing/internal/infrastructure/memory"
+	"go-packing/internal/service"
+	"go-packing/pkg/httpx"
+)
+
+// newPackSizesRouter serves the unscoped pack-size routes, and delete of a
+// product, over an empty memory store.
+func newPackSizesRouter(t *testing.T, requireIfMatch, requireReview bool) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := memory.NewPackConfigRepository(memory.NewStore())
+	svc := service.NewPackConfigService(repo, nil, logger, domain.PackSizeLimits{}, requireReview)
+	h := NewPackSizesHandler(svc, logger, requireIfMatch, []int{1, 251})
+
+	r := gin.New()
+	r.GET("/pack-sizes", h.Get)
+	r.PUT("/pack-sizes", h.Replace)
+	r.GET("/pack-sizes/history", h.History)
+	r.POST("/pack-sizes/rollback/:version", h.Rollback)
+	r.POST("/pack-sizes/:size", h.AddSize)
+	r.DELETE("/products/:sku/pack-sizes", h.Delete)
+	return r
+}
+
+// serve sends a request with an optional JSON body and If-Match header.
+func serve(r *gin.Engine, method, path, body, ifMatch string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// assertError checks the status and error code of a response.
+func assertError(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d: %s", w.Code, status, w.Body)
+	}
+	var resp httpx.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error.Code != code {
+		t.Fatalf("error code = %q, %v; want %q", resp.Error.Code, err, code)
+	}
+}
+
+func TestPackSizesHandler_ETag(t *testing.T) {
+	r := newPackSizesRouter(t, false, false)
+
+	w := serve(r, http.MethodPut, "/pack-sizes", `{"pack_sizes":[250,500]}`, "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"0"` {
+		t.Fatalf("create = %d, ETag %q: %s", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+	w = serve(r, http.MethodGet, "/pack-sizes", "", "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"0"` {
+		t.Fatalf("get = %d, ETag %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	w = serve(r, http.MethodPut, "/pack-sizes", `{"pack_sizes":[250,500,1000]}`, `"0"`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("update = %d, ETag %q: %s", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+
+	// The client read version 0, which is no longer current.
+	assertError(t, serve(r, http.MethodPut, "/pack-sizes", `{"pack_sizes":[100]}`, `"0"`), http.StatusPreconditionFailed, "VERSION_MISMATCH")
+	assertError(t, serve(r, http.MethodPost, "/pack-sizes/100", "", `"0"`), http.StatusPreconditionFailed, "VERSION_MISMATCH")
+	assertError(t, serve(r, http.MethodPut, "/pack-sizes", `{"pack_sizes":[100]}`, `"one"`), http.StatusBadRequest, "INVALID_IF_MATCH")
+
+	w = serve(r, http.MethodGet, "/pack-sizes", "", "")
+	var got PackSizesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || *got.Version != 1 || len(got.PackSizes) != 3 {
+		t.Fatalf("stored config = %+v, %v", got, err)
+	}
+}
+
+func TestPackSizesHandler_ReplaceKeepsOmittedSettings(t *testing.T) {
+	r := newPackSizesRouter(t, false, false)
+
+	seed := `{"pack_sizes":[250,500],"stock_limits":[{"size":500,"available":3}],` +
+		`"pack_costs":[{"size":250,"unit_cost":3},{"size":500,"unit_cost":5}],"overfill_item_cost":2,` +
+		`"rules":[{"criterion":"pack_count"}],"packs":[{"size":500,"label":"Medium box"}]}`
+	if w := serve(r, http.MethodPut, "/pack-sizes", seed, ""); w.Code != http.StatusOK {
+		t.Fatalf("seed = %d: %s", w.Code, w.Body)
+	}
+
+	// What the UI sends: only the sizes.
+	w := serve(r, http.MethodPut, "/pack-sizes", `{"pack_sizes":[250,500,1000]}`, "")
+	var got PackSizesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("sizes only = %d, %v: %s", w.Code, err, w.Body)
+	}
+	if len(got.StockLimits) != 1 || len(got.PackCosts) != 2 || got.OverfillItemCost != 2 || len(got.Rules) != 1 || got.Packs[1].Label != "Medium box" {
+		t.Fatalf("sizes only dropped settings: %+v", got)
+	}
+
+	w = serve(r, http.MethodPut, "/pack-sizes", `{"pack_sizes":[250,500,1000],"stock_limits":[],"pack_costs":[]}`, "")
+	got = PackSizesResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("clear = %d, %v: %s", w.Code, err, w.Body)
+	}
+	if len(got.StockLimits) != 0 || len(got.PackCosts) != 0 || got.OverfillItemCost != 2 || len(got.Rules) != 1 {
+		t.Fatalf("empty lists did not clear exactly their settings: %+v", got)
+	}
+}
+
+func TestPackSizesHandler_IfMatchRequired(t *testing.T) {
+	r := newPackSizesRouter(t, true, false)
+
+	assertError(t, serve(r, http.MethodPut, "/pack-sizes", `{"pack_sizes":[250]}`, ""), http.StatusPreconditionRequired, "PRECONDITION_REQUIRED")
+	assertError(t, serve(r, http.MethodPost, "/pack-sizes/250", "", ""), http.StatusPreconditionRequired, "PRECONDITION_REQUIRED")
+	assertError(t, serve(r, http.MethodPost, "/pack-sizes/rollback/0", "", ""), http.StatusPreconditionRequired, "PRECONDITION_REQUIRED")
+
+	// Dry runs are validated like real writes.
+	assertError(t, serve(r, http.MethodPut, "/pack-sizes?dry_run=true", `{"pack_sizes":[250]}`, ""), http.StatusPreconditionRequired, "PRECONDITION_REQUIRED")
+}
+
+func TestPackSizesHandler_IfMatchAny(t *testing.T) {
+	r := newPackSizesRouter(t, false, false)
+
+	// "*" requires a current config, and there is none yet.
+	assertError(t, serve(r, http.MethodPut, "/pack-sizes", `{"pack_sizes":[250]}`, "*"), http.StatusPreconditionFailed, "VERSION_MISMATCH")
+	assertError(t, serve(r, http.MethodPost, "/pack-sizes/250", "", "*"), http.StatusPreconditionFailed, "VERSION_MISMATCH")
+	if w := serve(r, http.MethodGet, "/pack-sizes/history", "", ""); !strings.Contains(w.Body.String(), `"versions":[]`) {
+		t.Fatalf("history after rejected writes = %s", w.Body)
+	}
+
+	if w := serve(r, http.MethodPut, "/pack-sizes", `{"pack_sizes":[250]}`, ""); w.Code != http.StatusOK {
+		t.Fatalf("create = %d: %s", w.Code, w.Body)
+	}
+	// Once a config exists, "*" matches whatever version is current.
+	for i, body := range []string{`{"pack_sizes":[250,500]}`, `{"pack_sizes":[250,500,1000]}`} {
+		w := serve(r, http.MethodPut, "/pack-sizes", body, "*")
+		if w.Code != http.StatusOK || w.Header().Get("ETag") != httpx.VersionETag(int64(i+1)) {
+			t.Fatalf("update %d with * = %d, ETag %q: %s", i, w.Code, w.Header().Get("ETag"), w.Body)
+		}
+	}
+	if w := serve(r, http.MethodPost, "/pack-sizes/2000", "", "*"); w.Code != http.StatusOK || w.Header().Get("ETag") != `"3"` {
+		t.Fatalf("add size with * = %d, ETag %q: %s", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+}
+
+func TestPackSizesHandler_Rollback(t *testing.T) {
+	r := newPackSizesRouter(t, false, false)
+	for _, body := range []string{`{"pack_sizes":[250]}`, `{"pack_sizes":[250,500]}`} {
+		if w := serve(r, http.MethodPut, "/pack-sizes", body, ""); w.Code != http.StatusOK {
+			t.Fatalf("write = %d: %s", w.Code, w.Body)
+		}
+	}
+
+	assertError(t, serve(r, http.MethodPost, "/pack-sizes/rollback/9", "", ""), http.StatusNotFound, "VERSION_NOT_FOUND")
+	assertError(t, serve(r, http.MethodPost, "/pack-sizes/rollback/x", "", ""), http.StatusBadRequest, "INVALID_VERSION")
+	assertError(t, serve(r, http.MethodPost, "/pack-sizes/rollback/0", "", `"0"`), http.StatusPreconditionFailed, "VERSION_MISMATCH")
+
+	w := serve(r, http.MethodPost, "/pack-sizes/rollback/0", `{"reason":"500 boxes ran out"}`, `"1"`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("rollback = %d, ETag %q: %s", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+	var got PackSizesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || len(got.PackSizes) != 1 || got.Reason != "500 boxes ran out" {
+		t.Fatalf("rolled back config = %+v, %v", got, err)
+	}
+}
+
+func TestPackSizesHandler_HistoryPages(t *testing.T) {
+	r := newPackSizesRouter(t, false, false)
+	for _, body := range []string{`{"pack_sizes":[1]}`, `{"pack_sizes":[2]}`, `{"pack_sizes":[3]}`} {
+		if w := serve(r, http.MethodPut, "/pack-sizes", body, ""); w.Code != http.StatusOK {
+			t.Fatalf("write = %d: %s", w.Code, w.Body)
+		}
+	}
+
+	var versions []int64
+	path := "/pack-sizes/history?limit=2"
+	for range 3 {
+		w := serve(r, http.MethodGet, path, "", "")
+		var page PackSizesHistoryResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &page); w.Code != http.StatusOK || err != nil {
+			t.Fatalf("history = %d, %v: %s", w.Code, err, w.Body)
+		}
+		for _, v := range page.Versions {
+			versions = append(versions, v.Version)
+		}
+		if page.NextCursor == 0 {
+			break
+		}
+		path = "/pack-sizes/history?limit=2&cursor=" + strconv.FormatInt(page.NextCursor, 10)
+	}
+	if fmt.Sprint(versions) != "[2 1 0]" {
+		t.Fatalf("versions = %v", versions)
+	}
+
+	for _, query := range []string{"limit=0x", "limit=501", "cursor=0", "cursor=-3"} {
+		assertError(t, serve(r, http.MethodGet, "/pack-sizes/history?"+query, "", ""), http.StatusBadRequest, "INVALID_HISTORY_PAGE")
+	}
+}
+
+func TestPackSizesHandler_ReviewRequired(t *testing.T) {
+	r := newPackSizesRouter(t, false, true)
+
+	assertError(t, serve(r, http.MethodPut, "/pack-sizes", `{"pack_sizes":[250]}`, ""), http.StatusForbidden, "REVIEW_REQUIRED")
+	assertError(t, serve(r, http.MethodPost, "/pack-sizes/250", "", ""), http.StatusForbidden, "REVIEW_REQUIRED")
+	assertError(t, serve(r, http.MethodPost, "/pack-sizes/rollback/0", "", ""), http.StatusForbidden, "REVIEW_REQUIRED")
+	assertError(t, serve(r, http.MethodDelete, "/products/widget/pack-sizes", "", ""), http.StatusForbidden, "REVIEW_REQUIRED")
+
+	if w := serve(r, http.MethodPut, "/pack-sizes?dry_run=true", `{"pack_sizes":[250]}`, ""); w.Code != http.StatusOK {
+		t.Fatalf("dry run = %d: %s", w.Code, w.Body)
+	}
+	if w := serve(r, http.MethodGet, "/pack-sizes/history", "", ""); !strings.Contains(w.Body.String(), `"versions":[]`) {
+		t.Fatalf("history after rejected writes = %s", w.Body)
+	}
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"go-packing/internal/domain"
+	"go-packing/internal/service"
+	"go-packing/pkg/httpx"
+)
+
+type ReplaysHandler struct {
+	jobs            *service.ReplayJobs
+	logger          *slog.Logger
+	maxCalculations int
+}
+
+// NewReplaysHandler builds handlers that start replays and report their progress.
+// maxCalculations caps an uploaded export; 0 means no cap.
+func NewReplaysHandler(jobs *service.ReplayJobs, logger *slog.Logger, maxCalculations int) *ReplaysHandler {
+	return &ReplaysHandler{jobs: jobs, logger: logger, maxCalculations: maxCalculations}
+}
+
+// Start handles POST /api/v1/replays.
+// @Summary Start a replay
+// @Description Re-runs past calculations with their stored options on the config version active now, or on the given version, and reports every breakdown that would change. Send JSON to replay stored calculations matching the filters, or multipart/form-data with an export file "calculations" (NDJSON shaped like GET /calculations, or CSV with an amount and a packs column such as "500:1;250:1"), an optional "format" (ndjson or csv, otherwise guessed from the file name) and an optional "version". The replay runs in the background; poll the Location header for progress and the report. Replayed answers are not stored.
+// @Tags Replays
+// @Accept json
+// @Accept mpfd
+// @Produce json
+// @Param request body ReplayRequest false "Calculations to replay"
+// @Success 202 {object} ReplayJobResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 429 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/replays [post]
+func (h *ReplaysHandler) Start(c *gin.Context) {
+	in, ok := h.replayInput(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.jobs.Start(in)
+	if err != nil {
+		h.writeReplayError(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/replays/%d", job.ID))
+	c.JSON(http.StatusAccepted, toReplayJobResponse(job))
+}
+
+// Get handles GET /api/v1/replays/{id}.
+// @Summary Get a replay
+// @Description Returns the progress of a replay and, once it is done, its report. Finished replays are kept in memory for a while and are lost on restart.
+// @Tags Replays
+// @Produce json
+// @Param id path int true "Replay ID"
+// @Success 200 {object} ReplayJobResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 404 {object} httpx.ErrorResponse
+// @Router /api/v1/replays/{id} [get]
+func (h *ReplaysHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_REPLAY_ID", "replay id must be a positive integer")
+		return
+	}
+
+	job, err := h.jobs.Get(id)
+	if err != nil {
+		h.writeReplayError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toReplayJobResponse(job))
+}
+
+// replayInput reads a JSON filter or a multipart export upload, and writes a
+// 400 when neither can be read.
+func (h *ReplaysHandler) replayInput(c *gin.Context) (service.ReplayInput, bool) {
+	var in service.ReplayInput
+	if c.ContentType() != gin.MIMEMultipartPOSTForm {
+		var req ReplayRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				httpx.WriteError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+				return in, false
+			}
+		}
+		in.Filter = domain.CalculationFilter{SKU: req.SKU, MinAmount: req.MinAmount, MaxAmount: req.MaxAmount}
+		if req.From != nil {
+			in.Filter.From = *req.From
+		}
+		if req.To != nil {
+			in.Filter.To = *req.To
+		}
+		if req.Version != nil && *req.Version < 0 {
+			httpx.WriteError(c, http.StatusBadRequest, "INVALID_VERSION", "version must be a non-negative integer")
+			return in, false
+		}
+		in.Version = req.Version
+		return in, true
+	}
+
+	if raw := c.PostForm("version"); raw != "" {
+		version, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || version < 0 {
+			httpx.WriteError(c, http.StatusBadRequest, "INVALID_VERSION", "version must be a non-negative integer")
+			return in, false
+		}
+		in.Version = &version
+	}
+
+	header, err := c.FormFile("calculations")
+	if err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_REQUEST", "calculations export file is required")
+		return in, false
+	}
+	file, err := header.Open()
+	if err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_REQUEST", "calculations export file cannot be read")
+		return in, false
+	}
+	defer file.Close()
+
+	format := c.PostForm("format")
+	if format == "" {
+		format = service.ExportFormatOf(header.Filename)
+	}
+	in.Calculations, err = service.ReadCalculations(file, format, h.maxCalculations)
+	if err != nil {
+		h.writeReplayError(c, err)
+		return in, false
+	}
+
+	return in, true
+}
+
+func (h *ReplaysHandler) writeReplayError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCalculationFilter):
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_CALCULATION_FILTER", err.Error())
+	case errors.Is(err, domain.ErrInvalidSKU):
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_SKU", err.Error())
+	case errors.Is(err, domain.ErrInvalidExport):
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_EXPORT", err.Error())
+	case errors.Is(err, domain.ErrReplayTooLarge):
+		httpx.WriteError(c, http.StatusBadRequest, "REPLAY_TOO_LARGE", err.Error())
+	case errors.Is(err, domain.ErrReplayBusy):
+		httpx.WriteError(c, http.StatusTooManyRequests, "REPLAYS_BUSY", err.Error())
+	case errors.Is(err, domain.ErrReplayNotFound):
+		httpx.WriteError(c, http.StatusNotFound, "REPLAY_NOT_FOUND", err.Error())
+	default:
+		h.logger.Error("replay failed", "error", err)
+		httpx.WriteError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+	}
+}
+
+func toReplayJobResponse(job service.ReplayJob) ReplayJobResponse {
+	resp := ReplayJobResponse{
+		ID:        job.ID,
+		Status:    job.Status,
+		Done:      job.Done,
+		Total:     job.Total,
+		CreatedAt: job.CreatedAt,
+	}
+	if !job.FinishedAt.IsZero() {
+		finishedAt := job.FinishedAt
+		resp.FinishedAt = &finishedAt
+	}
+	if job.Err != nil {
+		resp.Error = job.Err.Error()
+	}
+	if job.Report != nil {
+		report := ToReplayReportResponse(job.Report)
+		resp.Report = &report
+	}
+
+	return resp
+}
+
+// ToReplayReportResponse is the JSON form of a replay report, shared by the
+// API and the replay command.
+func ToReplayReportResponse(report *service.ReplayReport) ReplayReportResponse {
+	resp := ReplayReportResponse{
+		Replayed:       report.Replayed,
+		Unchanged:      report.Unchanged,
+		Changed:        make([]ReplayChangeResponse, 0, len(report.Changed)),
+		Failed:         make([]ReplayFailureResponse, 0, len(report.Failed)),
+		OverfillDelta:  report.OverfillDelta,
+		PackCountDelta: report.PackCountDelta,
+	}
+	for _, change := range report.Changed {
+		resp.Changed = append(resp.Changed, ReplayChangeResponse{
+			CalculationID:  change.CalculationID,
+			SKU:            change.SKU,
+			Amount:         change.Amount,
+			StoredVersion:  change.StoredVersion,
+			ReplayVersion:  change.ReplayVersion,
+			Before:         change.Before,
+			After:          change.After,
+			OverfillDelta:  change.OverfillDelta,
+			PackCountDelta: change.PackCountDelta,
+		})
+	}
+	for _, failure := range report.Failed {
+		resp.Failed = append(resp.Failed, ReplayFailureResponse{
+			CalculationID: failure.CalculationID,
+			SKU:           failure.SKU,
+			Amount:        failure.Amount,
+			Error:         failure.Err.Error(),
+		})
+	}
+
+	return resp
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go-packing/internal/domain"
+	"go-packing/internal/infrastructure/memory"
+	"go-packing/internal/service"
+)
+
+// postExport uploads an NDJSON export with the given form fields.
+func postExport(r *gin.Engine, fields map[string]string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		_ = form.WriteField(name, value)
+	}
+	file, _ := form.CreateFormFile("calculations", "export.ndjson")
+	_, _ = file.Write([]byte(`{"amount":250,"packs":[{"size":250,"count":1}]}` + "\n"))
+	_ = form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/replays", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestReplaysHandler_Version(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := memory.NewStore()
+	repo := memory.NewPackConfigRepository(store)
+	configs := service.NewPackConfigService(repo, nil, logger, domain.PackSizeLimits{}, false)
+	if _, err := configs.ReplacePackSizes(context.Background(), service.ReplacePackSizesInput{PackSizes: []int64{250, 500}}); err != nil {
+		t.Fatalf("seed config: %v", err)
+	}
+	calc := service.NewCalculateService(repo, nil, 1, domain.PackSizeLimits{}, memory.NewCalculationRepository(store))
+	h := NewReplaysHandler(service.NewReplayJobs(context.Background(), calc, logger, 0, 0), logger, 0)
+	r := gin.New()
+	r.POST("/replays", h.Start)
+
+	// Version 0 is the first stored version, not "no version".
+	if w := postExport(r, map[string]string{"version": "0"}); w.Code != http.StatusAccepted {
+		t.Fatalf("form version 0 = %d: %s", w.Code, w.Body)
+	}
+	if w := serve(r, http.MethodPost, "/replays", `{"version":0}`, ""); w.Code != http.StatusAccepted {
+		t.Fatalf("JSON version 0 = %d: %s", w.Code, w.Body)
+	}
+
+	assertError(t, postExport(r, map[string]string{"version": "-1"}), http.StatusBadRequest, "INVALID_VERSION")
+	assertError(t, serve(r, http.MethodPost, "/replays", `{"version":-1}`, ""), http.StatusBadRequest, "INVALID_VERSION")
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go-packing/internal/domain"
+	"go-packing/internal/service"
+	"go-packing/pkg/httpx"
+)
+
+// Simulate handles POST /api/v1/simulations.
+// @Summary Simulate candidate pack sizes
+// @Description Replays historical orders against the current pack sizes and a candidate set, and reports the change in overfill, pack count and per-size usage plus the orders that got worse. Send JSON, or multipart/form-data with a CSV file "orders" (one amount per row, first column; a header row is skipped) and the fields "pack_sizes" (comma-separated) and "sku". Stock limits are ignored and nothing is stored.
+// @Tags Simulations
+// @Accept json
+// @Accept mpfd
+// @Produce json
+// @Param request body SimulationRequest true "Candidate pack sizes and orders"
+// @Success 200 {object} SimulationResponse
+// @Failure 400 {object} httpx.ErrorResponse
+// @Failure 409 {object} httpx.ErrorResponse
+// @Failure 500 {object} httpx.ErrorResponse
+// @Router /api/v1/simulations [post]
+func (h *CalculateHandler) Simulate(c *gin.Context) {
+	req, ok := simulationRequest(c)
+	if !ok {
+		return
+	}
+	if len(req.Orders) == 0 || len(req.Orders) > h.maxSimulationOrders {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_ORDERS", fmt.Sprintf("orders must hold between 1 and %d amounts", h.maxSimulationOrders))
+		return
+	}
+
+	sim, err := h.svc.Simulate(c.Request.Context(), service.SimulateInput{
+		SKU:       req.SKU,
+		PackSizes: req.PackSizes,
+		Orders:    req.Orders,
+	})
+	if err != nil {
+		h.writeCalculateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toSimulationResponse(sim))
+}
+
+// simulationRequest reads a JSON body or a multipart form with a CSV upload,
+// and writes a 400 when neither can be read.
+func simulationRequest(c *gin.Context) (SimulationRequest, bool) {
+	var req SimulationRequest
+	if c.ContentType() != gin.MIMEMultipartPOSTForm {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httpx.WriteError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+			return req, false
+		}
+		return req, true
+	}
+
+	req.SKU = c.PostForm("sku")
+	for _, field := range strings.Split(c.PostForm("pack_sizes"), ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		size, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			httpx.WriteError(c, http.StatusBadRequest, "INVALID_PACK_SIZES", domain.ErrInvalidPackSizes.Error(),
+				httpx.ErrorDetail{Field: "pack_sizes", Reason: "must be comma-separated integers"})
+			return req, false
+		}
+		req.PackSizes = append(req.PackSizes, size)
+	}
+
+	header, err := c.FormFile("orders")
+	if err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_REQUEST", "orders CSV file is required")
+		return req, false
+	}
+	file, err := header.Open()
+	if err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_REQUEST", "orders CSV file cannot be read")
+		return req, false
+	}
+	defer file.Close()
+
+	req.Orders, err = ordersFromCSV(file)
+	if err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, "INVALID_ORDERS", err.Error())
+		return req, false
+	}
+
+	return req, true
+}
+
+// ordersFromCSV reads the order amount from the first column of every row.
+// A first row that is not a number is taken as a header; blank rows are skipped.
+func ordersFromCSV(r io.Reader) ([]int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var orders []int
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return orders, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("orders CSV is malformed: %w", err)
+		}
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		amount, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("orders CSV line %d: %q is not an amount", line, record[0])
+		}
+		orders = append(orders, amount)
+	}
+}
+
+func toSimulationResponse(sim *service.Simulation) SimulationResponse {
+	resp := SimulationResponse{
+		SKU:                sim.SKU,
+		ConfigVersion:      sim.ConfigVersion,
+		CurrentPackSizes:   sim.CurrentSizes,
+		CandidatePackSizes: sim.CandidateSizes,
+		Orders:             sim.Orders,
+		Current:            toSimulationTotals(sim.Current),
+		Candidate:          toSimulationTotals(sim.Candidate),
+		Delta: SimulationTotalsResponse{
+			ShippedTotal: sim.Candidate.ShippedTotal - sim.Current.ShippedTotal,
+			Overfill:     sim.Candidate.Overfill - sim.Current.Overfill,
+			PackCount:    sim.Candidate.PackCount - sim.Current.PackCount,
+			ExactOrders:  sim.Candidate.ExactOrders - sim.Current.ExactOrders,
+		},
+		SizeUsage:       make([]SizeUsageResponse, 0, len(sim.SizeUsage)),
+		BetterOrders:    sim.BetterOrders,
+		WorseOrders:     sim.WorseOrders,
+		Worse:           make([]SimulatedOrderResponse, 0, len(sim.Worse)),
+		TruncatedOrders: sim.TruncatedOrders,
+	}
+	for _, u := range sim.SizeUsage {
+		resp.SizeUsage = append(resp.SizeUsage, SizeUsageResponse{
+			Size:      u.Size,
+			Current:   u.Current,
+			Candidate: u.Candidate,
+			Delta:     u.Candidate - u.Current,
+		})
+	}
+	for _, order := range sim.Worse {
+		resp.Worse = append(resp.Worse, SimulatedOrderResponse{
+			Amount:    order.Amount,
+			Orders:    order.Orders,
+			Current:   toCandidateResponse(order.Amount, order.Current, nil),
+			Candidate: toCandidateResponse(order.Amount, order.Candidate, nil),
+		})
+	}
+
+	return resp
+}
+
+func toSimulationTotals(totals service.SimulationTotals) SimulationTotalsResponse {
+	return SimulationTotalsResponse{
+		ShippedTotal: totals.ShippedTotal,
+		Overfill:     totals.Overfill,
+		PackCount:    totals.PackCount,
+		ExactOrders:  totals.ExactOrders,
+	}
+}
@@ -1,18 +1,503 @@
 package handlers
 
+import (
+	"time"
+
+	"go-packing/internal/domain"
+	"go-packing/pkg/httpx"
+)
+
 // CalculateRequest is the request body for calculation.
 type CalculateRequest struct {
 	Amount int `json:"amount" example:"251"`
+	// SKU selects a product configuration; omitted means the default profile.
+	SKU string `json:"sku,omitempty" example:"default"`
+	// StockLimits overrides the stored availability of individual pack sizes.
+	StockLimits []StockLimit `json:"stock_limits,omitempty"`
+	// Objective is min_overfill (default) or min_cost.
+	Objective string `json:"objective,omitempty" enums:"min_overfill,min_cost" example:"min_overfill"`
+	// Rules overrides the rule chain stored for the SKU, applied in order.
+	Rules []domain.Rule `json:"rules,omitempty"`
+	// AsOf selects the config version that applies at that time; omitted means now.
+	AsOf *time.Time `json:"as_of,omitempty" example:"2026-03-01T00:00:00Z"`
+}
+
+// BatchCalculateRequest is the request body for batch calculation. The batch-level
+// fields are defaults for items that do not set their own.
+type BatchCalculateRequest struct {
+	Items       []CalculateRequest `json:"items"`
+	SKU         string             `json:"sku,omitempty" example:"default"`
+	StockLimits []StockLimit       `json:"stock_limits,omitempty"`
+	Objective   string             `json:"objective,omitempty" enums:"min_overfill,min_cost" example:"min_overfill"`
+	Rules       []domain.Rule      `json:"rules,omitempty"`
+	AsOf        *time.Time         `json:"as_of,omitempty" example:"2026-03-01T00:00:00Z"`
+}
+
+// BatchCalculateResponse holds one result per request item, in request order.
+type BatchCalculateResponse struct {
+	Results   []BatchItemResponse `json:"results"`
+	Succeeded int                 `json:"succeeded" example:"2"`
+	Failed    int                 `json:"failed" example:"0"`
+}
+
+// BatchItemResponse is the outcome of one batch item: packs on success, error otherwise.
+type BatchItemResponse struct {
+	Index  int `json:"index" example:"0"`
+	Amount int `json:"amount" example:"251"`
+	// CalculationID identifies the stored calculation of a solved item.
+	CalculationID int64                 `json:"calculation_id,omitempty" example:"42"`
+	Packs         []PackLineResponse    `json:"packs,omitempty"`
+	ShippedTotal  int                   `json:"shipped_total,omitempty" example:"500"`
+	Overfill      int                   `json:"overfill,omitempty" example:"249"`
+	PackCount     int                   `json:"pack_count,omitempty" example:"1"`
+	Cost          *domain.CostBreakdown `json:"cost,omitempty"`
+	Error         *httpx.ErrorBody      `json:"error,omitempty"`
+}
+
+// PackLineResponse is one line of a calculated breakdown with the metadata of its size.
+type PackLineResponse struct {
+	Size            int                    `json:"size" example:"500"`
+	Count           int                    `json:"count" example:"1"`
+	Label           string                 `json:"label,omitempty" example:"Medium box"`
+	PackagingSKU    string                 `json:"packaging_sku,omitempty" example:"BOX-M"`
+	Dimensions      *domain.PackDimensions `json:"dimensions,omitempty"`
+	TareWeightGrams int64                  `json:"tare_weight_g,omitempty" example:"180"`
+}
+
+// CostCalculationResponse is returned by calculate for the min_cost objective.
+type CostCalculationResponse struct {
+	Packs []PackLineResponse   `json:"packs"`
+	Cost  domain.CostBreakdown `json:"cost"`
+}
+
+// CalculationResponse is returned by POST /api/v2/calculate.
+type CalculationResponse struct {
+	// CalculationID identifies the stored calculation, see GET /api/v1/calculations/{id}.
+	CalculationID int64              `json:"calculation_id,omitempty" example:"42"`
+	Amount        int                `json:"amount" example:"501"`
+	Packs         []PackLineResponse `json:"packs"`
+	ShippedTotal  int                `json:"shipped_total" example:"750"`
+	Overfill      int                `json:"overfill" example:"249"`
+	// OverfillPct is overfill as a percentage of the amount.
+	OverfillPct   float64                `json:"overfill_pct" example:"49.7"`
+	PackCount     int                    `json:"pack_count" example:"2"`
+	ConfigVersion int64                  `json:"config_version" example:"3"`
+	Solver        string                 `json:"solver" enums:"residue,bounded_knapsack,min_cost,rule_chain" example:"residue"`
+	Cost          *domain.CostBreakdown  `json:"cost,omitempty"`
+	Explanation   CalculationExplanation `json:"explanation"`
+	// Alternatives ranks the K best breakdowns, the chosen one first; only with alternatives=K.
+	Alternatives []CandidateResponse `json:"alternatives,omitempty"`
+	// CandidatesTruncated is set when the search for candidate breakdowns hit its
+	// limit, so a custom rule chain, the explanation or the alternatives may have
+	// missed better breakdowns.
+	CandidatesTruncated bool `json:"candidates_truncated,omitempty"`
+}
+
+// CalculationExplanation says which rule decided the breakdown.
+type CalculationExplanation struct {
+	DecidedBy string        `json:"decided_by" example:"pack_count"`
+	Summary   string        `json:"summary"`
+	Rules     []domain.Rule `json:"rules,omitempty"`
+	// RunnersUp lists breakdowns that lost, only with explain=true.
+	RunnersUp []CandidateResponse `json:"runners_up,omitempty"`
+}
+
+// CandidateResponse is one ranked or losing breakdown.
+type CandidateResponse struct {
+	Packs        []PackLineResponse `json:"packs"`
+	ShippedTotal int                `json:"shipped_total" example:"750"`
+	Overfill     int                `json:"overfill" example:"249"`
+	OverfillPct  float64            `json:"overfill_pct" example:"49.7"`
+	PackCount    int                `json:"pack_count" example:"3"`
+	// LostOn names the rule a runner-up lost on.
+	LostOn string `json:"lost_on,omitempty" example:"pack_count"`
+}
+
+// SimulationRequest is the JSON body of a simulation.
+type SimulationRequest struct {
+	// SKU selects the current configuration; omitted means the default profile.
+	SKU       string  `json:"sku,omitempty" example:"default"`
+	PackSizes []int64 `json:"pack_sizes" example:"250,500,1000,2000"`
+	Orders    []int   `json:"orders" example:"251,501,12001"`
+}
+
+// StoredCalculationResponse is a calculation from the history.
+type StoredCalculationResponse struct {
+	ID            int64                     `json:"id" example:"42"`
+	SKU           string                    `json:"sku" example:"default"`
+	Amount        int                       `json:"amount" example:"501"`
+	Options       domain.CalculationOptions `json:"options"`
+	ConfigVersion int64                     `json:"config_version" example:"3"`
+	Packs         []domain.PackBreakdown    `json:"packs"`
+	ShippedTotal  int                       `json:"shipped_total" example:"750"`
+	Overfill      int                       `json:"overfill" example:"249"`
+	PackCount     int                       `json:"pack_count" example:"2"`
+	Cost          *domain.CostBreakdown     `json:"cost,omitempty"`
+	Solver        string                    `json:"solver" example:"residue"`
+	DurationMS    float64                   `json:"duration_ms" example:"0.42"`
+	CreatedAt     time.Time                 `json:"created_at"`
+}
+
+// CalculationListResponse is one page of stored calculations, newest first.
+type CalculationListResponse struct {
+	Calculations []StoredCalculationResponse `json:"calculations"`
+	// NextCursor fetches the next page as ?cursor=; omitted on the last page.
+	NextCursor int64 `json:"next_cursor,omitempty" example:"17"`
+}
+
+// ReplayRequest selects the stored calculations to replay; every filter is optional.
+type ReplayRequest struct {
+	SKU       string     `json:"sku,omitempty" example:"default"`
+	From      *time.Time `json:"from,omitempty"`
+	To        *time.Time `json:"to,omitempty"`
+	MinAmount int        `json:"min_amount,omitempty" example:"0"`
+	MaxAmount int        `json:"max_amount,omitempty" example:"10000"`
+	// Version replays on that config version of each SKU instead of the active
+	// one; versions start at 0.
+	Version *int64 `json:"version,omitempty" example:"3"`
+}
+
+// ReplayJobResponse is the state of a replay; report is set once it is done
+// and error once it failed.
+type ReplayJobResponse struct {
+	ID         int64                 `json:"id" example:"7"`
+	Status     string                `json:"status" example:"running" enums:"running,done,failed"`
+	Done       int                   `json:"done" example:"120"`
+	Total      int                   `json:"total" example:"500"`
+	CreatedAt  time.Time             `json:"created_at"`
+	FinishedAt *time.Time            `json:"finished_at,omitempty"`
+	Error      string                `json:"error,omitempty"`
+	Report     *ReplayReportResponse `json:"report,omitempty"`
+}
+
+// ReplayReportResponse lists the replayed calculations whose breakdown changed.
+type ReplayReportResponse struct {
+	Replayed  int                     `json:"replayed" example:"498"`
+	Unchanged int                     `json:"unchanged" example:"480"`
+	Changed   []ReplayChangeResponse  `json:"changed"`
+	Failed    []ReplayFailureResponse `json:"failed"`
+	// OverfillDelta and PackCountDelta sum the changes, replayed minus stored.
+	OverfillDelta  int64 `json:"overfill_delta" example:"-2250"`
+	PackCountDelta int64 `json:"pack_count_delta" example:"-18"`
+}
+
+// ReplayChangeResponse compares the stored and the replayed breakdown of one calculation.
+type ReplayChangeResponse struct {
+	// CalculationID is 0 for an exported calculation without an id.
+	CalculationID  int64                  `json:"calculation_id,omitempty" example:"42"`
+	SKU            string                 `json:"sku" example:"default"`
+	Amount         int                    `json:"amount" example:"1000"`
+	StoredVersion  int64                  `json:"stored_version" example:"2"`
+	ReplayVersion  int64                  `json:"replay_version" example:"3"`
+	Before         []domain.PackBreakdown `json:"before"`
+	After          []domain.PackBreakdown `json:"after"`
+	OverfillDelta  int                    `json:"overfill_delta" example:"0"`
+	PackCountDelta int                    `json:"pack_count_delta" example:"-1"`
+}
+
+// ReplayFailureResponse is a calculation that could not be replayed.
+type ReplayFailureResponse struct {
+	CalculationID int64  `json:"calculation_id,omitempty" example:"43"`
+	SKU           string `json:"sku" example:"widget"`
+	Amount        int    `json:"amount" example:"5"`
+	Error         string `json:"error" example:"pack config version not found"`
+}
+
+// SimulationResponse compares the current and the candidate pack sizes over the same orders.
+type SimulationResponse struct {
+	SKU                string                   `json:"sku" example:"default"`
+	ConfigVersion      int64                    `json:"config_version" example:"3"`
+	CurrentPackSizes   []int64                  `json:"current_pack_sizes" example:"250,500,1000,2000,5000"`
+	CandidatePackSizes []int64                  `json:"candidate_pack_sizes" example:"250,500,1000,2000"`
+	Orders             int                      `json:"orders" example:"3"`
+	Current            SimulationTotalsResponse `json:"current"`
+	Candidate          SimulationTotalsResponse `json:"candidate"`
+	// Delta is candidate minus current.
+	Delta        SimulationTotalsResponse `json:"delta"`
+	SizeUsage    []SizeUsageResponse      `json:"size_usage"`
+	BetterOrders int                      `json:"better_orders" example:"0"`
+	WorseOrders  int                      `json:"worse_orders" example:"1"`
+	// Worse lists up to 50 worsened amounts, the largest overfill increase first.
+	Worse []SimulatedOrderResponse `json:"worse"`
+	// TruncatedOrders counts orders whose breakdown under a custom rule chain
+	// came from a shortened candidate search.
+	TruncatedOrders int `json:"truncated_orders" example:"0"`
+}
+
+// SimulationTotalsResponse sums the breakdowns of every simulated order.
+type SimulationTotalsResponse struct {
+	ShippedTotal int64 `json:"shipped_total" example:"13250"`
+	Overfill     int64 `json:"overfill" example:"497"`
+	PackCount    int64 `json:"pack_count" example:"6"`
+	ExactOrders  int   `json:"exact_orders" example:"0"`
+}
+
+// SizeUsageResponse is how many packs of a size each side ships over all orders.
+type SizeUsageResponse struct {
+	Size      int64 `json:"size" example:"2000"`
+	Current   int64 `json:"current" example:"1"`
+	Candidate int64 `json:"candidate" example:"6"`
+	Delta     int64 `json:"delta" example:"5"`
+}
+
+// SimulatedOrderResponse is one amount with both breakdowns; Orders is how often it was ordered.
+type SimulatedOrderResponse struct {
+	Amount    int               `json:"amount" example:"12001"`
+	Orders    int               `json:"orders" example:"1"`
+	Current   CandidateResponse `json:"current"`
+	Candidate CandidateResponse `json:"candidate"`
+}
+
+// PackSizeCost prices one pack size, in minor currency units.
+type PackSizeCost struct {
+	Size         int64 `json:"size" example:"500"`
+	UnitCost     int64 `json:"unit_cost" example:"120"`
+	HandlingCost int64 `json:"handling_cost" example:"30"`
+}
+
+// StockLimit caps how many packs of one size are available.
+type StockLimit struct {
+	Size      int64 `json:"size" example:"5000"`
+	Available int   `json:"available" example:"3"`
+}
+
+// PackSizeDetails describes one configured pack size.
+type PackSizeDetails struct {
+	Size            int64                  `json:"size" example:"500"`
+	Label           string                 `json:"label,omitempty" example:"Medium box"`
+	PackagingSKU    string                 `json:"packaging_sku,omitempty" example:"BOX-M"`
+	Dimensions      *domain.PackDimensions `json:"dimensions,omitempty"`
+	TareWeightGrams int64                  `json:"tare_weight_g,omitempty" example:"180"`
+	// Active sizes are used by calculations; omitted in a request means true.
+	Active *bool `json:"active,omitempty" example:"true"`
 }
 
 // PackSizesRequest is the request body for replacing configured pack sizes.
 type PackSizesRequest struct {
 	PackSizes []int64 `json:"pack_sizes" example:"250,500,1000,2000,5000"`
+	// Packs, StockLimits, PackCosts, OverfillItemCost and Rules are optional:
+	// omitted ones keep the stored settings of the sizes that remain, and an
+	// empty list clears them.
+	//
+	// Packs describes sizes of pack_sizes; sizes without an entry are active without metadata.
+	Packs  []PackSizeDetails `json:"packs,omitempty"`
+	Reason string            `json:"reason,omitempty" example:"new 5000 box"`
+	// StockLimits caps availability per size; sizes without an entry are unlimited.
+	StockLimits []StockLimit `json:"stock_limits,omitempty"`
+	// PackCosts and OverfillItemCost price breakdowns for the min_cost objective.
+	PackCosts        []PackSizeCost `json:"pack_costs,omitempty"`
+	OverfillItemCost *int64         `json:"overfill_item_cost,omitempty" example:"1"`
+	// Rules is the default rule chain for calculations; an empty list means overfill, then pack_count.
+	Rules []domain.Rule `json:"rules,omitempty"`
+	// EffectiveFrom queues the version to apply from a future time; omitted applies it at once.
+	EffectiveFrom *time.Time `json:"effective_from,omitempty" example:"2026-03-01T00:00:00Z"`
+	// EffectiveTo retires the version; omitted keeps it until a later version starts.
+	EffectiveTo *time.Time `json:"effective_to,omitempty" example:"2026-03-31T23:59:59Z"`
 }
 
 // PackSizesResponse is returned by pack size read/update endpoints.
 type PackSizesResponse struct {
 	PackSizes []int64 `json:"pack_sizes"`
+	// Packs lists every size of pack_sizes with its metadata.
+	Packs            []PackSizeDetails `json:"packs"`
+	Version          *int64            `json:"version,omitempty" example:"3"`
+	UpdatedAt        *time.Time        `json:"updated_at,omitempty"`
+	UpdatedBy        string            `json:"updated_by,omitempty"`
+	Reason           string            `json:"reason,omitempty"`
+	StockLimits      []StockLimit      `json:"stock_limits,omitempty"`
+	PackCosts        []PackSizeCost    `json:"pack_costs,omitempty"`
+	OverfillItemCost int64             `json:"overfill_item_cost,omitempty"`
+	Rules            []domain.Rule     `json:"rules,omitempty"`
+	EffectiveFrom    *time.Time        `json:"effective_from,omitempty"`
+	EffectiveTo      *time.Time        `json:"effective_to,omitempty"`
+	// Analysis is set on PUT with analyze=true.
+	Analysis *PackSizesAnalysisResponse `json:"analysis,omitempty"`
+}
+
+// PackConfigDraftResponse is a proposed pack configuration and its review state.
+type PackConfigDraftResponse struct {
+	ID     int64  `json:"id" example:"12"`
+	SKU    string `json:"sku" example:"default"`
+	Status string `json:"status" enums:"draft,approved,rejected,published" example:"draft"`
+	// BaseVersion is the version the draft was made from; omitted for a SKU without a config.
+	BaseVersion      *int64            `json:"base_version,omitempty" example:"3"`
+	Revision         int64             `json:"revision" example:"1"`
+	PackSizes        []int64           `json:"pack_sizes" example:"250,500,750,1000,2000,5000"`
+	Packs            []PackSizeDetails `json:"packs"`
+	StockLimits      []StockLimit      `json:"stock_limits,omitempty"`
+	PackCosts        []PackSizeCost    `json:"pack_costs,omitempty"`
+	OverfillItemCost int64             `json:"overfill_item_cost,omitempty"`
+	Rules            []domain.Rule     `json:"rules,omitempty"`
+	EffectiveFrom    *time.Time        `json:"effective_from,omitempty"`
+	EffectiveTo      *time.Time        `json:"effective_to,omitempty"`
+	Reason           string            `json:"reason,omitempty" example:"new 750 box"`
+	CreatedBy        string            `json:"created_by" example:"jane"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	ReviewedBy       string            `json:"reviewed_by,omitempty" example:"sam"`
+	ReviewedAt       *time.Time        `json:"reviewed_at,omitempty"`
+	PublishedBy      string            `json:"published_by,omitempty" example:"sam"`
+	PublishedAt      *time.Time        `json:"published_at,omitempty"`
+	PublishedVersion *int64            `json:"published_version,omitempty" example:"4"`
+	// Comments are omitted from lists.
+	Comments []DraftCommentResponse `json:"comments,omitempty"`
+}
+
+// DraftCommentResponse is one review comment on a draft.
+type DraftCommentResponse struct {
+	ID        int64     `json:"id" example:"1"`
+	Author    string    `json:"author" example:"sam"`
+	Body      string    `json:"body" example:"750 boxes arrive on March 1st"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DraftCommentRequest is the body of a comment, or the optional reason of a rejection.
+type DraftCommentRequest struct {
+	Body string `json:"body" example:"750 boxes arrive on March 1st"`
+}
+
+// PackConfigDraftsResponse lists the drafts of a SKU, newest first.
+type PackConfigDraftsResponse struct {
+	Drafts []PackConfigDraftResponse `json:"drafts"`
+}
+
+// PackSizesPreviewResponse is returned by PUT with dry_run=true; nothing is stored.
+type PackSizesPreviewResponse struct {
+	DryRun bool   `json:"dry_run" example:"true"`
+	SKU    string `json:"sku" example:"default"`
+	// CurrentVersion and CurrentPackSizes are omitted for a SKU without a configuration.
+	CurrentVersion   *int64  `json:"current_version,omitempty" example:"3"`
+	CurrentPackSizes []int64 `json:"current_pack_sizes,omitempty" example:"250,500,1000,2000,5000"`
+	PackSizes        []int64 `json:"pack_sizes" example:"250,500,1000,2000"`
+	Added            []int64 `json:"added"`
+	Removed          []int64 `json:"removed" example:"5000"`
+	// Warnings are the analysis warnings of the new sizes.
+	Warnings     []string                  `json:"warnings"`
+	Changes      []BreakdownChangeResponse `json:"changes"`
+	ChangedCount int                       `json:"changed_count" example:"1"`
+}
+
+// BreakdownChangeResponse compares how one sample amount ships before and after the change.
+// A side is omitted when it has no config or cannot ship the amount.
+type BreakdownChangeResponse struct {
+	Amount   int                `json:"amount" example:"12001"`
+	Current  *CandidateResponse `json:"current,omitempty"`
+	Proposed *CandidateResponse `json:"proposed,omitempty"`
+	Changed  bool               `json:"changed" example:"true"`
+}
+
+// PackSizesAnalysisResponse describes what a set of pack sizes can and cannot ship.
+type PackSizesAnalysisResponse struct {
+	GCD int `json:"gcd" example:"1"`
+	// FrobeniusNumber is the largest amount no combination hits exactly; null when gcd > 1.
+	FrobeniusNumber *int `json:"frobenius_number" example:"43"`
+	// UnreachableCount is how many amounts cannot be hit exactly; null when gcd > 1.
+	UnreachableCount *int `json:"unreachable_count" example:"22"`
+	// RedundantForOverfill are sizes that are sums of smaller sizes.
+	RedundantForOverfill []int64 `json:"redundant_for_overfill"`
+	// UnusedSizes never appear in a calculated breakdown for amounts up to UpTo.
+	UnusedSizes    []int64                 `json:"unused_sizes"`
+	UpTo           int                     `json:"up_to" example:"200"`
+	OverfillRanges []OverfillRangeResponse `json:"overfill_ranges"`
+	Warnings       []string                `json:"warnings"`
+}
+
+// OverfillRangeResponse is the worst overfill over amounts from..to.
+type OverfillRangeResponse struct {
+	From        int `json:"from" example:"1"`
+	To          int `json:"to" example:"20"`
+	MaxOverfill int `json:"max_overfill" example:"5"`
+	WorstAmount int `json:"worst_amount" example:"1"`
+}
+
+// DemandBucket is how many historical orders asked for one amount.
+type DemandBucket struct {
+	Amount int `json:"amount" example:"251"`
+	Count  int `json:"count" example:"40"`
+}
+
+// RecommendPackSizesRequest is the request body for pack-size recommendations.
+type RecommendPackSizesRequest struct {
+	Histogram []DemandBucket `json:"histogram"`
+	// SizeCount is how many pack sizes each candidate set has, fixed sizes included.
+	SizeCount  int     `json:"size_count" example:"4"`
+	FixedSizes []int64 `json:"fixed_sizes,omitempty" example:"5000"`
+	// CandidateSizes are the sizes to pick from; omitted means the most demanded amounts.
+	CandidateSizes []int64 `json:"candidate_sizes,omitempty" example:"250,500,750,1000,2000,5000"`
+	// Top is how many sets to return, 1 to 10 (default 5).
+	Top int `json:"top,omitempty" example:"5"`
+}
+
+// PackSizeRecommendationResponse ranks pack-size sets, the best first.
+type PackSizeRecommendationResponse struct {
+	Candidates []RecommendedPackSizesResponse `json:"candidates"`
+	// Current scores the configured sizes; omitted when none are configured.
+	Current    *RecommendedPackSizesResponse `json:"current,omitempty"`
+	Evaluated  int                           `json:"evaluated" example:"120"`
+	Exhaustive bool                          `json:"exhaustive" example:"true"`
+}
+
+// RecommendedPackSizesResponse is one set of pack sizes with its expected metrics per order.
+type RecommendedPackSizesResponse struct {
+	PackSizes         []int64 `json:"pack_sizes" example:"250,500,1000,5000"`
+	ExpectedOverfill  float64 `json:"expected_overfill" example:"12.5"`
+	ExpectedPackCount float64 `json:"expected_pack_count" example:"1.8"`
+	// OverfillPct is the total overfill as a percentage of the total demand.
+	OverfillPct float64 `json:"overfill_pct" example:"1.2"`
+	// ExactShare is the share of orders shipped without overfill.
+	ExactShare float64 `json:"exact_share" example:"0.75"`
+}
+
+// PackConfigVersionResponse is one entry of the pack configuration history.
+type PackConfigVersionResponse struct {
+	Version          int64             `json:"version" example:"3"`
+	PackSizes        []int64           `json:"pack_sizes"`
+	Packs            []PackSizeDetails `json:"packs"`
+	ChangedAt        time.Time         `json:"changed_at"`
+	ChangedBy        string            `json:"changed_by,omitempty" example:"jane"`
+	Reason           string            `json:"reason,omitempty"`
+	StockLimits      []StockLimit      `json:"stock_limits,omitempty"`
+	PackCosts        []PackSizeCost    `json:"pack_costs,omitempty"`
+	OverfillItemCost int64             `json:"overfill_item_cost,omitempty"`
+	Rules            []domain.Rule     `json:"rules,omitempty"`
+	EffectiveFrom    *time.Time        `json:"effective_from,omitempty"`
+	EffectiveTo      *time.Time        `json:"effective_to,omitempty"`
+}
+
+// PackSizesHistoryResponse lists stored pack configuration versions, newest first.
+type PackSizesHistoryResponse struct {
+	Versions []PackConfigVersionResponse `json:"versions"`
+	// NextCursor fetches the next page as ?cursor=; omitted on the last page.
+	NextCursor int64 `json:"next_cursor,omitempty" example:"4"`
+}
+
+// ProductPackSizesResponse is the current pack configuration of one product.
+type ProductPackSizesResponse struct {
+	SKU       string    `json:"sku" example:"default"`
+	PackSizes []int64   `json:"pack_sizes"`
+	Version   int64     `json:"version" example:"3"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductsResponse lists configured products ordered by SKU.
+type ProductsResponse struct {
+	Products []ProductPackSizesResponse `json:"products"`
+}
+
+// AddPackSizeRequest is the optional request body for adding one pack size.
+type AddPackSizeRequest struct {
+	Label           string                 `json:"label,omitempty" example:"Seasonal box"`
+	PackagingSKU    string                 `json:"packaging_sku,omitempty" example:"BOX-S750"`
+	Dimensions      *domain.PackDimensions `json:"dimensions,omitempty"`
+	TareWeightGrams int64                  `json:"tare_weight_g,omitempty" example:"150"`
+	// Active defaults to true.
+	Active *bool  `json:"active,omitempty" example:"true"`
+	Reason string `json:"reason,omitempty" example:"new 750 box"`
+}
+
+// RollbackRequest is the optional request body for rollbacks.
+type RollbackRequest struct {
+	Reason string `json:"reason,omitempty" example:"revert accidental change"`
 }
 
 // HealthResponse is the response model for service health checks.
@@ -12,7 +12,8 @@ import (
 	"go-packing/cmd/api/handlers"
 	"go-packing/cmd/api/router"
 	"go-packing/cmd/config"
-	"go-packing/internal/infrastructure/postgres"
+	"go-packing/internal/domain"
+	"go-packing/internal/infrastructure/events"
 	"go-packing/internal/service"
 	"go-packing/pkg/logx"
 )
@@ -23,6 +24,15 @@ import (
 // @BasePath /
 // @schemes http
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			os.Exit(runReplay(os.Args[2:]))
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		}
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		// Create a basic logger for errors before config is loaded
@@ -34,26 +44,46 @@ func main() {
 	logger.Info("starting service")
 	logger.Info("configuration loaded", "env", cfg.AppEnv, "config_file", cfg.SourcePath)
 
-	db, err := postgres.NewDB(context.Background(), cfg.Database.URL)
+	repos, err := openRepositories(context.Background(), cfg.Database, logger)
 	if err != nil {
-		logger.Error("database initialization failed", "error", err)
+		logger.Error("database initialization failed", "error", err, "driver", cfg.Database.Driver)
 		os.Exit(1)
 	}
 	defer func() {
-		if closeErr := db.Close(); closeErr != nil {
+		if closeErr := repos.close(); closeErr != nil {
 			logger.Error("database close failed", "error", closeErr)
 		}
 	}()
 
-	repo := postgres.NewPackConfigRepository(db, logger)
+	repo := repos.configs
+
+	// Both services share the cache: writes invalidate what calculations reuse.
+	solverCache := service.NewSolverCache(int64(cfg.Calculate.SolverCacheMB) << 20)
+	limits := domain.PackSizeLimits{MaxSize: cfg.PackSizes.MaxSize, MaxCount: cfg.PackSizes.MaxCount}
+	history := repos.calculations
+	calculateService := service.NewCalculateService(repo, solverCache, cfg.Calculate.BatchWorkers, limits, history)
+	packConfigService := service.NewPackConfigService(repo, solverCache, logger, limits, cfg.Server.RequireReview)
+	draftService := service.NewDraftService(repos.drafts, packConfigService, logger)
 
-	calculateService := service.NewCalculateService(repo)
-	packConfigService := service.NewPackConfigService(repo, logger)
+	calculateHandler := handlers.NewCalculateHandler(calculateService, logger, cfg.Calculate.BatchMaxItems, cfg.Calculate.SimulationMaxOrders)
+	packSizesHandler := handlers.NewPackSizesHandler(packConfigService, logger, cfg.Server.RequireIfMatch, cfg.Calculate.PreviewAmounts)
+	draftsHandler := handlers.NewDraftsHandler(draftService, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if interval := cfg.Schedule.ActivationInterval; interval > 0 {
+		activationJob := service.NewActivationJob(repo, events.NewLogPublisher(logger), logger, interval)
+		go activationJob.Run(ctx)
+	}
+	if cfg.History.Retention > 0 && cfg.History.PurgeInterval > 0 {
+		purgeJob := service.NewHistoryPurgeJob(history, logger, cfg.History.PurgeInterval, cfg.History.Retention)
+		go purgeJob.Run(ctx)
+	}
 
-	calculateHandler := handlers.NewCalculateHandler(calculateService, logger)
-	packSizesHandler := handlers.NewPackSizesHandler(packConfigService, logger)
+	replayJobs := service.NewReplayJobs(ctx, calculateService, logger, cfg.Replay.MaxCalculations, cfg.Replay.MaxRunning)
+	replaysHandler := handlers.NewReplaysHandler(replayJobs, logger, cfg.Replay.MaxCalculations)
 
-	router := router.NewRouter(logger, calculateHandler, packSizesHandler)
+	router := router.NewRouter(logger, calculateHandler, packSizesHandler, draftsHandler, replaysHandler)
 
 	addr := cfg.Server.Port
 	if !strings.HasPrefix(addr, ":") {
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"go-packing/cmd/config"
+	"go-packing/internal/infrastructure/postgres"
+	"go-packing/pkg/logx"
+)
+
+const migrateUsage = "usage: api migrate up | down [-steps N] | status"
+
+// runMigrate implements `api migrate up|down|status` against the PostgreSQL
+// database of the config. Logs go to stderr.
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	command := args[0]
+	if command != "up" && command != "down" && command != "status" {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("migrate "+command, flag.ContinueOnError)
+	steps := flags.Int("steps", 1, "how many migrations down reverts")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if command != "down" && *steps != 1 {
+		fmt.Fprintln(os.Stderr, "-steps only applies to down")
+		return 2
+	}
+	if *steps < 1 {
+		fmt.Fprintln(os.Stderr, "-steps must be at least 1")
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("config initialization failed", "error", err)
+		return 1
+	}
+	logger := logx.NewJSONLoggerTo(os.Stderr, cfg.Log.Level)
+	if cfg.Database.Driver != config.DriverPostgres {
+		logger.Error("migrations need the postgres driver", "driver", cfg.Database.Driver)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	db, err := postgres.NewDB(ctx, cfg.Database.URL)
+	if err != nil {
+		logger.Error("database initialization failed", "error", err)
+		return 1
+	}
+	defer db.Close()
+
+	migrator, err := postgres.NewMigrator(db, logger)
+	if err != nil {
+		logger.Error("failed to load migrations", "error", err)
+		return 1
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			logger.Error("migrate up failed", "error", err)
+			return 1
+		}
+		logger.Info("migrate up finished", "applied", len(applied))
+	case "down":
+		reverted, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			logger.Error("migrate down failed", "error", err)
+			return 1
+		}
+		logger.Info("migrate down finished", "reverted", len(reverted))
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			logger.Error("migrate status failed", "error", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		if err := w.Flush(); err != nil {
+			logger.Error("failed to write status", "error", err)
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+
+	"go-packing/cmd/api/handlers"
+	"go-packing/cmd/config"
+	"go-packing/internal/domain"
+	"go-packing/internal/service"
+	"go-packing/pkg/logx"
+)
+
+// replayProgressEvery is how many calculations pass between progress logs.
+const replayProgressEvery = 1000
+
+// runReplay implements `api replay`: it replays stored calculations, or the
+// export named by -input, and writes the JSON report. Logs go to stderr.
+func runReplay(args []string) int {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	sku := flags.String("sku", "", "only calculations of this SKU")
+	from := flags.String("from", "", "only calculations created at or after this RFC 3339 time")
+	to := flags.String("to", "", "only calculations created before this RFC 3339 time")
+	minAmount := flags.Int("min-amount", 0, "smallest amount")
+	maxAmount := flags.Int("max-amount", 0, "largest amount; 0 means no bound")
+	version := flags.Int64("version", 0, "replay on this config version instead of the active one; versions start at 0")
+	input := flags.String("input", "", `export to replay instead of the stored history; "-" reads stdin`)
+	format := flags.String("format", "", "format of -input, ndjson or csv; guessed from the file name by default")
+	output := flags.String("output", "-", `file to write the report to; "-" writes stdout`)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("config initialization failed", "error", err)
+		return 1
+	}
+	logger := logx.NewJSONLoggerTo(os.Stderr, cfg.Log.Level)
+
+	in := service.ReplayInput{
+		Filter:          domain.CalculationFilter{SKU: *sku, MinAmount: *minAmount, MaxAmount: *maxAmount},
+		MaxCalculations: cfg.Replay.MaxCalculations,
+	}
+	for _, t := range []struct {
+		name, raw string
+		dst       *time.Time
+	}{{"from", *from, &in.Filter.From}, {"to", *to, &in.Filter.To}} {
+		if t.raw == "" {
+			continue
+		}
+		if *t.dst, err = time.Parse(time.RFC3339, t.raw); err != nil {
+			logger.Error("invalid flag", "flag", t.name, "error", err)
+			return 2
+		}
+	}
+	// Version 0 is a real version, so only an unset flag means the active one.
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "version" {
+			in.Version = version
+		}
+	})
+	if in.Version != nil && *in.Version < 0 {
+		logger.Error("invalid flag", "flag", "version", "error", "version must be a non-negative integer")
+		return 2
+	}
+	if *input != "" {
+		if in.Calculations, err = readExport(*input, *format, cfg.Replay.MaxCalculations); err != nil {
+			logger.Error("failed to read export", "error", err, "input", *input)
+			return 1
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	repos, err := openRepositories(ctx, cfg.Database, logger)
+	if err != nil {
+		logger.Error("database initialization failed", "error", err, "driver", cfg.Database.Driver)
+		return 1
+	}
+	defer repos.close()
+
+	limits := domain.PackSizeLimits{MaxSize: cfg.PackSizes.MaxSize, MaxCount: cfg.PackSizes.MaxCount}
+	calculateService := service.NewCalculateService(
+		repos.configs,
+		service.NewSolverCache(int64(cfg.Calculate.SolverCacheMB)<<20),
+		cfg.Calculate.BatchWorkers,
+		limits,
+		repos.calculations,
+	)
+
+	report, err := calculateService.Replay(ctx, in, func(done, total int) {
+		if done%replayProgressEvery == 0 || done == total {
+			logger.Info("replay progress", "done", done, "total", total)
+		}
+	})
+	if err != nil {
+		logger.Error("replay failed", "error", err)
+		return 1
+	}
+
+	if err := writeReport(*output, handlers.ToReplayReportResponse(report)); err != nil {
+		logger.Error("failed to write report", "error", err, "output", *output)
+		return 1
+	}
+	logger.Info("replay finished", "replayed", report.Replayed, "changed", len(report.Changed), "failed", len(report.Failed))
+
+	return 0
+}
+
+// readExport reads an export file, or stdin for "-".
+func readExport(name, format string, limit int) ([]domain.Calculation, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+	if format == "" {
+		format = service.ExportFormatOf(name)
+	}
+
+	return service.ReadCalculations(r, format, limit)
+}
+
+// writeReport writes the report as indented JSON to a file, or stdout for "-".
+func writeReport(name string, report handlers.ReplayReportResponse) error {
+	if name == "-" {
+		return encodeReport(os.Stdout, report)
+	}
+
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := encodeReport(file, report); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func encodeReport(w io.Writer, report handlers.ReplayReportResponse) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+
+	return nil
+}
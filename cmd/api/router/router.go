@@ -16,7 +16,7 @@ import (
 )
 
 // NewRouter wires HTTP routes, middleware, and Swagger UI.
-func NewRouter(logger *slog.Logger, calculateHandler *handlers.CalculateHandler, packSizesHandler *handlers.PackSizesHandler) *gin.Engine {
+func NewRouter(logger *slog.Logger, calculateHandler *handlers.CalculateHandler, packSizesHandler *handlers.PackSizesHandler, draftsHandler *handlers.DraftsHandler, replaysHandler *handlers.ReplaysHandler) *gin.Engine {
 	r := gin.New()
 	r.Use(gin.Recovery())
 	r.Use(middleware.RequestLogger(logger))
@@ -34,8 +34,55 @@ func NewRouter(logger *slog.Logger, calculateHandler *handlers.CalculateHandler,
 	// Versioned API group for business endpoints.
 	api := r.Group("/api/v1")
 	api.POST("/calculate", calculateHandler.Handle)
+	api.POST("/calculate/batch", calculateHandler.HandleBatch)
+	api.POST("/simulations", calculateHandler.Simulate)
+	api.GET("/calculations", calculateHandler.ListCalculations)
+	api.GET("/calculations/:id", calculateHandler.GetCalculation)
+	api.POST("/replays", replaysHandler.Start)
+	api.GET("/replays/:id", replaysHandler.Get)
 	api.GET("/pack-sizes", packSizesHandler.Get)
 	api.PUT("/pack-sizes", packSizesHandler.Replace)
+	api.GET("/pack-sizes/active", packSizesHandler.Active)
+	api.GET("/pack-sizes/history", packSizesHandler.History)
+	api.GET("/pack-sizes/analysis", packSizesHandler.Analysis)
+	api.POST("/pack-sizes/recommendations", packSizesHandler.Recommend)
+	api.GET("/pack-sizes/versions/:version", packSizesHandler.GetVersion)
+	api.POST("/pack-sizes/rollback/:version", packSizesHandler.Rollback)
+	api.POST("/pack-sizes/:size", packSizesHandler.AddSize)
+	api.DELETE("/pack-sizes/:size", packSizesHandler.RemoveSize)
+	registerDrafts(api.Group("/pack-sizes/drafts"), draftsHandler)
+
+	// Product-scoped pack configurations share handlers with the default profile.
+	api.GET("/products", packSizesHandler.ListProducts)
+	products := api.Group("/products/:sku/pack-sizes")
+	products.GET("", packSizesHandler.Get)
+	products.PUT("", packSizesHandler.Replace)
+	products.DELETE("", packSizesHandler.Delete)
+	products.GET("/active", packSizesHandler.Active)
+	products.GET("/history", packSizesHandler.History)
+	products.GET("/analysis", packSizesHandler.Analysis)
+	products.POST("/recommendations", packSizesHandler.Recommend)
+	products.GET("/versions/:version", packSizesHandler.GetVersion)
+	products.POST("/rollback/:version", packSizesHandler.Rollback)
+	products.POST("/:size", packSizesHandler.AddSize)
+	products.DELETE("/:size", packSizesHandler.RemoveSize)
+	registerDrafts(products.Group("/drafts"), draftsHandler)
+
+	// v2 shares the request body and adds totals and an explanation to the response.
+	v2 := r.Group("/api/v2")
+	v2.POST("/calculate", calculateHandler.HandleV2)
 
 	return r
 }
+
+// registerDrafts wires the review workflow of one pack configuration scope.
+func registerDrafts(drafts *gin.RouterGroup, draftsHandler *handlers.DraftsHandler) {
+	drafts.POST("", draftsHandler.Create)
+	drafts.GET("", draftsHandler.List)
+	drafts.GET("/:id", draftsHandler.Get)
+	drafts.PUT("/:id", draftsHandler.Update)
+	drafts.POST("/:id/comments", draftsHandler.Comment)
+	drafts.POST("/:id/approve", draftsHandler.Approve)
+	drafts.POST("/:id/reject", draftsHandler.Reject)
+	drafts.POST("/:id/publish", draftsHandler.Publish)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"go-packing/cmd/config"
+	"go-packing/internal/domain"
+	"go-packing/internal/infrastructure/memory"
+	"go-packing/internal/infrastructure/postgres"
+)
+
+// repositories are the stores of one database.driver.
+type repositories struct {
+	configs      domain.PackConfigsRepository
+	drafts       domain.PackConfigDraftsRepository
+	calculations domain.CalculationsRepository
+	// close releases the storage; it is a no-op for the memory and file drivers.
+	close func() error
+}
+
+// openRepositories opens the storage picked by cfg.Driver and, when
+// cfg.AutoMigrate is set, brings a PostgreSQL schema up to date.
+func openRepositories(ctx context.Context, cfg config.DatabaseConfig, logger *slog.Logger) (*repositories, error) {
+	switch cfg.Driver {
+	case config.DriverMemory:
+		return memoryRepositories(memory.NewStore()), nil
+	case config.DriverFile:
+		store, err := memory.OpenFile(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+		return memoryRepositories(store), nil
+	case config.DriverPostgres:
+		db, err := postgres.NewDB(ctx, cfg.URL)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.AutoMigrate {
+			if err := migrate(ctx, db, logger); err != nil {
+				_ = db.Close()
+				return nil, err
+			}
+		}
+		return &repositories{
+			configs:      postgres.NewPackConfigRepository(db, logger),
+			drafts:       postgres.NewDraftRepository(db, logger),
+			calculations: postgres.NewCalculationRepository(db, logger),
+			close:        db.Close,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+}
+
+func memoryRepositories(store *memory.Store) *repositories {
+	return &repositories{
+		configs:      memory.NewPackConfigRepository(store),
+		drafts:       memory.NewDraftRepository(store),
+		calculations: memory.NewCalculationRepository(store),
+		close:        func() error { return nil },
+	}
+}
+
+// migrate applies the pending schema migrations.
+func migrate(ctx context.Context, db *sql.DB, logger *slog.Logger) error {
+	migrator, err := postgres.NewMigrator(db, logger)
+	if err != nil {
+		return err
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	return nil
+}
@@ -4,24 +4,90 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	AppEnv     string        `mapstructure:"app_env"`
-	Server     ServerConfig   `mapstructure:"server"`
-	Database   DatabaseConfig `mapstructure:"database"`
-	Log        LogConfig      `mapstructure:"log"`
-	SourcePath string        `mapstructure:"-"`
+	AppEnv     string          `mapstructure:"app_env"`
+	Server     ServerConfig    `mapstructure:"server"`
+	Database   DatabaseConfig  `mapstructure:"database"`
+	Calculate  CalculateConfig `mapstructure:"calculate"`
+	Schedule   ScheduleConfig  `mapstructure:"schedule"`
+	PackSizes  PackSizesConfig `mapstructure:"pack_sizes"`
+	History    HistoryConfig   `mapstructure:"history"`
+	Replay     ReplayConfig    `mapstructure:"replay"`
+	Log        LogConfig       `mapstructure:"log"`
+	SourcePath string          `mapstructure:"-"`
 }
 
 type ServerConfig struct {
 	Port string `mapstructure:"port"`
+	// RequireIfMatch rejects pack-size writes that do not send an If-Match version.
+	RequireIfMatch bool `mapstructure:"require_if_match"`
+	// RequireReview rejects direct pack-size writes; changes must be published
+	// from an approved draft. On by default; turning it off is an explicit opt-out.
+	RequireReview bool `mapstructure:"require_review"`
 }
 
+type CalculateConfig struct {
+	// BatchWorkers bounds how many batch items are solved in parallel; 0 means one per CPU.
+	BatchWorkers int `mapstructure:"batch_workers"`
+	// BatchMaxItems caps how many items one batch request may carry.
+	BatchMaxItems int `mapstructure:"batch_max_items"`
+	// SimulationMaxOrders caps how many orders one simulation may replay.
+	SimulationMaxOrders int `mapstructure:"simulation_max_orders"`
+	// PreviewAmounts are the sample amounts a pack-size dry run solves by default.
+	PreviewAmounts []int `mapstructure:"preview_amounts"`
+	// SolverCacheMB is the memory budget of cached solver tables; 0 disables the cache.
+	SolverCacheMB int `mapstructure:"solver_cache_mb"`
+}
+
+type ScheduleConfig struct {
+	// ActivationInterval is how often scheduled pack configs are checked for
+	// activation, e.g. "1m"; 0 disables the check.
+	ActivationInterval time.Duration `mapstructure:"activation_interval"`
+}
+
+type PackSizesConfig struct {
+	// MaxSize caps the items of one pack size; 0 means no cap.
+	MaxSize int64 `mapstructure:"max_size"`
+	// MaxCount caps how many sizes one configuration may hold; 0 means no cap.
+	MaxCount int `mapstructure:"max_count"`
+}
+
+type HistoryConfig struct {
+	// Retention is how long calculations are kept, e.g. "2160h"; 0 keeps them forever.
+	Retention time.Duration `mapstructure:"retention"`
+	// PurgeInterval is how often calculations past Retention are deleted.
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
+type ReplayConfig struct {
+	// MaxCalculations caps how many calculations one replay may re-run; 0 means no cap.
+	MaxCalculations int `mapstructure:"max_calculations"`
+	// MaxRunning caps how many replays run at once; 0 means no cap.
+	MaxRunning int `mapstructure:"max_running"`
+}
+
+// Storage drivers selectable with database.driver.
+const (
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+	DriverFile     = "file"
+)
+
 type DatabaseConfig struct {
+	// Driver picks where configs, drafts and calculations are kept: postgres,
+	// memory (lost on restart) or file (one JSON file, for a single node).
+	Driver string `mapstructure:"driver"`
+	// URL is the PostgreSQL DSN of the postgres driver.
 	URL string `mapstructure:"url"`
+	// Path is the JSON file of the file driver.
+	Path string `mapstructure:"path"`
+	// AutoMigrate applies pending schema migrations on startup; postgres only.
+	AutoMigrate bool `mapstructure:"auto_migrate"`
 }
 
 type LogConfig struct {
@@ -43,6 +109,22 @@ func Load() (Config, error) {
 	v.AddConfigPath("./cmd/config")
 	v.AddConfigPath("/app/cmd/config")
 	v.SetDefault("log.level", "info")
+	v.SetDefault("database.driver", DriverPostgres)
+	v.SetDefault("database.auto_migrate", false)
+	v.SetDefault("server.require_if_match", false)
+	v.SetDefault("server.require_review", true)
+	v.SetDefault("calculate.batch_workers", 0)
+	v.SetDefault("calculate.batch_max_items", 1000)
+	v.SetDefault("calculate.simulation_max_orders", 100000)
+	v.SetDefault("calculate.preview_amounts", []int{1, 250, 251, 501, 1001, 5001, 12001})
+	v.SetDefault("calculate.solver_cache_mb", 64)
+	v.SetDefault("schedule.activation_interval", "1m")
+	v.SetDefault("pack_sizes.max_size", 1000000)
+	v.SetDefault("pack_sizes.max_count", 50)
+	v.SetDefault("history.retention", "2160h")
+	v.SetDefault("history.purge_interval", "1h")
+	v.SetDefault("replay.max_calculations", 100000)
+	v.SetDefault("replay.max_running", 2)
 
 	if err := v.ReadInConfig(); err != nil {
 		return Config{}, fmt.Errorf("read %s config: %w", env, err)
@@ -52,8 +134,18 @@ func Load() (Config, error) {
 	if err := v.Unmarshal(&cfg); err != nil {
 		return Config{}, fmt.Errorf("unmarshal config: %w", err)
 	}
-	if strings.TrimSpace(cfg.Database.URL) == "" {
-		return Config{}, fmt.Errorf("database.url is required (from config file or DATABASE_URL)")
+	switch cfg.Database.Driver {
+	case DriverPostgres:
+		if strings.TrimSpace(cfg.Database.URL) == "" {
+			return Config{}, fmt.Errorf("database.url is required (from config file or DATABASE_URL)")
+		}
+	case DriverFile:
+		if strings.TrimSpace(cfg.Database.Path) == "" {
+			return Config{}, fmt.Errorf("database.path is required for the file driver")
+		}
+	case DriverMemory:
+	default:
+		return Config{}, fmt.Errorf("unsupported database.driver %q, expected postgres, memory or file", cfg.Database.Driver)
 	}
 	if strings.TrimSpace(cfg.Server.Port) == "" {
 		return Config{}, fmt.Errorf("server.port is required (from config file or PORT)")
@@ -2,11 +2,5 @@ SELECT 'CREATE DATABASE packing'
 WHERE NOT EXISTS (SELECT FROM pg_database WHERE datname = 'packing')
 \gexec
 
-\connect packing
-
-CREATE TABLE IF NOT EXISTS pack_configs (
-    id SMALLINT PRIMARY KEY CHECK (id = 1),
-    pack_sizes INTEGER[] NOT NULL,
-    version BIGINT NOT NULL,
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
+-- The schema lives in internal/infrastructure/postgres/migrations and is applied
+-- by `api migrate up`, or on startup when database.auto_migrate is set.
@@ -28,10 +28,16 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "OK (CostCalculationResponse for objective=min_cost)",
                         "schema": {
                             "type": "array",
-                            "items": {"$ref": "#/definitions/PackBreakdown"}
+                            "items": {"$ref": "#/definitions/PackLineResponse"}
+                        },
+                        "headers": {
+                            "X-Calculation-ID": {
+                                "type": "integer",
+                                "description": "ID of the stored calculation"
+                            }
                         }
                     },
                     "400": {
@@ -46,6 +52,249 @@ const docTemplate = `{
                         "description": "Internal Server Error",
                         "schema": {"$ref": "#/definitions/ErrorResponse"}
                     }
+                },
+                "description": "Returns the optimal pack allocation for the requested amount, using the pack sizes of the given SKU or the default profile. With objective=min_cost the response is a CostCalculationResponse instead of the array. The config version active now is used, or the one active at as_of. The calculation is stored, and its ID is sent in X-Calculation-ID."
+            }
+        },
+        "/api/v1/calculate/batch": {
+            "post": {
+                "summary": "Calculate many pack breakdowns",
+                "tags": ["Calculate"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {"$ref": "#/definitions/BatchCalculateRequest"}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/BatchCalculateResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                },
+                "description": "Solves every item in parallel and reports results and errors per item, in request order. Item fields override the batch-level sku, objective, rules and stock_limits. Each solved item is stored and carries its calculation_id."
+            }
+        },
+        "/api/v1/simulations": {
+            "post": {
+                "summary": "Simulate candidate pack sizes",
+                "description": "Replays historical orders against the current pack sizes and a candidate set, and reports the change in overfill, pack count and per-size usage plus the orders that got worse. Send JSON, or multipart/form-data with a CSV file \"orders\" (one amount per row, first column; a header row is skipped) and the fields \"pack_sizes\" (comma-separated) and \"sku\". Stock limits are ignored and nothing is stored.",
+                "tags": ["Simulations"],
+                "consumes": ["application/json", "multipart/form-data"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {"$ref": "#/definitions/SimulationRequest"}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/SimulationResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/calculations": {
+            "get": {
+                "summary": "List stored calculations",
+                "description": "Lists past calculations, newest first. Filters combine; from is inclusive and to exclusive, and both amount bounds are inclusive. Pass next_cursor of a page as cursor to get the next one.",
+                "tags": ["Calculations"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "query",
+                        "required": false,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "from",
+                        "in": "query",
+                        "required": false,
+                        "type": "string",
+                        "description": "Earliest creation time, RFC 3339"
+                    },
+                    {
+                        "name": "to",
+                        "in": "query",
+                        "required": false,
+                        "type": "string",
+                        "description": "Creation time to stop before, RFC 3339"
+                    },
+                    {
+                        "name": "min_amount",
+                        "in": "query",
+                        "required": false,
+                        "type": "integer",
+                        "description": "Smallest amount"
+                    },
+                    {
+                        "name": "max_amount",
+                        "in": "query",
+                        "required": false,
+                        "type": "integer",
+                        "description": "Largest amount"
+                    },
+                    {
+                        "name": "limit",
+                        "in": "query",
+                        "required": false,
+                        "type": "integer",
+                        "description": "Page size (default 50, at most 500)"
+                    },
+                    {
+                        "name": "cursor",
+                        "in": "query",
+                        "required": false,
+                        "type": "integer",
+                        "description": "next_cursor of the previous page"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/CalculationListResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/calculations/{id}": {
+            "get": {
+                "summary": "Get a stored calculation",
+                "description": "Returns a past calculation with its request options, the config version it used, the breakdown, the solver and how long it took.",
+                "tags": ["Calculations"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Calculation ID"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/StoredCalculationResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/replays": {
+            "post": {
+                "summary": "Start a replay",
+                "description": "Re-runs past calculations with their stored options on the config version active now, or on the given version, and reports every breakdown that would change. Send JSON to replay stored calculations matching the filters, or multipart/form-data with an export file \"calculations\" (NDJSON shaped like GET /calculations, or CSV with an amount and a packs column such as \"500:1;250:1\"), an optional \"format\" (ndjson or csv, otherwise guessed from the file name) and an optional \"version\". The replay runs in the background; poll the Location header for progress and the report. Replayed answers are not stored.",
+                "tags": ["Replays"],
+                "consumes": ["application/json", "multipart/form-data"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": false,
+                        "schema": {"$ref": "#/definitions/ReplayRequest"}
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {"$ref": "#/definitions/ReplayJobResponse"},
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL to poll the replay at"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/replays/{id}": {
+            "get": {
+                "summary": "Get a replay",
+                "description": "Returns the progress of a replay and, once it is done, its report. Finished replays are kept in memory for a while and are lost on restart.",
+                "tags": ["Replays"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Replay ID"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/ReplayJobResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
                 }
             }
         },
@@ -57,7 +306,13 @@ const docTemplate = `{
                 "responses": {
                     "200": {
                         "description": "OK",
-                        "schema": {"$ref": "#/definitions/PackSizesResponse"}
+                        "schema": {"$ref": "#/definitions/PackSizesResponse"},
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted config version"
+                            }
+                        }
                     },
                     "500": {
                         "description": "Internal Server Error",
@@ -71,6 +326,34 @@ const docTemplate = `{
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
+                    {
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": false,
+                        "type": "string",
+                        "description": "ETag of the version the change is based on"
+                    },
+                    {
+                        "name": "analyze",
+                        "in": "query",
+                        "required": false,
+                        "type": "boolean",
+                        "description": "Include an analysis of the new pack sizes"
+                    },
+                    {
+                        "name": "dry_run",
+                        "in": "query",
+                        "required": false,
+                        "type": "boolean",
+                        "description": "Validate and preview the change without storing it"
+                    },
+                    {
+                        "name": "sample",
+                        "in": "query",
+                        "required": false,
+                        "type": "string",
+                        "description": "Comma-separated amounts a dry run solves (default from config)"
+                    },
                     {
                         "name": "request",
                         "in": "body",
@@ -81,73 +364,3313 @@ const docTemplate = `{
                 "responses": {
                     "200": {
                         "description": "OK",
-                        "schema": {"$ref": "#/definitions/PackSizesResponse"}
+                        "schema": {"$ref": "#/definitions/PackSizesResponse"},
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted config version"
+                            }
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {"$ref": "#/definitions/ErrorResponse"}
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
                     "409": {
                         "description": "Conflict",
                         "schema": {"$ref": "#/definitions/ErrorResponse"}
                     },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {"$ref": "#/definitions/ErrorResponse"}
                     }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "CalculateRequest": {
-            "type": "object",
-            "required": ["amount"],
-            "properties": {
-                "amount": {
-                    "type": "integer",
-                    "example": 251
-                }
+                },
+                "description": "Replaces all pack sizes. Metadata, stock limits, costs and rules left out of the body keep their stored values for the sizes that remain; send an empty list to clear them. Send the ETag from GET as If-Match to reject writes based on a stale read. With effective_from the new version is queued and calculations keep using the active one until then; GET always returns the latest written version. Rejected with 403 REVIEW_REQUIRED unless server.require_review is turned off, except for dry runs; publish an approved draft instead. With analyze=true the response includes an analysis of the new sizes. With dry_run=true nothing is stored; the response is a PackSizesPreviewResponse with the sizes added and removed, the analysis warnings and how the sample amounts would ship."
             }
         },
-        "PackSizesRequest": {
-            "type": "object",
-            "required": ["pack_sizes"],
-            "properties": {
-                "pack_sizes": {
-                    "type": "array",
-                    "items": {"type": "integer"},
-                    "example": [250, 500, 1000, 2000, 5000]
+        "/api/v1/pack-sizes/active": {
+            "get": {
+                "summary": "Get active pack sizes",
+                "description": "Returns the pack configuration version that calculations use now, or at as_of. It differs from GET /pack-sizes while a newer version is scheduled.",
+                "tags": ["Pack Sizes"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "as_of",
+                        "in": "query",
+                        "required": false,
+                        "type": "string",
+                        "description": "RFC 3339 time to resolve the version at (default now)"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigVersionResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
                 }
             }
         },
-        "PackSizesResponse": {
-            "type": "object",
-            "properties": {
-                "pack_sizes": {
-                    "type": "array",
-                    "items": {"type": "integer"}
+        "/api/v1/pack-sizes/history": {
+            "get": {
+                "summary": "List pack size history",
+                "description": "Returns the stored pack configuration versions, newest first. Pass next_cursor of a page as cursor to get the next one.",
+                "tags": ["Pack Sizes"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "limit",
+                        "in": "query",
+                        "required": false,
+                        "type": "integer",
+                        "description": "Page size (default 50, at most 500)"
+                    },
+                    {
+                        "name": "cursor",
+                        "in": "query",
+                        "required": false,
+                        "type": "integer",
+                        "description": "next_cursor of the previous page"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackSizesHistoryResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
                 }
             }
         },
-        "PackBreakdown": {
-            "type": "object",
-            "properties": {
-                "size": {"type": "integer"},
-                "count": {"type": "integer"}
-            }
-        },
-        "ErrorBody": {
-            "type": "object",
-            "properties": {
-                "code": {"type": "string"},
-                "message": {"type": "string"}
-            }
-        },
-        "ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {"$ref": "#/definitions/ErrorBody"}
+        "/api/v1/pack-sizes/analysis": {
+            "get": {
+                "summary": "Analyze pack sizes",
+                "description": "Reports the GCD, the Frobenius number and how many amounts cannot be hit exactly. Flags sizes that never lower overfill or are never used up to a bound, and gives the worst overfill per amount range.",
+                "tags": ["Pack Sizes"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "up_to",
+                        "in": "query",
+                        "required": false,
+                        "type": "integer",
+                        "description": "Largest amount to solve (default 10x the largest pack, max 1000000)"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackSizesAnalysisResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/pack-sizes/recommendations": {
+            "post": {
+                "summary": "Recommend pack sizes",
+                "description": "Searches for the pack sizes that ship a histogram of order amounts with the least expected overfill, then the fewest expected packs, under the default calculation rules. Returns the best sets and scores the current sizes for comparison.",
+                "tags": ["Pack Sizes"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {"$ref": "#/definitions/RecommendPackSizesRequest"}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackSizeRecommendationResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/pack-sizes/versions/{version}": {
+            "get": {
+                "summary": "Get a pack size version",
+                "tags": ["Pack Sizes"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "version",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Config version"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigVersionResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/pack-sizes/rollback/{version}": {
+            "post": {
+                "summary": "Roll back pack sizes",
+                "description": "Creates a new version that restores the pack sizes of an older version. Rejected with 403 REVIEW_REQUIRED unless server.require_review is turned off.",
+                "tags": ["Pack Sizes"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "version",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Version to restore"
+                    },
+                    {
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": false,
+                        "type": "string",
+                        "description": "ETag of the version the change is based on"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": false,
+                        "schema": {"$ref": "#/definitions/RollbackRequest"}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackSizesResponse"},
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted config version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/pack-sizes/drafts": {
+            "post": {
+                "summary": "Create a pack size draft",
+                "description": "Validates a pack-size change like PUT /pack-sizes and stores it as a draft authored by X-Actor, without changing the config. Send If-Match to base the draft on a known version.",
+                "tags": ["Drafts"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true,
+                        "type": "string",
+                        "description": "Author of the draft"
+                    },
+                    {
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": false,
+                        "type": "string",
+                        "description": "ETag of the version the draft is based on"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {"$ref": "#/definitions/PackSizesRequest"}
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            },
+            "get": {
+                "summary": "List pack size drafts",
+                "description": "Returns the drafts of a SKU, newest first, without their comments.",
+                "tags": ["Drafts"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "status",
+                        "in": "query",
+                        "required": false,
+                        "type": "string",
+                        "description": "Only drafts in this status",
+                        "enum": ["draft", "approved", "rejected", "published"]
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftsResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/pack-sizes/drafts/{id}": {
+            "get": {
+                "summary": "Get a pack size draft",
+                "description": "Returns one draft with its comments.",
+                "tags": ["Drafts"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Draft ID"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            },
+            "put": {
+                "summary": "Edit a pack size draft",
+                "description": "Replaces the proposed config of a draft that is not yet approved and rebases it on the current version. Only the author, sent as X-Actor, may edit a draft.",
+                "tags": ["Drafts"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Draft ID"
+                    },
+                    {
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true,
+                        "type": "string",
+                        "description": "Author of the draft"
+                    },
+                    {
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": false,
+                        "type": "string",
+                        "description": "ETag of the version the draft is based on"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {"$ref": "#/definitions/PackSizesRequest"}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/pack-sizes/drafts/{id}/comments": {
+            "post": {
+                "summary": "Comment on a pack size draft",
+                "description": "Attaches a review comment by X-Actor to a draft.",
+                "tags": ["Drafts"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Draft ID"
+                    },
+                    {
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true,
+                        "type": "string",
+                        "description": "Author of the comment"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {"$ref": "#/definitions/DraftCommentRequest"}
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/pack-sizes/drafts/{id}/approve": {
+            "post": {
+                "summary": "Approve a pack size draft",
+                "description": "Signs a draft off on behalf of X-Actor. The author of a draft cannot approve it. X-Actor is trusted as sent, so this only holds behind a proxy that authenticates callers and sets the header.",
+                "tags": ["Drafts"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Draft ID"
+                    },
+                    {
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true,
+                        "type": "string",
+                        "description": "Reviewer"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/pack-sizes/drafts/{id}/reject": {
+            "post": {
+                "summary": "Reject a pack size draft",
+                "description": "Closes a draft that was not published, on behalf of X-Actor. The optional comment is attached as the reason.",
+                "tags": ["Drafts"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Draft ID"
+                    },
+                    {
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true,
+                        "type": "string",
+                        "description": "Reviewer"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": false,
+                        "schema": {"$ref": "#/definitions/DraftCommentRequest"}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/pack-sizes/drafts/{id}/publish": {
+            "post": {
+                "summary": "Publish a pack size draft",
+                "description": "Writes an approved draft as the next config version, on behalf of X-Actor. Fails with 412 when another version was written since the draft was made. The new version is sent as ETag.",
+                "tags": ["Drafts"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Draft ID"
+                    },
+                    {
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true,
+                        "type": "string",
+                        "description": "Publisher"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftResponse"},
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted config version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/pack-sizes/{size}": {
+            "post": {
+                "summary": "Add one pack size",
+                "description": "Adds a size, with optional metadata, to the stored sizes and keeps everything else. Without If-Match, a write that loses a race against another writer is applied again to the version that writer stored, so concurrent edits of different sizes all land. Adding a size that is configured already fails with 409 PACK_SIZE_EXISTS. A SKU without a configuration gets one holding only the new size. Rejected with 403 REVIEW_REQUIRED unless server.require_review is turned off.",
+                "tags": ["Pack Sizes"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "size",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Pack size"
+                    },
+                    {
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": false,
+                        "type": "string",
+                        "description": "ETag of the version the change is based on"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": false,
+                        "schema": {"$ref": "#/definitions/AddPackSizeRequest"}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackSizesResponse"},
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted config version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            },
+            "delete": {
+                "summary": "Remove one pack size",
+                "description": "Removes a size with its metadata, stock limit and cost, and keeps the other sizes. Concurrent writes are retried like on POST. Removing a size that is not configured fails with 404 PACK_SIZE_NOT_FOUND, and at least one active size must remain. Rejected with 403 REVIEW_REQUIRED unless server.require_review is turned off.",
+                "tags": ["Pack Sizes"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "size",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Pack size"
+                    },
+                    {
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": false,
+                        "type": "string",
+                        "description": "ETag of the version the change is based on"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackSizesResponse"},
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted config version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{sku}/pack-sizes": {
+            "get": {
+                "summary": "Get current pack sizes",
+                "tags": ["Products"],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackSizesResponse"},
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted config version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                },
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    }
+                ]
+            },
+            "put": {
+                "summary": "Replace pack sizes",
+                "tags": ["Products"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": false,
+                        "type": "string",
+                        "description": "ETag of the version the change is based on"
+                    },
+                    {
+                        "name": "analyze",
+                        "in": "query",
+                        "required": false,
+                        "type": "boolean",
+                        "description": "Include an analysis of the new pack sizes"
+                    },
+                    {
+                        "name": "dry_run",
+                        "in": "query",
+                        "required": false,
+                        "type": "boolean",
+                        "description": "Validate and preview the change without storing it"
+                    },
+                    {
+                        "name": "sample",
+                        "in": "query",
+                        "required": false,
+                        "type": "string",
+                        "description": "Comma-separated amounts a dry run solves (default from config)"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {"$ref": "#/definitions/PackSizesRequest"}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackSizesResponse"},
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted config version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                },
+                "description": "Replaces all pack sizes. Metadata, stock limits, costs and rules left out of the body keep their stored values for the sizes that remain; send an empty list to clear them. Send the ETag from GET as If-Match to reject writes based on a stale read. With effective_from the new version is queued and calculations keep using the active one until then; GET always returns the latest written version. Rejected with 403 REVIEW_REQUIRED unless server.require_review is turned off, except for dry runs; publish an approved draft instead. With analyze=true the response includes an analysis of the new sizes. With dry_run=true nothing is stored; the response is a PackSizesPreviewResponse with the sizes added and removed, the analysis warnings and how the sample amounts would ship."
+            },
+            "delete": {
+                "summary": "Delete product pack sizes",
+                "description": "Removes the pack configuration of a product together with its history. Rejected with 403 REVIEW_REQUIRED unless server.require_review is turned off.",
+                "tags": ["Products"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    }
+                ],
+                "responses": {
+                    "204": {"description": "No Content"},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{sku}/pack-sizes/active": {
+            "get": {
+                "summary": "Get active pack sizes",
+                "description": "Returns the pack configuration version that calculations use now, or at as_of. It differs from GET /pack-sizes while a newer version is scheduled.",
+                "tags": ["Pack Sizes"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "as_of",
+                        "in": "query",
+                        "required": false,
+                        "type": "string",
+                        "description": "RFC 3339 time to resolve the version at (default now)"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigVersionResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{sku}/pack-sizes/history": {
+            "get": {
+                "summary": "List pack size history",
+                "description": "Returns the stored pack configuration versions, newest first. Pass next_cursor of a page as cursor to get the next one.",
+                "tags": ["Products"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "limit",
+                        "in": "query",
+                        "required": false,
+                        "type": "integer",
+                        "description": "Page size (default 50, at most 500)"
+                    },
+                    {
+                        "name": "cursor",
+                        "in": "query",
+                        "required": false,
+                        "type": "integer",
+                        "description": "next_cursor of the previous page"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackSizesHistoryResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{sku}/pack-sizes/analysis": {
+            "get": {
+                "summary": "Analyze pack sizes",
+                "description": "Reports the GCD, the Frobenius number and how many amounts cannot be hit exactly. Flags sizes that never lower overfill or are never used up to a bound, and gives the worst overfill per amount range.",
+                "tags": ["Products"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "up_to",
+                        "in": "query",
+                        "required": false,
+                        "type": "integer",
+                        "description": "Largest amount to solve (default 10x the largest pack, max 1000000)"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackSizesAnalysisResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{sku}/pack-sizes/recommendations": {
+            "post": {
+                "summary": "Recommend pack sizes",
+                "description": "Searches for the pack sizes that ship a histogram of order amounts with the least expected overfill, then the fewest expected packs, under the default calculation rules. Returns the best sets and scores the current sizes for comparison.",
+                "tags": ["Products"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {"$ref": "#/definitions/RecommendPackSizesRequest"}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackSizeRecommendationResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{sku}/pack-sizes/versions/{version}": {
+            "get": {
+                "summary": "Get a pack size version",
+                "tags": ["Products"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "version",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Config version"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigVersionResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{sku}/pack-sizes/rollback/{version}": {
+            "post": {
+                "summary": "Roll back pack sizes",
+                "description": "Creates a new version that restores the pack sizes of an older version. Rejected with 403 REVIEW_REQUIRED unless server.require_review is turned off.",
+                "tags": ["Products"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "version",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Version to restore"
+                    },
+                    {
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": false,
+                        "type": "string",
+                        "description": "ETag of the version the change is based on"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": false,
+                        "schema": {"$ref": "#/definitions/RollbackRequest"}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackSizesResponse"},
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted config version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{sku}/pack-sizes/drafts": {
+            "post": {
+                "summary": "Create a pack size draft",
+                "description": "Validates a pack-size change like PUT /pack-sizes and stores it as a draft authored by X-Actor, without changing the config. Send If-Match to base the draft on a known version.",
+                "tags": ["Drafts"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true,
+                        "type": "string",
+                        "description": "Author of the draft"
+                    },
+                    {
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": false,
+                        "type": "string",
+                        "description": "ETag of the version the draft is based on"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {"$ref": "#/definitions/PackSizesRequest"}
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            },
+            "get": {
+                "summary": "List pack size drafts",
+                "description": "Returns the drafts of a SKU, newest first, without their comments.",
+                "tags": ["Drafts"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "status",
+                        "in": "query",
+                        "required": false,
+                        "type": "string",
+                        "description": "Only drafts in this status",
+                        "enum": ["draft", "approved", "rejected", "published"]
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftsResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{sku}/pack-sizes/drafts/{id}": {
+            "get": {
+                "summary": "Get a pack size draft",
+                "description": "Returns one draft with its comments.",
+                "tags": ["Drafts"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Draft ID"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            },
+            "put": {
+                "summary": "Edit a pack size draft",
+                "description": "Replaces the proposed config of a draft that is not yet approved and rebases it on the current version. Only the author, sent as X-Actor, may edit a draft.",
+                "tags": ["Drafts"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Draft ID"
+                    },
+                    {
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true,
+                        "type": "string",
+                        "description": "Author of the draft"
+                    },
+                    {
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": false,
+                        "type": "string",
+                        "description": "ETag of the version the draft is based on"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {"$ref": "#/definitions/PackSizesRequest"}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{sku}/pack-sizes/drafts/{id}/comments": {
+            "post": {
+                "summary": "Comment on a pack size draft",
+                "description": "Attaches a review comment by X-Actor to a draft.",
+                "tags": ["Drafts"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Draft ID"
+                    },
+                    {
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true,
+                        "type": "string",
+                        "description": "Author of the comment"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {"$ref": "#/definitions/DraftCommentRequest"}
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{sku}/pack-sizes/drafts/{id}/approve": {
+            "post": {
+                "summary": "Approve a pack size draft",
+                "description": "Signs a draft off on behalf of X-Actor. The author of a draft cannot approve it. X-Actor is trusted as sent, so this only holds behind a proxy that authenticates callers and sets the header.",
+                "tags": ["Drafts"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Draft ID"
+                    },
+                    {
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true,
+                        "type": "string",
+                        "description": "Reviewer"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{sku}/pack-sizes/drafts/{id}/reject": {
+            "post": {
+                "summary": "Reject a pack size draft",
+                "description": "Closes a draft that was not published, on behalf of X-Actor. The optional comment is attached as the reason.",
+                "tags": ["Drafts"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Draft ID"
+                    },
+                    {
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true,
+                        "type": "string",
+                        "description": "Reviewer"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": false,
+                        "schema": {"$ref": "#/definitions/DraftCommentRequest"}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftResponse"}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{sku}/pack-sizes/drafts/{id}/publish": {
+            "post": {
+                "summary": "Publish a pack size draft",
+                "description": "Writes an approved draft as the next config version, on behalf of X-Actor. Fails with 412 when another version was written since the draft was made. The new version is sent as ETag.",
+                "tags": ["Drafts"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Draft ID"
+                    },
+                    {
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true,
+                        "type": "string",
+                        "description": "Publisher"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackConfigDraftResponse"},
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted config version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{sku}/pack-sizes/{size}": {
+            "post": {
+                "summary": "Add one pack size",
+                "description": "Adds a size, with optional metadata, to the stored sizes and keeps everything else. Without If-Match, a write that loses a race against another writer is applied again to the version that writer stored, so concurrent edits of different sizes all land. Adding a size that is configured already fails with 409 PACK_SIZE_EXISTS. A SKU without a configuration gets one holding only the new size. Rejected with 403 REVIEW_REQUIRED unless server.require_review is turned off.",
+                "tags": ["Products"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "size",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Pack size"
+                    },
+                    {
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": false,
+                        "type": "string",
+                        "description": "ETag of the version the change is based on"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": false,
+                        "schema": {"$ref": "#/definitions/AddPackSizeRequest"}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackSizesResponse"},
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted config version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            },
+            "delete": {
+                "summary": "Remove one pack size",
+                "description": "Removes a size with its metadata, stock limit and cost, and keeps the other sizes. Concurrent writes are retried like on POST. Removing a size that is not configured fails with 404 PACK_SIZE_NOT_FOUND, and at least one active size must remain. Rejected with 403 REVIEW_REQUIRED unless server.require_review is turned off.",
+                "tags": ["Products"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sku",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Product code"
+                    },
+                    {
+                        "name": "size",
+                        "in": "path",
+                        "required": true,
+                        "type": "integer",
+                        "description": "Pack size"
+                    },
+                    {
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": false,
+                        "type": "string",
+                        "description": "ETag of the version the change is based on"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/PackSizesResponse"},
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted config version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v1/products": {
+            "get": {
+                "summary": "List product pack sizes",
+                "tags": ["Products"],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/ProductsResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                }
+            }
+        },
+        "/api/v2/calculate": {
+            "post": {
+                "summary": "Calculate pack breakdown with totals and explanation",
+                "tags": ["Calculate"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "explain",
+                        "in": "query",
+                        "required": false,
+                        "type": "boolean",
+                        "description": "List the runner-up breakdowns"
+                    },
+                    {
+                        "name": "alternatives",
+                        "in": "query",
+                        "required": false,
+                        "type": "integer",
+                        "description": "Return the K best breakdowns (1-10)"
+                    },
+                    {
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {"$ref": "#/definitions/CalculateRequest"}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {"$ref": "#/definitions/CalculationResponse"},
+                        "headers": {
+                            "X-Calculation-ID": {
+                                "type": "integer",
+                                "description": "ID of the stored calculation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {"$ref": "#/definitions/ErrorResponse"}
+                    }
+                },
+                "description": "Accepts the same body as v1. The response adds shipped total, overfill, pack count, config version and solver, and explains which rule decided. With explain=true it also lists the runner-up breakdowns that lost, and alternatives=K returns the K best distinct breakdowns ranked by the active rules. The calculation is stored; its ID is in calculation_id and X-Calculation-ID."
+            }
+        }
+    },
+    "definitions": {
+        "CalculateRequest": {
+            "type": "object",
+            "required": ["amount"],
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "maximum": 1000000000000,
+                    "example": 251
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "stock_limits": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/StockLimit"}
+                },
+                "objective": {
+                    "type": "string",
+                    "enum": ["min_overfill", "min_cost"],
+                    "example": "min_overfill"
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/Rule"},
+                    "description": "Rule chain applied in order; overrides the chain stored for the SKU"
+                },
+                "as_of": {
+                    "type": "string",
+                    "format": "date-time",
+                    "description": "Resolve the config version active at this time; omitted means now",
+                    "example": "2026-03-01T00:00:00Z"
+                }
+            }
+        },
+        "PackSizesRequest": {
+            "type": "object",
+            "required": ["pack_sizes"],
+            "properties": {
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {"type": "integer"},
+                    "example": [250, 500, 1000, 2000, 5000]
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackSizeDetails"},
+                    "description": "Metadata of sizes of pack_sizes; sizes without an entry are active without metadata. Omitted keeps the stored metadata of the remaining sizes"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "new 5000 box"
+                },
+                "stock_limits": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/StockLimit"},
+                    "description": "Availability per size; sizes without an entry are unlimited. Omitted keeps the stored limits of the remaining sizes, an empty list removes them"
+                },
+                "pack_costs": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackSizeCost"},
+                    "description": "Prices per size for the min_cost objective. Omitted keeps the stored costs of the remaining sizes, an empty list removes them"
+                },
+                "overfill_item_cost": {
+                    "type": "integer",
+                    "description": "Cost per item shipped above the amount; omitted keeps the stored value",
+                    "example": 1
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/Rule"},
+                    "description": "Default rule chain; omitted keeps the stored chain, an empty list means overfill, then pack_count"
+                },
+                "effective_from": {
+                    "type": "string",
+                    "format": "date-time",
+                    "description": "Queue the version to apply from this future time; omitted applies it at once",
+                    "example": "2026-03-01T00:00:00Z"
+                },
+                "effective_to": {
+                    "type": "string",
+                    "format": "date-time",
+                    "description": "Retire the version at this time; omitted keeps it until a later version starts",
+                    "example": "2026-03-31T23:59:59Z"
+                }
+            }
+        },
+        "PackSizesResponse": {
+            "type": "object",
+            "properties": {
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {"type": "integer"}
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackSizeDetails"},
+                    "description": "Every size of pack_sizes with its metadata"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "updated_by": {"type": "string"},
+                "reason": {"type": "string"},
+                "stock_limits": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/StockLimit"}
+                },
+                "pack_costs": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackSizeCost"}
+                },
+                "overfill_item_cost": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/Rule"}
+                },
+                "effective_from": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "effective_to": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "analysis": {"$ref": "#/definitions/PackSizesAnalysisResponse"}
+            }
+        },
+        "PackSizesPreviewResponse": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "current_version": {
+                    "type": "integer",
+                    "example": 3,
+                    "description": "Omitted for a SKU without a configuration"
+                },
+                "current_pack_sizes": {
+                    "type": "array",
+                    "items": {"type": "integer"},
+                    "example": [250, 500, 1000, 2000, 5000]
+                },
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {"type": "integer"},
+                    "example": [250, 500, 1000, 2000]
+                },
+                "added": {
+                    "type": "array",
+                    "items": {"type": "integer"}
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {"type": "integer"},
+                    "example": [5000]
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {"type": "string"},
+                    "description": "Analysis warnings of the new sizes"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/BreakdownChangeResponse"}
+                },
+                "changed_count": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "BreakdownChangeResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 12001
+                },
+                "current": {"$ref": "#/definitions/CandidateResponse"},
+                "proposed": {"$ref": "#/definitions/CandidateResponse"},
+                "changed": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "PackSizesAnalysisResponse": {
+            "type": "object",
+            "properties": {
+                "gcd": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "frobenius_number": {
+                    "type": "integer",
+                    "example": 43,
+                    "description": "Largest amount no combination hits exactly; null when gcd > 1"
+                },
+                "unreachable_count": {
+                    "type": "integer",
+                    "example": 22,
+                    "description": "How many amounts cannot be hit exactly; null when gcd > 1"
+                },
+                "redundant_for_overfill": {
+                    "type": "array",
+                    "items": {"type": "integer"},
+                    "description": "Sizes that are sums of smaller sizes"
+                },
+                "unused_sizes": {
+                    "type": "array",
+                    "items": {"type": "integer"},
+                    "description": "Sizes no breakdown up to up_to uses"
+                },
+                "up_to": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "overfill_ranges": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/OverfillRangeResponse"}
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {"type": "string"}
+                }
+            }
+        },
+        "OverfillRangeResponse": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "to": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "max_overfill": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "worst_amount": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "DemandBucket": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 251
+                },
+                "count": {
+                    "type": "integer",
+                    "example": 40
+                }
+            }
+        },
+        "RecommendPackSizesRequest": {
+            "type": "object",
+            "properties": {
+                "histogram": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/DemandBucket"}
+                },
+                "size_count": {
+                    "type": "integer",
+                    "example": 4,
+                    "description": "Pack sizes per candidate set, fixed sizes included"
+                },
+                "fixed_sizes": {
+                    "type": "array",
+                    "items": {"type": "integer"},
+                    "example": [5000]
+                },
+                "candidate_sizes": {
+                    "type": "array",
+                    "items": {"type": "integer"},
+                    "example": [250, 500, 750, 1000, 2000, 5000],
+                    "description": "Sizes to pick from; omitted means the most demanded amounts"
+                },
+                "top": {
+                    "type": "integer",
+                    "example": 5,
+                    "description": "How many sets to return, 1 to 10 (default 5)"
+                }
+            }
+        },
+        "PackSizeRecommendationResponse": {
+            "type": "object",
+            "properties": {
+                "candidates": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/RecommendedPackSizesResponse"}
+                },
+                "current": {"$ref": "#/definitions/RecommendedPackSizesResponse"},
+                "evaluated": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "exhaustive": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "RecommendedPackSizesResponse": {
+            "type": "object",
+            "properties": {
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {"type": "integer"},
+                    "example": [250, 500, 1000, 5000]
+                },
+                "expected_overfill": {
+                    "type": "number",
+                    "example": 12.5
+                },
+                "expected_pack_count": {
+                    "type": "number",
+                    "example": 1.8
+                },
+                "overfill_pct": {
+                    "type": "number",
+                    "example": 1.2,
+                    "description": "Total overfill as a percentage of the total demand"
+                },
+                "exact_share": {
+                    "type": "number",
+                    "example": 0.75,
+                    "description": "Share of orders shipped without overfill"
+                }
+            }
+        },
+        "PackConfigVersionResponse": {
+            "type": "object",
+            "properties": {
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {"type": "integer"}
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackSizeDetails"},
+                    "description": "Every size of pack_sizes with its metadata"
+                },
+                "changed_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "changed_by": {
+                    "type": "string",
+                    "example": "jane"
+                },
+                "reason": {"type": "string"},
+                "stock_limits": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/StockLimit"}
+                },
+                "pack_costs": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackSizeCost"}
+                },
+                "overfill_item_cost": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/Rule"}
+                },
+                "effective_from": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "effective_to": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "PackSizesHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "versions": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackConfigVersionResponse"}
+                },
+                "next_cursor": {
+                    "type": "integer",
+                    "example": 4,
+                    "description": "NextCursor fetches the next page as ?cursor=; omitted on the last page."
+                }
+            }
+        },
+        "AddPackSizeRequest": {
+            "type": "object",
+            "properties": {
+                "label": {
+                    "type": "string",
+                    "description": "Up to 100 bytes",
+                    "example": "Seasonal box"
+                },
+                "packaging_sku": {
+                    "type": "string",
+                    "description": "Up to 64 bytes",
+                    "example": "BOX-S750"
+                },
+                "dimensions": {"$ref": "#/definitions/PackDimensions"},
+                "tare_weight_g": {
+                    "type": "integer",
+                    "description": "Tare weight in grams",
+                    "example": 150
+                },
+                "active": {
+                    "type": "boolean",
+                    "description": "Defaults to true",
+                    "example": true
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "new 750 box"
+                }
+            }
+        },
+        "RollbackRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "revert accidental change"
+                }
+            }
+        },
+        "ProductPackSizesResponse": {
+            "type": "object",
+            "properties": {
+                "sku": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {"type": "integer"}
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "ProductsResponse": {
+            "type": "object",
+            "properties": {
+                "products": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/ProductPackSizesResponse"}
+                }
+            }
+        },
+        "StockLimit": {
+            "type": "object",
+            "required": ["size", "available"],
+            "properties": {
+                "size": {
+                    "type": "integer",
+                    "example": 5000
+                },
+                "available": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "SimulationRequest": {
+            "type": "object",
+            "properties": {
+                "sku": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {"type": "integer"},
+                    "example": [250, 500, 1000, 2000]
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {"type": "integer"},
+                    "example": [251, 501, 12001]
+                }
+            }
+        },
+        "SimulationResponse": {
+            "type": "object",
+            "properties": {
+                "sku": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "config_version": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "current_pack_sizes": {
+                    "type": "array",
+                    "items": {"type": "integer"},
+                    "example": [250, 500, 1000, 2000, 5000]
+                },
+                "candidate_pack_sizes": {
+                    "type": "array",
+                    "items": {"type": "integer"},
+                    "example": [250, 500, 1000, 2000]
+                },
+                "orders": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "current": {"$ref": "#/definitions/SimulationTotalsResponse"},
+                "candidate": {"$ref": "#/definitions/SimulationTotalsResponse"},
+                "delta": {
+                    "$ref": "#/definitions/SimulationTotalsResponse",
+                    "description": "Candidate minus current"
+                },
+                "size_usage": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/SizeUsageResponse"}
+                },
+                "better_orders": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "worse_orders": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "worse": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/SimulatedOrderResponse"},
+                    "description": "Up to 50 worsened amounts, the largest overfill increase first"
+                },
+                "truncated_orders": {
+                    "type": "integer",
+                    "example": 0,
+                    "description": "Orders whose breakdown under a custom rule chain came from a shortened candidate search"
+                }
+            }
+        },
+        "SimulationTotalsResponse": {
+            "type": "object",
+            "properties": {
+                "shipped_total": {
+                    "type": "integer",
+                    "example": 13250
+                },
+                "overfill": {
+                    "type": "integer",
+                    "example": 497
+                },
+                "pack_count": {
+                    "type": "integer",
+                    "example": 6
+                },
+                "exact_orders": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "SizeUsageResponse": {
+            "type": "object",
+            "properties": {
+                "size": {
+                    "type": "integer",
+                    "example": 2000
+                },
+                "current": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "candidate": {
+                    "type": "integer",
+                    "example": 6
+                },
+                "delta": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
+        "SimulatedOrderResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 12001
+                },
+                "orders": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "current": {"$ref": "#/definitions/CandidateResponse"},
+                "candidate": {"$ref": "#/definitions/CandidateResponse"}
+            }
+        },
+        "PackSizeCost": {
+            "type": "object",
+            "required": ["size"],
+            "properties": {
+                "size": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "unit_cost": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "handling_cost": {
+                    "type": "integer",
+                    "example": 30
+                }
+            }
+        },
+        "PackSizeDetails": {
+            "type": "object",
+            "required": ["size"],
+            "properties": {
+                "size": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "label": {
+                    "type": "string",
+                    "description": "Up to 100 bytes",
+                    "example": "Medium box"
+                },
+                "packaging_sku": {
+                    "type": "string",
+                    "description": "Up to 64 bytes",
+                    "example": "BOX-M"
+                },
+                "dimensions": {"$ref": "#/definitions/PackDimensions"},
+                "tare_weight_g": {
+                    "type": "integer",
+                    "description": "Tare weight in grams",
+                    "example": 180
+                },
+                "active": {
+                    "type": "boolean",
+                    "description": "Inactive sizes stay configured but calculations never pick them; omitted in a request means true",
+                    "example": true
+                }
+            }
+        },
+        "PackDimensions": {
+            "type": "object",
+            "description": "Outer dimensions in millimetres",
+            "properties": {
+                "length_mm": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "width_mm": {
+                    "type": "integer",
+                    "example": 300
+                },
+                "height_mm": {
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "PackCostLine": {
+            "type": "object",
+            "properties": {
+                "size": {"type": "integer"},
+                "count": {"type": "integer"},
+                "unit_cost": {"type": "integer"},
+                "handling_cost": {"type": "integer"},
+                "total": {"type": "integer"}
+            }
+        },
+        "CostBreakdown": {
+            "type": "object",
+            "properties": {
+                "lines": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackCostLine"}
+                },
+                "unit_cost": {"type": "integer"},
+                "handling_cost": {"type": "integer"},
+                "overfill_cost": {"type": "integer"},
+                "total": {"type": "integer"}
+            }
+        },
+        "CostCalculationResponse": {
+            "type": "object",
+            "properties": {
+                "packs": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackLineResponse"}
+                },
+                "cost": {"$ref": "#/definitions/CostBreakdown"}
+            }
+        },
+        "PackLineResponse": {
+            "type": "object",
+            "properties": {
+                "size": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "label": {
+                    "type": "string",
+                    "example": "Medium box"
+                },
+                "packaging_sku": {
+                    "type": "string",
+                    "example": "BOX-M"
+                },
+                "dimensions": {"$ref": "#/definitions/PackDimensions"},
+                "tare_weight_g": {
+                    "type": "integer",
+                    "example": 180
+                }
+            }
+        },
+        "ErrorBody": {
+            "type": "object",
+            "properties": {
+                "code": {"type": "string"},
+                "message": {"type": "string"},
+                "details": {
+                    "type": "array",
+                    "description": "Invalid fields of a rejected request, if known",
+                    "items": {"$ref": "#/definitions/ErrorDetail"}
+                }
+            }
+        },
+        "ErrorDetail": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "pack_sizes[2]"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "duplicate of 500"
+                }
+            }
+        },
+        "ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {"$ref": "#/definitions/ErrorBody"}
+            }
+        },
+        "Rule": {
+            "type": "object",
+            "required": ["criterion"],
+            "properties": {
+                "criterion": {
+                    "type": "string",
+                    "enum": ["overfill", "pack_count", "distinct_sizes", "prefer_large", "prefer_small"],
+                    "example": "overfill"
+                },
+                "tolerance": {
+                    "type": "integer",
+                    "description": "Absolute tolerance, in items for overfill and in packs or sizes otherwise",
+                    "minimum": 0,
+                    "maximum": 1000000000,
+                    "example": 0
+                },
+                "tolerance_pct": {
+                    "type": "number",
+                    "description": "Tolerance as a percentage of the requested amount; overfill only",
+                    "minimum": 0,
+                    "maximum": 100,
+                    "example": 2
+                }
+            }
+        },
+        "CandidateResponse": {
+            "type": "object",
+            "properties": {
+                "packs": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackLineResponse"}
+                },
+                "shipped_total": {
+                    "type": "integer",
+                    "example": 750
+                },
+                "overfill": {
+                    "type": "integer",
+                    "example": 249
+                },
+                "overfill_pct": {
+                    "type": "number",
+                    "example": 49.7
+                },
+                "pack_count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "lost_on": {
+                    "type": "string",
+                    "example": "pack_count",
+                    "description": "Rule a runner-up lost on"
+                }
+            }
+        },
+        "CalculationExplanation": {
+            "type": "object",
+            "properties": {
+                "decided_by": {
+                    "type": "string",
+                    "example": "pack_count"
+                },
+                "summary": {"type": "string"},
+                "rules": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/Rule"}
+                },
+                "runners_up": {
+                    "type": "array",
+                    "description": "Breakdowns that lost, only with explain=true",
+                    "items": {"$ref": "#/definitions/CandidateResponse"}
+                }
+            }
+        },
+        "CalculationResponse": {
+            "type": "object",
+            "properties": {
+                "calculation_id": {
+                    "type": "integer",
+                    "description": "ID of the stored calculation, see GET /api/v1/calculations/{id}",
+                    "example": 42
+                },
+                "amount": {
+                    "type": "integer",
+                    "example": 501
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackLineResponse"}
+                },
+                "shipped_total": {
+                    "type": "integer",
+                    "example": 750
+                },
+                "overfill": {
+                    "type": "integer",
+                    "example": 249
+                },
+                "overfill_pct": {
+                    "type": "number",
+                    "description": "Overfill as a percentage of the amount",
+                    "example": 49.7
+                },
+                "pack_count": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "config_version": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "solver": {
+                    "type": "string",
+                    "enum": ["residue", "bounded_knapsack", "min_cost", "rule_chain"],
+                    "example": "residue"
+                },
+                "cost": {"$ref": "#/definitions/CostBreakdown"},
+                "explanation": {"$ref": "#/definitions/CalculationExplanation"},
+                "alternatives": {
+                    "type": "array",
+                    "description": "The K best breakdowns, the chosen one first; only with alternatives=K",
+                    "items": {"$ref": "#/definitions/CandidateResponse"}
+                },
+                "candidates_truncated": {
+                    "type": "boolean",
+                    "description": "Set when the candidate search hit its limit, so a custom rule chain, the explanation or the alternatives may have missed better breakdowns"
+                }
+            }
+        },
+        "BatchCalculateRequest": {
+            "type": "object",
+            "required": ["items"],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/CalculateRequest"}
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "stock_limits": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/StockLimit"}
+                },
+                "objective": {
+                    "type": "string",
+                    "enum": ["min_overfill", "min_cost"],
+                    "example": "min_overfill"
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/Rule"}
+                },
+                "as_of": {
+                    "type": "string",
+                    "format": "date-time",
+                    "description": "Resolve the config version active at this time; omitted means now",
+                    "example": "2026-03-01T00:00:00Z"
+                }
+            }
+        },
+        "BatchItemResponse": {
+            "type": "object",
+            "properties": {
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "amount": {
+                    "type": "integer",
+                    "example": 251
+                },
+                "calculation_id": {
+                    "type": "integer",
+                    "description": "ID of the stored calculation of a solved item",
+                    "example": 42
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackLineResponse"}
+                },
+                "shipped_total": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "overfill": {
+                    "type": "integer",
+                    "example": 249
+                },
+                "pack_count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "cost": {"$ref": "#/definitions/CostBreakdown"},
+                "error": {"$ref": "#/definitions/ErrorBody"}
+            }
+        },
+        "BatchCalculateResponse": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/BatchItemResponse"}
+                },
+                "succeeded": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "StoredCalculationResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "amount": {
+                    "type": "integer",
+                    "example": 501
+                },
+                "options": {"$ref": "#/definitions/CalculationOptions"},
+                "config_version": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackBreakdown"}
+                },
+                "shipped_total": {
+                    "type": "integer",
+                    "example": 750
+                },
+                "overfill": {
+                    "type": "integer",
+                    "example": 249
+                },
+                "pack_count": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "cost": {"$ref": "#/definitions/CostBreakdown"},
+                "solver": {
+                    "type": "string",
+                    "example": "residue"
+                },
+                "duration_ms": {
+                    "type": "number",
+                    "example": 0.42
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "CalculationOptions": {
+            "type": "object",
+            "properties": {
+                "stock_limits": {
+                    "type": "object",
+                    "description": "Stock overrides sent with the request, keyed by size",
+                    "additionalProperties": {"type": "integer"}
+                },
+                "objective": {
+                    "type": "string",
+                    "enum": ["min_overfill", "min_cost"],
+                    "example": "min_overfill"
+                },
+                "rules": {
+                    "type": "array",
+                    "description": "Rule chain sent with the request",
+                    "items": {"$ref": "#/definitions/Rule"}
+                },
+                "as_of": {
+                    "type": "string",
+                    "format": "date-time",
+                    "description": "Time the config version was picked for"
+                },
+                "alternatives": {
+                    "type": "integer",
+                    "description": "Number of ranked breakdowns asked for",
+                    "example": 3
+                }
+            }
+        },
+        "PackBreakdown": {
+            "type": "object",
+            "properties": {
+                "size": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "count": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "CalculationListResponse": {
+            "type": "object",
+            "properties": {
+                "calculations": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/StoredCalculationResponse"}
+                },
+                "next_cursor": {
+                    "type": "integer",
+                    "description": "Pass as cursor to get the next page; omitted on the last page",
+                    "example": 17
+                }
+            }
+        },
+        "ReplayRequest": {
+            "type": "object",
+            "properties": {
+                "sku": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "from": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "to": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "min_amount": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "max_amount": {
+                    "type": "integer",
+                    "example": 10000
+                },
+                "version": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "description": "Replay on this config version of each SKU instead of the active one; versions start at 0",
+                    "example": 3
+                }
+            }
+        },
+        "ReplayJobResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "status": {
+                    "type": "string",
+                    "enum": ["running", "done", "failed"],
+                    "example": "running"
+                },
+                "done": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "finished_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "error": {
+                    "type": "string",
+                    "description": "Why the replay failed"
+                },
+                "report": {"$ref": "#/definitions/ReplayReportResponse"}
+            }
+        },
+        "ReplayReportResponse": {
+            "type": "object",
+            "properties": {
+                "replayed": {
+                    "type": "integer",
+                    "example": 498
+                },
+                "unchanged": {
+                    "type": "integer",
+                    "example": 480
+                },
+                "changed": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/ReplayChangeResponse"}
+                },
+                "failed": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/ReplayFailureResponse"}
+                },
+                "overfill_delta": {
+                    "type": "integer",
+                    "description": "Sum of the overfill changes, replayed minus stored",
+                    "example": -2250
+                },
+                "pack_count_delta": {
+                    "type": "integer",
+                    "description": "Sum of the pack count changes, replayed minus stored",
+                    "example": -18
+                }
+            }
+        },
+        "ReplayChangeResponse": {
+            "type": "object",
+            "properties": {
+                "calculation_id": {
+                    "type": "integer",
+                    "description": "Omitted for an exported calculation without an id",
+                    "example": 42
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "amount": {
+                    "type": "integer",
+                    "example": 1000
+                },
+                "stored_version": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "replay_version": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "before": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackBreakdown"}
+                },
+                "after": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackBreakdown"}
+                },
+                "overfill_delta": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "pack_count_delta": {
+                    "type": "integer",
+                    "example": -1
+                }
+            }
+        },
+        "ReplayFailureResponse": {
+            "type": "object",
+            "properties": {
+                "calculation_id": {
+                    "type": "integer",
+                    "example": 43
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "widget"
+                },
+                "amount": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "error": {
+                    "type": "string",
+                    "example": "pack config version not found"
+                }
+            }
+        },
+        "PackConfigDraftResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": ["draft", "approved", "rejected", "published"],
+                    "example": "draft"
+                },
+                "base_version": {
+                    "type": "integer",
+                    "example": 3,
+                    "description": "Version the draft was made from; omitted for a SKU without a config"
+                },
+                "revision": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {"type": "integer"},
+                    "example": [250, 500, 750, 1000, 2000, 5000]
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackSizeDetails"},
+                    "description": "Every size of pack_sizes with its metadata"
+                },
+                "stock_limits": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/StockLimit"}
+                },
+                "pack_costs": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackSizeCost"}
+                },
+                "overfill_item_cost": {"type": "integer"},
+                "rules": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/Rule"}
+                },
+                "effective_from": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "effective_to": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "new 750 box"
+                },
+                "created_by": {
+                    "type": "string",
+                    "example": "jane"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "reviewed_by": {
+                    "type": "string",
+                    "example": "sam"
+                },
+                "reviewed_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "published_by": {
+                    "type": "string",
+                    "example": "sam"
+                },
+                "published_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "published_version": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "comments": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/DraftCommentResponse"},
+                    "description": "Omitted from lists"
+                }
+            }
+        },
+        "DraftCommentResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "author": {
+                    "type": "string",
+                    "example": "sam"
+                },
+                "body": {
+                    "type": "string",
+                    "example": "750 boxes arrive on March 1st"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "DraftCommentRequest": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "example": "750 boxes arrive on March 1st"
+                }
+            }
+        },
+        "PackConfigDraftsResponse": {
+            "type": "object",
+            "properties": {
+                "drafts": {
+                    "type": "array",
+                    "items": {"$ref": "#/definitions/PackConfigDraftResponse"}
+                }
             }
         }
     }
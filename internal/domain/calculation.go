@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// MaxAmount is the largest amount a calculation accepts: a trillion items. The
+// solvers add a few packs to the amount, which stays far from overflowing int.
+const MaxAmount = 1_000_000_000_000
+
+// ValidateAmount checks that an amount is from 1 to MaxAmount.
+func ValidateAmount(amount int) error {
+	if amount <= 0 || amount > MaxAmount {
+		return ErrInvalidAmount
+	}
+
+	return nil
+}
+
+// Calculation is a stored calculation: what was asked, which config version
+// answered and what it answered. Calculations are never changed once stored.
+type Calculation struct {
+	ID     int64
+	SKU    string
+	Amount int
+	// Options are the request settings besides SKU and amount.
+	Options       CalculationOptions
+	ConfigVersion int64
+	Packs         []PackBreakdown
+	// Cost is set for the min_cost objective.
+	Cost   *CostBreakdown
+	Solver string
+	// Duration is how long loading the config and solving took.
+	Duration  time.Duration
+	CreatedAt time.Time
+}
+
+// CalculationOptions are the request settings of a calculation, after defaults
+// were applied.
+type CalculationOptions struct {
+	// StockLimits and Rules are the request overrides; nil means the stored ones.
+	StockLimits map[int64]int `json:"stock_limits,omitempty"`
+	Objective   Objective     `json:"objective"`
+	Rules       []Rule        `json:"rules,omitempty"`
+	// AsOf is the time the config version was picked for.
+	AsOf time.Time `json:"as_of"`
+	// Alternatives is how many ranked breakdowns were asked for; 0 means one.
+	Alternatives int `json:"alternatives,omitempty"`
+}
+
+// CalculationFilter selects stored calculations. Zero fields match everything.
+type CalculationFilter struct {
+	SKU string
+	// From and To bound CreatedAt to [From, To).
+	From time.Time
+	To   time.Time
+	// MinAmount and MaxAmount bound Amount, inclusive.
+	MinAmount int
+	MaxAmount int
+	// BeforeID continues a listing below the last ID of the previous page.
+	BeforeID int64
+	Limit    int
+}
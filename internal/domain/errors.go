@@ -3,9 +3,39 @@ package domain
 import "errors"
 
 var (
-	ErrInvalidAmount         = errors.New("amount must be greater than zero")
-	ErrPackSizesNotConfigured = errors.New("pack sizes are not configured")
-	ErrInvalidPackSizes      = errors.New("pack sizes must be non-empty unique positive integers")
-	ErrCouldNotCalculate     = errors.New("could not calculate pack selection")
-	ErrConcurrencyConflict    = errors.New("concurrency conflict")
+	ErrInvalidAmount            = errors.New("amount must be from 1 to 1000000000000")
+	ErrPackSizesNotConfigured   = errors.New("pack sizes are not configured")
+	ErrInvalidPackSizes         = errors.New("pack sizes must be non-empty unique positive integers")
+	ErrCouldNotCalculate        = errors.New("could not calculate pack selection")
+	ErrConcurrencyConflict      = errors.New("concurrency conflict")
+	ErrVersionMismatch          = errors.New("pack config version does not match the expected version")
+	ErrVersionNotFound          = errors.New("pack config version not found")
+	ErrPackConfigNotFound       = errors.New("pack config not found")
+	ErrInvalidStockLimits       = errors.New("stock limits must reference configured pack sizes and be non-negative")
+	ErrInvalidPackCosts         = errors.New("pack costs must reference configured pack sizes and be non-negative")
+	ErrPackCostsNotConfigured   = errors.New("pack costs are not configured for every pack size")
+	ErrInvalidObjective         = errors.New("objective must be min_overfill or min_cost")
+	ErrInvalidRules             = errors.New("rules must name distinct known criteria with tolerances from 0 to 1000000000 items or 0 to 100 percent")
+	ErrInvalidRecommendation    = errors.New("recommendation needs 1 to 1000 demand buckets with amounts from 1 to 1000000000000 and positive counts, and 1 to 10 sizes that fit the fixed and candidate sizes")
+	ErrInvalidSchedule          = errors.New("effective_from must not be in the past and effective_to must be after it")
+	ErrDraftNotFound            = errors.New("pack config draft not found")
+	ErrInvalidDraftTransition   = errors.New("pack config draft cannot make this transition in its current status")
+	ErrInvalidDraftStatus       = errors.New("status must be draft, approved, rejected or published")
+	ErrSelfApproval             = errors.New("the author of a draft cannot approve it")
+	ErrNotDraftAuthor           = errors.New("only the author of a draft can edit it")
+	ErrActorRequired            = errors.New("an actor is required to author, review or comment on drafts")
+	ErrInvalidComment           = errors.New("comment must be non-empty and at most 2000 bytes")
+	ErrReviewRequired           = errors.New("pack configs must be published through an approved draft")
+	ErrInvalidPackMetadata      = errors.New("pack metadata must reference configured pack sizes once, with labels up to 100 bytes, packaging SKUs up to 64 bytes and non-negative dimensions and tare weight")
+	ErrPackSizeExists           = errors.New("pack size is already configured")
+	ErrPackSizeNotFound         = errors.New("pack size is not configured")
+	ErrNoActivePackSizes        = errors.New("at least one pack size must be active")
+	ErrInvalidSKU               = errors.New("sku must be up to 64 letters, digits, '-', '_' or '.'")
+	ErrCalculationNotFound      = errors.New("calculation not found")
+	ErrReplayTooLarge           = errors.New("replay selects more calculations than replay.max_calculations allows")
+	ErrReplayNotFound           = errors.New("replay not found")
+	ErrReplayBusy               = errors.New("too many replays are running; retry when one has finished")
+	ErrInvalidExport            = errors.New("calculation export is malformed")
+	ErrInvalidCalculationFilter = errors.New("calculation filter needs from before to, non-negative amounts with min_amount up to max_amount, and a limit from 1 to 500")
+	ErrInvalidHistoryPage       = errors.New("history page needs a limit from 1 to 500 and a positive cursor")
 )
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Event is a fact worth telling other systems about.
+type Event interface {
+	// EventType names the event, e.g. "pack_config.activated".
+	EventType() string
+}
+
+// EventPublisher delivers events; a failed delivery is reported, not retried.
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// PackConfigActivated is emitted when a scheduled version of a SKU config
+// reaches its EffectiveFrom and starts to apply.
+type PackConfigActivated struct {
+	SKU           string    `json:"sku"`
+	Version       int64     `json:"version"`
+	PackSizes     []int64   `json:"pack_sizes"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	ActivatedAt   time.Time `json:"activated_at"`
+}
+
+func (PackConfigActivated) EventType() string { return "pack_config.activated" }
@@ -1,32 +1,80 @@
 package domain
 
 import (
+	"maps"
 	"sort"
 	"time"
 )
 
-// PackConfig maps to the single persisted pack configuration row.
+// PackConfig maps to one persisted pack configuration row, keyed by product SKU.
+// Every version is also kept as an immutable history entry.
 type PackConfig struct {
-	Version   int64
+	SKU     string
+	Version int64
+	// PackSizes lists every configured size, active or not, in ascending order.
 	PackSizes []int64
 	UpdatedAt time.Time
+	// UpdatedBy and Reason describe who made this version and why; both are optional.
+	UpdatedBy string
+	Reason    string
+	// Packs holds the metadata of each size; sizes without an entry are active
+	// and carry none. See Pack and ActiveSizes.
+	Packs map[int64]PackSize
+	// StockLimits caps how many packs of a size are available. Sizes without
+	// an entry are unlimited.
+	StockLimits map[int64]int
+	// PackCosts prices each size for the min_cost objective, and OverfillItemCost
+	// prices every item shipped above the requested amount.
+	PackCosts        map[int64]PackCost
+	OverfillItemCost int64
+	// Rules is the default rule chain of this config; empty means DefaultRules.
+	Rules []Rule
+	// EffectiveFrom schedules a version to take over later; nil means from
+	// UpdatedAt. EffectiveTo retires it; nil means until a later version starts.
+	EffectiveFrom *time.Time
+	EffectiveTo   *time.Time
+	// Status is StatusPublished for stored versions; drafts carry their review state.
+	Status ConfigStatus
 }
 
-// NewPackConfig creates a new in-memory configuration.
+// AnyVersion is an expected version that every stored version matches; only a
+// missing config fails it, like If-Match: *.
+const AnyVersion int64 = -1
+
+// MatchesVersion reports whether a stored version satisfies an expected one;
+// nil expects nothing.
+func MatchesVersion(expected *int64, version int64) bool {
+	return expected == nil || *expected == AnyVersion || *expected == version
+}
+
+// NewPackConfig creates a new in-memory configuration for the default profile.
+// The sizes must pass ValidatePackSizes without limits.
 func NewPackConfig(sizes []int64) (*PackConfig, error) {
+	if err := ValidatePackSizes(sizes, PackSizeLimits{}); err != nil {
+		return nil, err
+	}
+
 	newSizes := make([]int64, len(sizes))
 	copy(newSizes, sizes)
 	sortPackSizesAsc(newSizes)
 
 	return &PackConfig{
+		SKU:       DefaultSKU,
 		Version:   0,
 		PackSizes: newSizes,
 		UpdatedAt: time.Now().UTC(),
+		Status:    StatusPublished,
 	}, nil
 }
 
 // Replace swaps pack sizes and advances version for CAS persistence updates.
+// The sizes must pass ValidatePackSizes without limits; callers that enforce
+// limits validate them first.
 func (p *PackConfig) Replace(sizes []int64) error {
+	if err := ValidatePackSizes(sizes, PackSizeLimits{}); err != nil {
+		return err
+	}
+
 	newSizes := make([]int64, len(sizes))
 	copy(newSizes, sizes)
 	sortPackSizesAsc(newSizes)
@@ -35,9 +83,305 @@ func (p *PackConfig) Replace(sizes []int64) error {
 	p.Version++
 	p.UpdatedAt = time.Now().UTC()
 
+	// Metadata, limits and costs of removed sizes would otherwise linger in storage.
+	for size := range p.Packs {
+		if !p.HasSize(size) {
+			delete(p.Packs, size)
+		}
+	}
+	for size := range p.StockLimits {
+		if !p.HasSize(size) {
+			delete(p.StockLimits, size)
+		}
+	}
+	for size := range p.PackCosts {
+		if !p.HasSize(size) {
+			delete(p.PackCosts, size)
+		}
+	}
+
+	return nil
+}
+
+// AddSize adds one size with its metadata and advances the version. The size
+// must be positive and not configured yet.
+func (p *PackConfig) AddSize(pack PackSize) error {
+	if pack.Size <= 0 {
+		return ErrInvalidPackSizes
+	}
+	if p.HasSize(pack.Size) {
+		return ErrPackSizeExists
+	}
+	if err := pack.Validate(); err != nil {
+		return err
+	}
+	if !pack.Active && len(p.ActiveSizes()) == 0 {
+		return ErrNoActivePackSizes
+	}
+
+	newSizes := append(append(make([]int64, 0, len(p.PackSizes)+1), p.PackSizes...), pack.Size)
+	sortPackSizesAsc(newSizes)
+	p.PackSizes = newSizes
+	// A size without metadata needs no entry; the map is cloned, not written,
+	// since a copied config shares it with the one it was copied from.
+	if pack != NewPackSize(pack.Size) {
+		newPacks := maps.Clone(p.Packs)
+		if newPacks == nil {
+			newPacks = make(map[int64]PackSize, 1)
+		}
+		newPacks[pack.Size] = pack
+		p.Packs = newPacks
+	}
+	p.Version++
+	p.UpdatedAt = time.Now().UTC()
+
+	return nil
+}
+
+// RemoveSize removes one size with its metadata, stock limit and cost and
+// advances the version. At least one active size must remain.
+func (p *PackConfig) RemoveSize(size int64) error {
+	if !p.HasSize(size) {
+		return ErrPackSizeNotFound
+	}
+
+	newSizes := make([]int64, 0, len(p.PackSizes)-1)
+	for _, s := range p.PackSizes {
+		if s != size {
+			newSizes = append(newSizes, s)
+		}
+	}
+	next := PackConfig{PackSizes: newSizes, Packs: p.Packs}
+	if len(next.ActiveSizes()) == 0 {
+		return ErrNoActivePackSizes
+	}
+
+	// Replace prunes the maps in place; they are cloned first because a copied
+	// config shares them with the one it was copied from.
+	p.Packs, p.StockLimits, p.PackCosts = maps.Clone(p.Packs), maps.Clone(p.StockLimits), maps.Clone(p.PackCosts)
+	return p.Replace(newSizes)
+}
+
+// SetPacks replaces the metadata of the configured sizes; sizes left out are
+// active without metadata. Each entry must reference a configured size once,
+// and at least one size must stay active.
+func (p *PackConfig) SetPacks(packs []PackSize) error {
+	newPacks := make(map[int64]PackSize, len(packs))
+	for _, pack := range packs {
+		if _, dup := newPacks[pack.Size]; dup || !p.HasSize(pack.Size) {
+			return ErrInvalidPackMetadata
+		}
+		if err := pack.Validate(); err != nil {
+			return err
+		}
+		newPacks[pack.Size] = pack
+	}
+
+	next := PackConfig{PackSizes: p.PackSizes, Packs: newPacks}
+	if len(next.ActiveSizes()) == 0 {
+		return ErrNoActivePackSizes
+	}
+	p.Packs = newPacks
+
+	return nil
+}
+
+// Pack returns the metadata of a configured size.
+func (p *PackConfig) Pack(size int64) PackSize {
+	if pack, ok := p.Packs[size]; ok {
+		return pack
+	}
+
+	return NewPackSize(size)
+}
+
+// ListPacks returns every configured size with its metadata, in ascending order.
+func (p *PackConfig) ListPacks() []PackSize {
+	packs := make([]PackSize, 0, len(p.PackSizes))
+	for _, size := range p.PackSizes {
+		packs = append(packs, p.Pack(size))
+	}
+
+	return packs
+}
+
+// ActiveSizes returns the sizes calculations may use, in ascending order.
+func (p *PackConfig) ActiveSizes() []int64 {
+	if len(p.Packs) == 0 {
+		return p.PackSizes
+	}
+
+	sizes := make([]int64, 0, len(p.PackSizes))
+	for _, size := range p.PackSizes {
+		if p.Pack(size).Active {
+			sizes = append(sizes, size)
+		}
+	}
+
+	return sizes
+}
+
+// SetStockLimits replaces the per-size availability; nil or empty means unlimited.
+// Every limit must reference a configured size and be non-negative.
+func (p *PackConfig) SetStockLimits(limits map[int64]int) error {
+	if err := p.ValidateStockLimits(limits); err != nil {
+		return err
+	}
+
+	newLimits := make(map[int64]int, len(limits))
+	for size, available := range limits {
+		newLimits[size] = available
+	}
+	p.StockLimits = newLimits
+
+	return nil
+}
+
+// ValidateStockLimits checks limits against the configured sizes without applying them.
+func (p *PackConfig) ValidateStockLimits(limits map[int64]int) error {
+	for size, available := range limits {
+		if !p.HasSize(size) || available < 0 {
+			return ErrInvalidStockLimits
+		}
+	}
+
+	return nil
+}
+
+// SetCosts replaces pack costs and the overfill item cost.
+// Every cost must reference a configured size and no amount may be negative.
+func (p *PackConfig) SetCosts(costs map[int64]PackCost, overfillItemCost int64) error {
+	if overfillItemCost < 0 {
+		return ErrInvalidPackCosts
+	}
+	for size, cost := range costs {
+		if !p.HasSize(size) || cost.UnitCost < 0 || cost.HandlingCost < 0 {
+			return ErrInvalidPackCosts
+		}
+	}
+
+	newCosts := make(map[int64]PackCost, len(costs))
+	for size, cost := range costs {
+		newCosts[size] = cost
+	}
+	p.PackCosts = newCosts
+	p.OverfillItemCost = overfillItemCost
+
+	return nil
+}
+
+// HasCosts reports whether every active size has a price.
+func (p *PackConfig) HasCosts() bool {
+	sizes := p.ActiveSizes()
+	for _, size := range sizes {
+		if _, ok := p.PackCosts[size]; !ok {
+			return false
+		}
+	}
+
+	return len(sizes) > 0
+}
+
+// CostBreakdown prices a breakdown that ships overfill items above the requested amount.
+func (p *PackConfig) CostBreakdown(packs []PackBreakdown, overfill int) CostBreakdown {
+	result := CostBreakdown{Lines: make([]PackCostLine, 0, len(packs))}
+	for _, pack := range packs {
+		cost := p.PackCosts[int64(pack.Size)]
+		line := PackCostLine{
+			Size:         pack.Size,
+			Count:        pack.Count,
+			UnitCost:     cost.UnitCost * int64(pack.Count),
+			HandlingCost: cost.HandlingCost * int64(pack.Count),
+		}
+		line.Total = line.UnitCost + line.HandlingCost
+
+		result.Lines = append(result.Lines, line)
+		result.UnitCost += line.UnitCost
+		result.HandlingCost += line.HandlingCost
+	}
+	result.OverfillCost = p.OverfillItemCost * int64(overfill)
+	result.Total = result.UnitCost + result.HandlingCost + result.OverfillCost
+
+	return result
+}
+
+// SetRules replaces the default rule chain; nil or empty restores DefaultRules.
+func (p *PackConfig) SetRules(rules []Rule) error {
+	if err := ValidateRules(rules); err != nil {
+		return err
+	}
+
+	p.Rules = append([]Rule(nil), rules...)
+
 	return nil
 }
 
+// EffectiveRules returns the configured chain, or DefaultRules when none is set.
+func (p *PackConfig) EffectiveRules() []Rule {
+	if len(p.Rules) == 0 {
+		return DefaultRules
+	}
+
+	return p.Rules
+}
+
+// SetSchedule replaces the effective window; nil bounds are open. EffectiveFrom
+// cannot lie before UpdatedAt, so a new version never starts before older ones,
+// and EffectiveTo must come after the start of the version.
+func (p *PackConfig) SetSchedule(from, to *time.Time) error {
+	if from != nil && from.Before(p.UpdatedAt) {
+		return ErrInvalidSchedule
+	}
+	if to != nil {
+		start := p.UpdatedAt
+		if from != nil {
+			start = *from
+		}
+		if !to.After(start) {
+			return ErrInvalidSchedule
+		}
+	}
+
+	p.EffectiveFrom, p.EffectiveTo = utcTime(from), utcTime(to)
+
+	return nil
+}
+
+// EffectiveStart is when this version starts to apply.
+func (p *PackConfig) EffectiveStart() time.Time {
+	if p.EffectiveFrom != nil {
+		return *p.EffectiveFrom
+	}
+
+	return p.UpdatedAt
+}
+
+// EffectiveAt reports whether at falls inside the effective window of this version.
+// Of several such versions, the one with the latest EffectiveStart applies.
+func (p *PackConfig) EffectiveAt(at time.Time) bool {
+	return !p.EffectiveStart().After(at) && (p.EffectiveTo == nil || p.EffectiveTo.After(at))
+}
+
+// IsScheduled reports whether this version only starts to apply after at.
+func (p *PackConfig) IsScheduled(at time.Time) bool {
+	return p.EffectiveFrom != nil && p.EffectiveFrom.After(at)
+}
+
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	utc := t.UTC()
+	return &utc
+}
+
+// HasSize reports whether size is one of the configured pack sizes.
+func (p *PackConfig) HasSize(size int64) bool {
+	i := sort.Search(len(p.PackSizes), func(i int) bool { return p.PackSizes[i] >= size })
+	return i < len(p.PackSizes) && p.PackSizes[i] == size
+}
+
 func sortPackSizesAsc(sizes []int64) {
 	sort.Slice(sizes, func(i, j int) bool {
 		return sizes[i] < sizes[j]
@@ -0,0 +1,180 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// ConfigStatus is the lifecycle state of a pack configuration.
+type ConfigStatus string
+
+const (
+	// StatusDraft is a proposed config that can still be edited and commented on.
+	StatusDraft ConfigStatus = "draft"
+	// StatusApproved is a draft a second person signed off; it can only be published.
+	StatusApproved ConfigStatus = "approved"
+	// StatusRejected is a draft that will not be published.
+	StatusRejected ConfigStatus = "rejected"
+	// StatusPublished is a stored version; only these are used by calculations.
+	StatusPublished ConfigStatus = "published"
+)
+
+// ParseConfigStatus maps an API value to a ConfigStatus; empty means any status.
+func ParseConfigStatus(value string) (ConfigStatus, error) {
+	switch status := ConfigStatus(value); status {
+	case "", StatusDraft, StatusApproved, StatusRejected, StatusPublished:
+		return status, nil
+	default:
+		return "", ErrInvalidDraftStatus
+	}
+}
+
+// MaxCommentLength caps the length of a review comment, in bytes.
+const MaxCommentLength = 2000
+
+// PackConfigDraft is a proposed configuration on its way through review:
+// draft, then approved by someone other than its author, then published as a
+// new version. A draft can be rejected instead at any point before publishing.
+type PackConfigDraft struct {
+	ID int64
+	// Config is the proposed configuration; Config.Status is the state of the draft.
+	Config PackConfig
+	// BaseVersion is the version the draft was made from, nil for a SKU without
+	// a config. Publishing fails once another version was written on top of it.
+	BaseVersion *int64
+	// Revision increases with every change, for optimistic concurrency.
+	Revision   int64
+	CreatedBy  string
+	CreatedAt  time.Time
+	ReviewedBy string
+	ReviewedAt *time.Time
+	// PublishedBy, PublishedAt and PublishedVersion record who published the
+	// draft, when, and the config version it became.
+	PublishedBy      string
+	PublishedAt      *time.Time
+	PublishedVersion *int64
+	Comments         []DraftComment
+}
+
+// DraftComment is one review remark on a draft.
+type DraftComment struct {
+	ID        int64
+	Author    string
+	Body      string
+	CreatedAt time.Time
+}
+
+// NewPackConfigDraft proposes cfg, made from base, on behalf of author.
+func NewPackConfigDraft(cfg PackConfig, base *int64, author string) (*PackConfigDraft, error) {
+	author = strings.TrimSpace(author)
+	if author == "" {
+		return nil, ErrActorRequired
+	}
+
+	now := time.Now().UTC()
+	cfg.Status, cfg.UpdatedBy = StatusDraft, author
+	return &PackConfigDraft{
+		Config:      cfg,
+		BaseVersion: base,
+		Revision:    1,
+		CreatedBy:   author,
+		CreatedAt:   now,
+	}, nil
+}
+
+// Edit replaces the proposed config of a draft that is not yet approved. Only
+// its author may edit it, so an approval always covers the author's own work.
+func (d *PackConfigDraft) Edit(cfg PackConfig, base *int64, editor string) error {
+	if !strings.EqualFold(strings.TrimSpace(editor), d.CreatedBy) {
+		return ErrNotDraftAuthor
+	}
+	if d.Config.Status != StatusDraft {
+		return ErrInvalidDraftTransition
+	}
+
+	cfg.Status, cfg.UpdatedBy = StatusDraft, d.CreatedBy
+	d.Config, d.BaseVersion = cfg, base
+	d.Revision++
+
+	return nil
+}
+
+// Approve signs the draft off. The reviewer must be known and must not be its author.
+func (d *PackConfigDraft) Approve(reviewer string) error {
+	reviewer = strings.TrimSpace(reviewer)
+	if reviewer == "" {
+		return ErrActorRequired
+	}
+	if d.Config.Status != StatusDraft {
+		return ErrInvalidDraftTransition
+	}
+	if strings.EqualFold(reviewer, d.CreatedBy) {
+		return ErrSelfApproval
+	}
+
+	d.review(StatusApproved, reviewer)
+
+	return nil
+}
+
+// Reject closes a draft that was not published; its author may withdraw it this way.
+func (d *PackConfigDraft) Reject(reviewer string) error {
+	reviewer = strings.TrimSpace(reviewer)
+	if reviewer == "" {
+		return ErrActorRequired
+	}
+	if d.Config.Status != StatusDraft && d.Config.Status != StatusApproved {
+		return ErrInvalidDraftTransition
+	}
+
+	d.review(StatusRejected, reviewer)
+
+	return nil
+}
+
+func (d *PackConfigDraft) review(status ConfigStatus, reviewer string) {
+	now := time.Now().UTC()
+	d.Config.Status = status
+	d.ReviewedBy, d.ReviewedAt = reviewer, &now
+	d.Revision++
+}
+
+// CanPublish reports whether actor may publish the draft now: it must be
+// approved, and the actor must be known.
+func (d *PackConfigDraft) CanPublish(actor string) error {
+	if strings.TrimSpace(actor) == "" {
+		return ErrActorRequired
+	}
+	if d.Config.Status != StatusApproved {
+		return ErrInvalidDraftTransition
+	}
+
+	return nil
+}
+
+// MarkPublished records that an approved draft became the given version.
+func (d *PackConfigDraft) MarkPublished(actor string, version int64) error {
+	if err := d.CanPublish(actor); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	d.Config.Status = StatusPublished
+	d.PublishedBy, d.PublishedAt, d.PublishedVersion = strings.TrimSpace(actor), &now, &version
+	d.Revision++
+
+	return nil
+}
+
+// NewDraftComment validates a review remark.
+func NewDraftComment(author, body string) (DraftComment, error) {
+	author, body = strings.TrimSpace(author), strings.TrimSpace(body)
+	if author == "" {
+		return DraftComment{}, ErrActorRequired
+	}
+	if body == "" || len(body) > MaxCommentLength {
+		return DraftComment{}, ErrInvalidComment
+	}
+
+	return DraftComment{Author: author, Body: body, CreatedAt: time.Now().UTC()}, nil
+}
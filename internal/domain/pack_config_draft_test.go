@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPackConfigDraftReview(t *testing.T) {
+	cfg, _ := NewPackConfig([]int64{250, 500, 750})
+	base := int64(3)
+
+	if _, err := NewPackConfigDraft(*cfg, &base, " "); !errors.Is(err, ErrActorRequired) {
+		t.Fatalf("draft without author: error = %v, want ErrActorRequired", err)
+	}
+	draft, err := NewPackConfigDraft(*cfg, &base, "jane")
+	if err != nil {
+		t.Fatalf("new draft returned error: %v", err)
+	}
+	if draft.Config.Status != StatusDraft || draft.Revision != 1 {
+		t.Fatalf("status = %q, revision = %d", draft.Config.Status, draft.Revision)
+	}
+
+	if err := draft.Edit(*cfg, &base, "sam"); !errors.Is(err, ErrNotDraftAuthor) {
+		t.Fatalf("edit by another actor: error = %v, want ErrNotDraftAuthor", err)
+	}
+	if err := draft.CanPublish("sam"); !errors.Is(err, ErrInvalidDraftTransition) {
+		t.Fatalf("publish before approval: error = %v, want ErrInvalidDraftTransition", err)
+	}
+	if err := draft.Approve("JANE"); !errors.Is(err, ErrSelfApproval) {
+		t.Fatalf("self approval: error = %v, want ErrSelfApproval", err)
+	}
+	if err := draft.Approve(""); !errors.Is(err, ErrActorRequired) {
+		t.Fatalf("anonymous approval: error = %v, want ErrActorRequired", err)
+	}
+
+	if err := draft.Approve("sam"); err != nil {
+		t.Fatalf("approve returned error: %v", err)
+	}
+	if draft.Config.Status != StatusApproved || draft.ReviewedBy != "sam" || draft.ReviewedAt == nil || draft.Revision != 2 {
+		t.Fatalf("approved draft = %+v", draft)
+	}
+	if err := draft.Edit(*cfg, &base, "jane"); !errors.Is(err, ErrInvalidDraftTransition) {
+		t.Fatalf("edit after approval: error = %v, want ErrInvalidDraftTransition", err)
+	}
+
+	if err := draft.MarkPublished("sam", 4); err != nil {
+		t.Fatalf("mark published returned error: %v", err)
+	}
+	if draft.Config.Status != StatusPublished || *draft.PublishedVersion != 4 {
+		t.Fatalf("published draft = %+v", draft)
+	}
+	if err := draft.Reject("sam"); !errors.Is(err, ErrInvalidDraftTransition) {
+		t.Fatalf("reject after publishing: error = %v, want ErrInvalidDraftTransition", err)
+	}
+}
+
+func TestNewDraftComment(t *testing.T) {
+	long := make([]byte, MaxCommentLength+1)
+	for i := range long {
+		long[i] = 'x'
+	}
+
+	tests := []struct {
+		name   string
+		author string
+		body   string
+		want   error
+	}{
+		{name: "valid", author: "sam", body: " looks good "},
+		{name: "no author", body: "looks good", want: ErrActorRequired},
+		{name: "blank body", author: "sam", body: "  ", want: ErrInvalidComment},
+		{name: "too long", author: "sam", body: string(long), want: ErrInvalidComment},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment, err := NewDraftComment(tt.author, tt.body)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+			if err == nil && comment.Body != "looks good" {
+				t.Fatalf("body = %q, want it trimmed", comment.Body)
+			}
+		})
+	}
+}
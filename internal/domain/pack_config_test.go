@@ -1,6 +1,11 @@
 package domain
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestNewPackConfig(t *testing.T) {
 	cfg, err := NewPackConfig([]int64{53, 23, 31})
@@ -33,3 +38,162 @@ func TestPackConfigReplace(t *testing.T) {
 		t.Fatalf("unexpected pack sizes: %#v", cfg.PackSizes)
 	}
 }
+
+func TestPackConfigStockLimits(t *testing.T) {
+	cfg, err := NewPackConfig([]int64{250, 500, 1000})
+	if err != nil {
+		t.Fatalf("new pack config returned error: %v", err)
+	}
+
+	if err := cfg.SetStockLimits(map[int64]int{750: 1}); err != ErrInvalidStockLimits {
+		t.Fatalf("expected ErrInvalidStockLimits for unknown size, got %v", err)
+	}
+	if err := cfg.SetStockLimits(map[int64]int{500: -1}); err != ErrInvalidStockLimits {
+		t.Fatalf("expected ErrInvalidStockLimits for negative limit, got %v", err)
+	}
+	if err := cfg.SetStockLimits(map[int64]int{500: 2, 1000: 0}); err != nil {
+		t.Fatalf("set stock limits returned error: %v", err)
+	}
+
+	// Replacing sizes drops limits of sizes that are gone.
+	if err := cfg.Replace([]int64{250, 500}); err != nil {
+		t.Fatalf("replace returned error: %v", err)
+	}
+	if len(cfg.StockLimits) != 1 || cfg.StockLimits[500] != 2 {
+		t.Fatalf("unexpected stock limits: %#v", cfg.StockLimits)
+	}
+}
+
+func TestPackConfigSchedule(t *testing.T) {
+	cfg, err := NewPackConfig([]int64{250, 500})
+	if err != nil {
+		t.Fatalf("new pack config returned error: %v", err)
+	}
+	from := cfg.UpdatedAt.Add(24 * time.Hour)
+	to := from.Add(24 * time.Hour)
+	past := cfg.UpdatedAt.Add(-time.Hour)
+
+	if err := cfg.SetSchedule(&past, nil); !errors.Is(err, ErrInvalidSchedule) {
+		t.Fatalf("past effective_from: error = %v, want ErrInvalidSchedule", err)
+	}
+	if err := cfg.SetSchedule(&from, &from); !errors.Is(err, ErrInvalidSchedule) {
+		t.Fatalf("empty window: error = %v, want ErrInvalidSchedule", err)
+	}
+	if err := cfg.SetSchedule(nil, &past); !errors.Is(err, ErrInvalidSchedule) {
+		t.Fatalf("effective_to before start: error = %v, want ErrInvalidSchedule", err)
+	}
+
+	if err := cfg.SetSchedule(&from, &to); err != nil {
+		t.Fatalf("set schedule returned error: %v", err)
+	}
+	tests := []struct {
+		at        time.Time
+		effective bool
+		scheduled bool
+	}{
+		{at: cfg.UpdatedAt, effective: false, scheduled: true},
+		{at: from, effective: true, scheduled: false},
+		{at: to.Add(-time.Second), effective: true, scheduled: false},
+		{at: to, effective: false, scheduled: false},
+	}
+	for _, tt := range tests {
+		if cfg.EffectiveAt(tt.at) != tt.effective || cfg.IsScheduled(tt.at) != tt.scheduled {
+			t.Fatalf("at %v: effective = %v, scheduled = %v", tt.at, cfg.EffectiveAt(tt.at), cfg.IsScheduled(tt.at))
+		}
+	}
+
+	if err := cfg.SetSchedule(nil, nil); err != nil || !cfg.EffectiveAt(cfg.UpdatedAt) {
+		t.Fatalf("open schedule: error = %v, effective = %v", err, cfg.EffectiveAt(cfg.UpdatedAt))
+	}
+}
+
+func TestPackConfigPacks(t *testing.T) {
+	cfg, err := NewPackConfig([]int64{250, 500, 1000})
+	if err != nil {
+		t.Fatalf("new pack config returned error: %v", err)
+	}
+
+	box := PackSize{Size: 500, Label: "Medium box", PackagingSKU: "BOX-M", Dimensions: PackDimensions{LengthMM: 400, WidthMM: 300, HeightMM: 200}, TareWeightGrams: 180, Active: true}
+	tests := []struct {
+		name  string
+		packs []PackSize
+		want  error
+	}{
+		{name: "unknown size", packs: []PackSize{{Size: 750, Active: true}}, want: ErrInvalidPackMetadata},
+		{name: "duplicate size", packs: []PackSize{NewPackSize(500), NewPackSize(500)}, want: ErrInvalidPackMetadata},
+		{name: "negative tare weight", packs: []PackSize{{Size: 500, TareWeightGrams: -1, Active: true}}, want: ErrInvalidPackMetadata},
+		{name: "long label", packs: []PackSize{{Size: 500, Label: strings.Repeat("x", MaxPackLabelLength+1), Active: true}}, want: ErrInvalidPackMetadata},
+		{name: "nothing active", packs: []PackSize{{Size: 250}, {Size: 500}, {Size: 1000}}, want: ErrNoActivePackSizes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := cfg.SetPacks(tt.packs); !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if err := cfg.SetPacks([]PackSize{{Size: 250}, box}); err != nil {
+		t.Fatalf("set packs returned error: %v", err)
+	}
+	if got := cfg.ActiveSizes(); len(got) != 2 || got[0] != 500 || got[1] != 1000 {
+		t.Fatalf("active sizes = %v, want [500 1000]", got)
+	}
+	if got := cfg.Pack(500); got != box {
+		t.Fatalf("pack 500 = %+v, want %+v", got, box)
+	}
+	if packs := cfg.ListPacks(); len(packs) != 3 || packs[0].Active || !packs[2].Active || packs[2].Label != "" {
+		t.Fatalf("list packs = %+v", packs)
+	}
+
+	// Replacing sizes keeps the metadata of sizes that stay and drops the rest.
+	if err := cfg.Replace([]int64{500, 2000}); err != nil {
+		t.Fatalf("replace returned error: %v", err)
+	}
+	if _, ok := cfg.Packs[250]; ok || cfg.Pack(500) != box {
+		t.Fatalf("packs after replace = %+v", cfg.Packs)
+	}
+}
+
+func TestPackConfigAddRemoveSize(t *testing.T) {
+	cfg, err := NewPackConfig([]int64{250, 1000})
+	if err != nil {
+		t.Fatalf("new pack config returned error: %v", err)
+	}
+	cfg.StockLimits = map[int64]int{1000: 2}
+	shared := *cfg
+
+	if err := cfg.AddSize(PackSize{Size: 500, Label: "Medium box", Active: true}); err != nil {
+		t.Fatalf("add size returned error: %v", err)
+	}
+	if cfg.Version != 1 || len(cfg.PackSizes) != 3 || cfg.PackSizes[1] != 500 || cfg.Pack(500).Label != "Medium box" {
+		t.Fatalf("unexpected config after add: %+v", cfg)
+	}
+	if err := cfg.AddSize(NewPackSize(500)); err != ErrPackSizeExists {
+		t.Fatalf("expected ErrPackSizeExists, got %v", err)
+	}
+	if err := cfg.AddSize(NewPackSize(0)); err != ErrInvalidPackSizes {
+		t.Fatalf("expected ErrInvalidPackSizes, got %v", err)
+	}
+
+	if err := cfg.RemoveSize(1000); err != nil {
+		t.Fatalf("remove size returned error: %v", err)
+	}
+	if cfg.Version != 2 || cfg.HasSize(1000) || len(cfg.StockLimits) != 0 {
+		t.Fatalf("unexpected config after remove: %+v", cfg)
+	}
+	if err := cfg.RemoveSize(750); err != ErrPackSizeNotFound {
+		t.Fatalf("expected ErrPackSizeNotFound, got %v", err)
+	}
+	// A config copied before the edits keeps its own limits.
+	if shared.StockLimits[1000] != 2 || len(shared.Packs) != 0 {
+		t.Fatalf("copied config was modified: %+v", shared)
+	}
+
+	if err := cfg.SetPacks([]PackSize{{Size: 250}}); err != nil {
+		t.Fatalf("set packs returned error: %v", err)
+	}
+	if err := cfg.RemoveSize(500); err != ErrNoActivePackSizes {
+		t.Fatalf("expected ErrNoActivePackSizes, got %v", err)
+	}
+}
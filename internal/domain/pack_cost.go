@@ -0,0 +1,54 @@
+package domain
+
+// Objective selects what a calculation optimizes.
+type Objective string
+
+const (
+	// ObjectiveMinOverfill applies the default rules: meet the amount, minimize
+	// overfill, then use the fewest packs.
+	ObjectiveMinOverfill Objective = "min_overfill"
+	// ObjectiveMinCost minimizes pack, handling and overfill cost while meeting the amount.
+	ObjectiveMinCost Objective = "min_cost"
+)
+
+// ParseObjective maps an API value to an Objective; empty means ObjectiveMinOverfill.
+func ParseObjective(value string) (Objective, error) {
+	switch Objective(value) {
+	case "", ObjectiveMinOverfill:
+		return ObjectiveMinOverfill, nil
+	case ObjectiveMinCost:
+		return ObjectiveMinCost, nil
+	default:
+		return "", ErrInvalidObjective
+	}
+}
+
+// PackCost prices one pack of a size, in minor currency units (e.g. cents).
+type PackCost struct {
+	UnitCost     int64 `json:"unit_cost"`
+	HandlingCost int64 `json:"handling_cost"`
+}
+
+// Total is the full cost of shipping one pack.
+func (c PackCost) Total() int64 {
+	return c.UnitCost + c.HandlingCost
+}
+
+// PackCostLine is the cost of one breakdown line.
+type PackCostLine struct {
+	Size         int   `json:"size"`
+	Count        int   `json:"count"`
+	UnitCost     int64 `json:"unit_cost"`
+	HandlingCost int64 `json:"handling_cost"`
+	Total        int64 `json:"total"`
+}
+
+// CostBreakdown itemizes what a breakdown costs.
+type CostBreakdown struct {
+	Lines        []PackCostLine `json:"lines"`
+	UnitCost     int64          `json:"unit_cost"`
+	HandlingCost int64          `json:"handling_cost"`
+	// OverfillCost prices the items shipped above the requested amount.
+	OverfillCost int64 `json:"overfill_cost"`
+	Total        int64 `json:"total"`
+}
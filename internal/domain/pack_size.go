@@ -0,0 +1,48 @@
+package domain
+
+// Limits on the descriptive fields of a pack size, in bytes.
+const (
+	MaxPackLabelLength    = 100
+	MaxPackagingSKULength = 64
+)
+
+// PackSize describes one configured size beyond its item count: how ops name
+// and source the packaging, and whether calculations may use it. Inactive
+// sizes stay configured but are never picked.
+type PackSize struct {
+	Size            int64
+	Label           string
+	PackagingSKU    string
+	Dimensions      PackDimensions
+	TareWeightGrams int64
+	Active          bool
+}
+
+// PackDimensions are the outer dimensions of a pack, in millimetres. Zero means unknown.
+type PackDimensions struct {
+	LengthMM int64 `json:"length_mm"`
+	WidthMM  int64 `json:"width_mm"`
+	HeightMM int64 `json:"height_mm"`
+}
+
+// NewPackSize returns an active size without metadata, which is what every
+// size without a stored entry is.
+func NewPackSize(size int64) PackSize {
+	return PackSize{Size: size, Active: true}
+}
+
+// IsZero reports whether no dimension is known.
+func (d PackDimensions) IsZero() bool {
+	return d == PackDimensions{}
+}
+
+// Validate checks the descriptive fields; it does not know the configured sizes.
+func (s PackSize) Validate() error {
+	d := s.Dimensions
+	if len(s.Label) > MaxPackLabelLength || len(s.PackagingSKU) > MaxPackagingSKULength ||
+		d.LengthMM < 0 || d.WidthMM < 0 || d.HeightMM < 0 || s.TareWeightGrams < 0 {
+		return ErrInvalidPackMetadata
+	}
+
+	return nil
+}
@@ -1,10 +1,66 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
-// PackConfigsRepository persists and retrieves the single pack configuration document.
+// PackConfigsRepository persists and retrieves pack configurations, one per product SKU.
+// Create and Update also append the written version to the configuration history.
 type PackConfigsRepository interface {
-	Get(ctx context.Context) (*PackConfig, error)
+	// Get returns the config of a SKU, or nil when it is not configured.
+	Get(ctx context.Context, sku string) (*PackConfig, error)
+	// List returns the current config of every SKU, ordered by SKU.
+	List(ctx context.Context) ([]PackConfig, error)
+	// Create stores the first version of a SKU. It returns ErrConcurrencyConflict
+	// when the SKU was created meanwhile.
 	Create(ctx context.Context, packCfg PackConfig) error
+	// Update stores a version whose Version was advanced by one. It returns
+	// ErrConcurrencyConflict when the stored version is not the previous one.
 	Update(ctx context.Context, packCfg PackConfig) error
+	// Delete removes a SKU config together with its history. It returns
+	// ErrPackConfigNotFound when the SKU is not configured.
+	Delete(ctx context.Context, sku string) error
+	// ListVersions returns every stored version of a SKU, newest first.
+	ListVersions(ctx context.Context, sku string) ([]PackConfig, error)
+	// GetVersion returns a single stored version, or nil when it does not exist.
+	GetVersion(ctx context.Context, sku string, version int64) (*PackConfig, error)
+	// GetActive returns the version of a SKU that applies at a time: of the versions
+	// whose effective window holds at, the one with the latest EffectiveStart, the
+	// higher version on a tie. It returns nil when no version applies.
+	GetActive(ctx context.Context, sku string, at time.Time) (*PackConfig, error)
+	// ListActivations returns the versions of every SKU whose EffectiveFrom lies
+	// in (from, to], ordered by EffectiveFrom.
+	ListActivations(ctx context.Context, from, to time.Time) ([]PackConfig, error)
+}
+
+// PackConfigDraftsRepository persists drafts and their review comments.
+// Published versions live in PackConfigsRepository, never here.
+type PackConfigDraftsRepository interface {
+	// CreateDraft stores a new draft and returns its ID.
+	CreateDraft(ctx context.Context, draft PackConfigDraft) (int64, error)
+	// GetDraft returns a draft with its comments, or nil when it does not exist.
+	GetDraft(ctx context.Context, id int64) (*PackConfigDraft, error)
+	// ListDrafts returns the drafts of a SKU, newest first, without comments.
+	// An empty status matches every status.
+	ListDrafts(ctx context.Context, sku string, status ConfigStatus) ([]PackConfigDraft, error)
+	// UpdateDraft stores a changed draft whose Revision was advanced by one. It
+	// returns ErrConcurrencyConflict when the stored revision is not the previous one.
+	UpdateDraft(ctx context.Context, draft PackConfigDraft) error
+	// AddComment appends a comment to a draft and returns its ID. It returns
+	// ErrDraftNotFound when the draft does not exist.
+	AddComment(ctx context.Context, draftID int64, comment DraftComment) (int64, error)
+}
+
+// CalculationsRepository persists calculations so answers can be looked up later.
+type CalculationsRepository interface {
+	// CreateCalculations stores calculations and returns their IDs, in order.
+	CreateCalculations(ctx context.Context, calcs []Calculation) ([]int64, error)
+	// GetCalculation returns a calculation, or nil when it does not exist.
+	GetCalculation(ctx context.Context, id int64) (*Calculation, error)
+	// ListCalculations returns the calculations matching filter, newest first.
+	ListCalculations(ctx context.Context, filter CalculationFilter) ([]Calculation, error)
+	// PurgeCalculations deletes the calculations created before a time and
+	// returns how many it deleted.
+	PurgeCalculations(ctx context.Context, before time.Time) (int64, error)
 }
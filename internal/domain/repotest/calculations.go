@@ -0,0 +1,60 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+// TestCalculationsRepository runs the CalculationsRepository contract against
+// the repositories newRepo returns. Every subtest stores calculations under
+// SKUs of its own and lists only those, so newRepo may return repositories
+// over shared storage.
+func TestCalculationsRepository(t *testing.T, newRepo func(t *testing.T) domain.CalculationsRepository) {
+	t.Run("amounts above 32 bits round-trip", func(t *testing.T) {
+		repo, sku := newRepo(t), newSKU(t)
+		ctx := context.Background()
+
+		const amount = 3_000_000_000
+		large := newCalculation(sku, amount, domain.PackBreakdown{Size: amount, Count: 1})
+		small := newCalculation(sku, 250, domain.PackBreakdown{Size: 250, Count: 1})
+		ids, err := repo.CreateCalculations(ctx, []domain.Calculation{large, small})
+		if err != nil || len(ids) != 2 {
+			t.Fatalf("CreateCalculations = %v, %v", ids, err)
+		}
+
+		got, err := repo.GetCalculation(ctx, ids[0])
+		if err != nil || got == nil {
+			t.Fatalf("GetCalculation = %+v, %v", got, err)
+		}
+		if got.Amount != amount || fmt.Sprint(got.Packs) != fmt.Sprint(large.Packs) {
+			t.Fatalf("GetCalculation = amount %d, packs %v; want %d, %v", got.Amount, got.Packs, amount, large.Packs)
+		}
+
+		listed, err := repo.ListCalculations(ctx, domain.CalculationFilter{SKU: sku, MinAmount: amount, MaxAmount: amount, Limit: 10})
+		if err != nil || len(listed) != 1 || listed[0].ID != ids[0] {
+			t.Fatalf("ListCalculations by amount = %+v, %v; want calculation %d", listed, err, ids[0])
+		}
+		listed, err = repo.ListCalculations(ctx, domain.CalculationFilter{SKU: sku, MaxAmount: amount - 1, Limit: 10})
+		if err != nil || len(listed) != 1 || listed[0].ID != ids[1] {
+			t.Fatalf("ListCalculations below the amount = %+v, %v; want calculation %d", listed, err, ids[1])
+		}
+	})
+}
+
+// newCalculation builds a min_overfill calculation of amount for sku.
+func newCalculation(sku string, amount int, packs ...domain.PackBreakdown) domain.Calculation {
+	return domain.Calculation{
+		SKU:           sku,
+		Amount:        amount,
+		Options:       domain.CalculationOptions{Objective: domain.ObjectiveMinOverfill},
+		ConfigVersion: 1,
+		Packs:         packs,
+		Solver:        "repotest",
+		Duration:      time.Millisecond,
+		CreatedAt:     time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
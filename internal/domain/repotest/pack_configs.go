@@ -0,0 +1,284 @@
+// Package repotest holds conformance suites that every implementation of a
+// domain repository must pass, so the storage backends keep one behaviour.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+// concurrentWriters is how many writers race for the same version.
+const concurrentWriters = 8
+
+// skuSeq makes SKUs unique within a run, so suites can share one database.
+var skuSeq atomic.Int64
+
+// TestPackConfigsRepository runs the PackConfigsRepository contract against
+// the repositories newRepo returns. Every subtest works on SKUs of its own and
+// deletes them afterwards, so newRepo may return repositories over shared storage.
+func TestPackConfigsRepository(t *testing.T, newRepo func(t *testing.T) domain.PackConfigsRepository) {
+	t.Run("get on empty", func(t *testing.T) {
+		repo, sku := newRepo(t), newSKU(t)
+		ctx := context.Background()
+
+		cfg, err := repo.Get(ctx, sku)
+		if err != nil || cfg != nil {
+			t.Fatalf("Get = %+v, %v; want nil, nil", cfg, err)
+		}
+		if v, err := repo.GetVersion(ctx, sku, 1); err != nil || v != nil {
+			t.Fatalf("GetVersion = %+v, %v; want nil, nil", v, err)
+		}
+		if v, err := repo.GetActive(ctx, sku, time.Now()); err != nil || v != nil {
+			t.Fatalf("GetActive = %+v, %v; want nil, nil", v, err)
+		}
+		if versions, err := repo.ListVersions(ctx, sku); err != nil || len(versions) != 0 {
+			t.Fatalf("ListVersions = %+v, %v; want none", versions, err)
+		}
+		if err := repo.Delete(ctx, sku); !errors.Is(err, domain.ErrPackConfigNotFound) {
+			t.Fatalf("Delete: expected ErrPackConfigNotFound, got %v", err)
+		}
+	})
+
+	t.Run("create twice keeps the first version", func(t *testing.T) {
+		repo, sku := newRepo(t), newSKU(t)
+		ctx := context.Background()
+		cleanup(t, repo, sku)
+
+		first := newConfig(sku, 0, 250, 500)
+		if err := repo.Create(ctx, first); err != nil {
+			t.Fatalf("Create: unexpected error: %v", err)
+		}
+		again := newConfig(sku, 0, 1000)
+		if err := repo.Create(ctx, again); !errors.Is(err, domain.ErrConcurrencyConflict) {
+			t.Fatalf("second Create: expected ErrConcurrencyConflict, got %v", err)
+		}
+
+		got := mustGet(t, repo, sku)
+		assertSizes(t, got, 250, 500)
+		if versions, err := repo.ListVersions(ctx, sku); err != nil || len(versions) != 1 {
+			t.Fatalf("ListVersions = %d versions, %v; want 1", len(versions), err)
+		}
+	})
+
+	t.Run("update enforces version CAS", func(t *testing.T) {
+		repo, sku := newRepo(t), newSKU(t)
+		ctx := context.Background()
+		cleanup(t, repo, sku)
+
+		if err := repo.Update(ctx, newConfig(sku, 1, 250)); !errors.Is(err, domain.ErrConcurrencyConflict) {
+			t.Fatalf("Update of a missing SKU: expected ErrConcurrencyConflict, got %v", err)
+		}
+		if err := repo.Create(ctx, newConfig(sku, 0, 250)); err != nil {
+			t.Fatalf("Create: unexpected error: %v", err)
+		}
+		if err := repo.Update(ctx, newConfig(sku, 1, 250, 500)); err != nil {
+			t.Fatalf("Update to 1: unexpected error: %v", err)
+		}
+		for _, version := range []int64{1, 3} {
+			if err := repo.Update(ctx, newConfig(sku, version, 1000)); !errors.Is(err, domain.ErrConcurrencyConflict) {
+				t.Fatalf("Update to %d: expected ErrConcurrencyConflict, got %v", version, err)
+			}
+		}
+
+		got := mustGet(t, repo, sku)
+		if got.Version != 1 || got.Status != domain.StatusPublished {
+			t.Fatalf("Get = version %d, status %q; want 1, published", got.Version, got.Status)
+		}
+		assertSizes(t, got, 250, 500)
+
+		versions, err := repo.ListVersions(ctx, sku)
+		if err != nil || len(versions) != 2 || versions[0].Version != 1 || versions[1].Version != 0 {
+			t.Fatalf("ListVersions = %+v, %v; want versions 1 and 0", versions, err)
+		}
+		if v, err := repo.GetVersion(ctx, sku, 0); err != nil || v == nil {
+			t.Fatalf("GetVersion(0) = %+v, %v", v, err)
+		} else {
+			assertSizes(t, v, 250)
+		}
+	})
+
+	t.Run("concurrent creates have one winner", func(t *testing.T) {
+		repo, sku := newRepo(t), newSKU(t)
+		cleanup(t, repo, sku)
+
+		won := race(t, func(i int) error {
+			return repo.Create(context.Background(), newConfig(sku, 0, int64(100+i)))
+		})
+		assertSizes(t, mustGet(t, repo, sku), int64(100+won))
+	})
+
+	t.Run("concurrent updates have one winner", func(t *testing.T) {
+		repo, sku := newRepo(t), newSKU(t)
+		cleanup(t, repo, sku)
+
+		if err := repo.Create(context.Background(), newConfig(sku, 0, 250)); err != nil {
+			t.Fatalf("Create: unexpected error: %v", err)
+		}
+		won := race(t, func(i int) error {
+			return repo.Update(context.Background(), newConfig(sku, 1, int64(100+i)))
+		})
+
+		got := mustGet(t, repo, sku)
+		if got.Version != 1 {
+			t.Fatalf("version = %d, want 1", got.Version)
+		}
+		assertSizes(t, got, int64(100+won))
+		if versions, err := repo.ListVersions(context.Background(), sku); err != nil || len(versions) != 2 {
+			t.Fatalf("ListVersions = %d versions, %v; want 2", len(versions), err)
+		}
+	})
+
+	t.Run("sizes above 32 bits round-trip", func(t *testing.T) {
+		repo, sku := newRepo(t), newSKU(t)
+		ctx := context.Background()
+		cleanup(t, repo, sku)
+
+		if err := repo.Create(ctx, newConfig(sku, 0, 250, 3_000_000_000)); err != nil {
+			t.Fatalf("Create: unexpected error: %v", err)
+		}
+		assertSizes(t, mustGet(t, repo, sku), 250, 3_000_000_000)
+	})
+
+	t.Run("timestamps round-trip", func(t *testing.T) {
+		repo, sku := newRepo(t), newSKU(t)
+		ctx := context.Background()
+		cleanup(t, repo, sku)
+
+		// PostgreSQL keeps microseconds; the zone is not part of the contract.
+		updatedAt := time.Date(2024, 3, 1, 9, 30, 15, 123456000, time.FixedZone("CET", 3600))
+		from := updatedAt.Add(24 * time.Hour)
+		to := from.Add(90 * 24 * time.Hour)
+		cfg := newConfig(sku, 0, 250)
+		cfg.UpdatedAt, cfg.EffectiveFrom, cfg.EffectiveTo = updatedAt, &from, &to
+		if err := repo.Create(ctx, cfg); err != nil {
+			t.Fatalf("Create: unexpected error: %v", err)
+		}
+
+		for name, get := range map[string]func() (*domain.PackConfig, error){
+			"Get":        func() (*domain.PackConfig, error) { return repo.Get(ctx, sku) },
+			"GetVersion": func() (*domain.PackConfig, error) { return repo.GetVersion(ctx, sku, 0) },
+			"GetActive":  func() (*domain.PackConfig, error) { return repo.GetActive(ctx, sku, from) },
+		} {
+			got, err := get()
+			if err != nil || got == nil {
+				t.Fatalf("%s = %+v, %v", name, got, err)
+			}
+			if !got.UpdatedAt.Equal(updatedAt) || got.EffectiveFrom == nil || !got.EffectiveFrom.Equal(from) ||
+				got.EffectiveTo == nil || !got.EffectiveTo.Equal(to) {
+				t.Fatalf("%s times = %v, %v, %v; want %v, %v, %v", name, got.UpdatedAt, got.EffectiveFrom, got.EffectiveTo, updatedAt, from, to)
+			}
+		}
+
+		// The window bounds are inclusive and exclusive.
+		if got, err := repo.GetActive(ctx, sku, from.Add(-time.Microsecond)); err != nil || got != nil {
+			t.Fatalf("GetActive before effective_from = %+v, %v; want nil", got, err)
+		}
+		if got, err := repo.GetActive(ctx, sku, to); err != nil || got != nil {
+			t.Fatalf("GetActive at effective_to = %+v, %v; want nil", got, err)
+		}
+		activations, err := repo.ListActivations(ctx, from.Add(-time.Microsecond), from)
+		if err != nil {
+			t.Fatalf("ListActivations: unexpected error: %v", err)
+		}
+		if !containsSKU(activations, sku) {
+			t.Fatalf("ListActivations = %+v; want %s", activations, sku)
+		}
+	})
+}
+
+// newSKU returns a SKU no other subtest of this run uses.
+func newSKU(t *testing.T) string {
+	t.Helper()
+	return fmt.Sprintf("repotest-%d-%d", time.Now().UnixNano(), skuSeq.Add(1))
+}
+
+// newConfig builds a published config of sku at version with sizes.
+func newConfig(sku string, version int64, sizes ...int64) domain.PackConfig {
+	return domain.PackConfig{
+		SKU:       sku,
+		Version:   version,
+		PackSizes: sizes,
+		UpdatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		Status:    domain.StatusPublished,
+	}
+}
+
+// cleanup deletes sku once the subtest is done; a missing SKU is fine.
+func cleanup(t *testing.T, repo domain.PackConfigsRepository, sku string) {
+	t.Cleanup(func() {
+		if err := repo.Delete(context.Background(), sku); err != nil && !errors.Is(err, domain.ErrPackConfigNotFound) {
+			t.Errorf("cleanup of %s: %v", sku, err)
+		}
+	})
+}
+
+func mustGet(t *testing.T, repo domain.PackConfigsRepository, sku string) *domain.PackConfig {
+	t.Helper()
+	cfg, err := repo.Get(context.Background(), sku)
+	if err != nil || cfg == nil {
+		t.Fatalf("Get(%s) = %+v, %v", sku, cfg, err)
+	}
+	return cfg
+}
+
+func assertSizes(t *testing.T, cfg *domain.PackConfig, want ...int64) {
+	t.Helper()
+	if fmt.Sprint(cfg.PackSizes) != fmt.Sprint(want) {
+		t.Fatalf("pack sizes = %v, want %v", cfg.PackSizes, want)
+	}
+}
+
+// race runs write concurrentWriters times at once and returns the index of the
+// only call that succeeded; every other call must fail with ErrConcurrencyConflict.
+func race(t *testing.T, write func(i int) error) int {
+	t.Helper()
+
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+		errs  = make([]error, concurrentWriters)
+	)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = write(i)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	won := -1
+	for i, err := range errs {
+		switch {
+		case err == nil && won == -1:
+			won = i
+		case err == nil:
+			t.Fatalf("writers %d and %d both won", won, i)
+		case !errors.Is(err, domain.ErrConcurrencyConflict):
+			t.Fatalf("writer %d: expected ErrConcurrencyConflict, got %v", i, err)
+		}
+	}
+	if won == -1 {
+		t.Fatal("no writer won")
+	}
+
+	return won
+}
+
+func containsSKU(configs []domain.PackConfig, sku string) bool {
+	for _, cfg := range configs {
+		if cfg.SKU == sku {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package domain
+
+// Criterion names one comparison in a rule chain.
+type Criterion string
+
+const (
+	// CriterionOverfill prefers less overfill (items shipped above the amount).
+	CriterionOverfill Criterion = "overfill"
+	// CriterionPackCount prefers fewer packs.
+	CriterionPackCount Criterion = "pack_count"
+	// CriterionDistinctSizes prefers fewer different pack sizes.
+	CriterionDistinctSizes Criterion = "distinct_sizes"
+	// CriterionPreferLarge prefers more packs of the largest sizes.
+	CriterionPreferLarge Criterion = "prefer_large"
+	// CriterionPreferSmall prefers more packs of the smallest sizes.
+	CriterionPreferSmall Criterion = "prefer_small"
+)
+
+const maxRules = 5
+
+// MaxRuleTolerance caps Rule.Tolerance. A non-redundant breakdown overfills by
+// less than its smallest pack, so larger tolerances could not change a choice.
+const MaxRuleTolerance = 1_000_000_000
+
+// Rule is one step of a rule chain. Candidates within the tolerance of the best
+// remaining candidate tie on this step and go on to the next one.
+type Rule struct {
+	Criterion Criterion `json:"criterion"`
+	// Tolerance is absolute, in items for overfill and in packs or sizes
+	// otherwise, and at most MaxRuleTolerance.
+	Tolerance int `json:"tolerance,omitempty"`
+	// TolerancePct is a percentage of the requested amount, at most 100; overfill only.
+	TolerancePct float64 `json:"tolerance_pct,omitempty"`
+}
+
+// DefaultRules is the built-in chain: minimal overfill, then fewest packs.
+var DefaultRules = []Rule{
+	{Criterion: CriterionOverfill},
+	{Criterion: CriterionPackCount},
+}
+
+// ValidateRules checks that a chain names known criteria once each, with
+// bounded tolerances only where they make sense.
+func ValidateRules(rules []Rule) error {
+	if len(rules) > maxRules {
+		return ErrInvalidRules
+	}
+
+	seen := make(map[Criterion]struct{}, len(rules))
+	for _, r := range rules {
+		if _, exists := seen[r.Criterion]; exists {
+			return ErrInvalidRules
+		}
+		seen[r.Criterion] = struct{}{}
+
+		if r.Tolerance < 0 || r.Tolerance > MaxRuleTolerance || !(r.TolerancePct >= 0 && r.TolerancePct <= 100) {
+			return ErrInvalidRules
+		}
+		switch r.Criterion {
+		case CriterionOverfill:
+		case CriterionPackCount, CriterionDistinctSizes:
+			if r.TolerancePct != 0 {
+				return ErrInvalidRules
+			}
+		case CriterionPreferLarge, CriterionPreferSmall:
+			// Orderings over whole breakdowns have no distance to tolerate.
+			if r.Tolerance != 0 || r.TolerancePct != 0 {
+				return ErrInvalidRules
+			}
+		default:
+			return ErrInvalidRules
+		}
+	}
+
+	return nil
+}
+
+// IsDefaultRules reports whether a chain behaves exactly like DefaultRules.
+func IsDefaultRules(rules []Rule) bool {
+	if len(rules) != len(DefaultRules) {
+		return false
+	}
+	for i, r := range rules {
+		if r != DefaultRules[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []Rule
+		wantErr error
+	}{
+		{name: "empty", rules: nil},
+		{name: "default", rules: DefaultRules},
+		{name: "overfill percent", rules: []Rule{{Criterion: CriterionOverfill, TolerancePct: 2}, {Criterion: CriterionPackCount}}},
+		{name: "pack count tolerance", rules: []Rule{{Criterion: CriterionPackCount, Tolerance: 1}, {Criterion: CriterionPreferLarge}}},
+		{name: "unknown", rules: []Rule{{Criterion: "cheapest"}}, wantErr: ErrInvalidRules},
+		{name: "duplicate", rules: []Rule{{Criterion: CriterionOverfill}, {Criterion: CriterionOverfill}}, wantErr: ErrInvalidRules},
+		{name: "negative tolerance", rules: []Rule{{Criterion: CriterionOverfill, Tolerance: -1}}, wantErr: ErrInvalidRules},
+		{name: "largest tolerances", rules: []Rule{{Criterion: CriterionOverfill, Tolerance: MaxRuleTolerance, TolerancePct: 100}}},
+		{name: "tolerance too large", rules: []Rule{{Criterion: CriterionOverfill, Tolerance: math.MaxInt}}, wantErr: ErrInvalidRules},
+		{name: "percent too large", rules: []Rule{{Criterion: CriterionOverfill, TolerancePct: 1e300}}, wantErr: ErrInvalidRules},
+		{name: "percent not a number", rules: []Rule{{Criterion: CriterionOverfill, TolerancePct: math.NaN()}}, wantErr: ErrInvalidRules},
+		{name: "percent on pack count", rules: []Rule{{Criterion: CriterionPackCount, TolerancePct: 5}}, wantErr: ErrInvalidRules},
+		{name: "tolerance on ordering", rules: []Rule{{Criterion: CriterionPreferSmall, Tolerance: 1}}, wantErr: ErrInvalidRules},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateRules(tt.rules); !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: ValidateRules() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestIsDefaultRules(t *testing.T) {
+	if !IsDefaultRules([]Rule{{Criterion: CriterionOverfill}, {Criterion: CriterionPackCount}}) {
+		t.Fatal("expected the built-in chain to be recognized")
+	}
+	if IsDefaultRules([]Rule{{Criterion: CriterionOverfill, Tolerance: 1}, {Criterion: CriterionPackCount}}) {
+		t.Fatal("a tolerance makes the chain custom")
+	}
+}
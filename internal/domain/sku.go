@@ -0,0 +1,32 @@
+package domain
+
+import "strings"
+
+// DefaultSKU names the configuration used when a request does not select a product.
+// It is the profile behind the original single-config endpoints.
+const DefaultSKU = "default"
+
+const maxSKULength = 64
+
+// NormalizeSKU trims a product code and maps an empty one to DefaultSKU.
+func NormalizeSKU(sku string) (string, error) {
+	sku = strings.TrimSpace(sku)
+	if sku == "" {
+		return DefaultSKU, nil
+	}
+	if len(sku) > maxSKULength {
+		return "", ErrInvalidSKU
+	}
+
+	// Product codes end up in URLs, so keep them to a URL-safe alphabet.
+	for _, r := range sku {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.':
+		default:
+			return "", ErrInvalidSKU
+		}
+	}
+
+	return sku, nil
+}
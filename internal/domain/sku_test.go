@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSKU(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr error
+	}{
+		{in: "", want: DefaultSKU},
+		{in: "  ", want: DefaultSKU},
+		{in: " widget-10.v2_a ", want: "widget-10.v2_a"},
+		{in: "has space", wantErr: ErrInvalidSKU},
+		{in: "slash/sku", wantErr: ErrInvalidSKU},
+		{in: strings.Repeat("a", 65), wantErr: ErrInvalidSKU},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeSKU(tt.in)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("NormalizeSKU(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Fatalf("NormalizeSKU(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldViolation is one rule an input breaks. Field is a path into the input,
+// e.g. "pack_sizes[2]".
+type FieldViolation struct {
+	Field  string
+	Reason string
+}
+
+func (v FieldViolation) String() string {
+	return v.Field + ": " + v.Reason
+}
+
+// ValidationError lists every violation found in an input. It wraps the error
+// of the rule set that failed, so errors.Is(err, ErrInvalidPackSizes) holds.
+type ValidationError struct {
+	Err        error
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	reasons := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		reasons = append(reasons, v.String())
+	}
+
+	return strings.Join(reasons, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// PackSizeLimits caps the pack sizes of a configuration; zero means no cap.
+type PackSizeLimits struct {
+	MaxSize  int64
+	MaxCount int
+}
+
+// ValidatePackSizes checks that sizes is a non-empty list of unique positive
+// sizes within limits. It reports every violation at once as a *ValidationError
+// wrapping ErrInvalidPackSizes, indexed by position in sizes.
+func ValidatePackSizes(sizes []int64, limits PackSizeLimits) error {
+	var violations []FieldViolation
+	if len(sizes) == 0 {
+		violations = append(violations, FieldViolation{Field: "pack_sizes", Reason: "must not be empty"})
+	}
+	if limits.MaxCount > 0 && len(sizes) > limits.MaxCount {
+		violations = append(violations, FieldViolation{Field: "pack_sizes", Reason: fmt.Sprintf("must hold at most %d sizes", limits.MaxCount)})
+	}
+
+	seen := make(map[int64]struct{}, len(sizes))
+	for i, size := range sizes {
+		field := fmt.Sprintf("pack_sizes[%d]", i)
+		switch {
+		case size <= 0:
+			violations = append(violations, FieldViolation{Field: field, Reason: "must be positive"})
+		case limits.MaxSize > 0 && size > limits.MaxSize:
+			violations = append(violations, FieldViolation{Field: field, Reason: fmt.Sprintf("must be at most %d", limits.MaxSize)})
+		}
+		if _, dup := seen[size]; dup {
+			violations = append(violations, FieldViolation{Field: field, Reason: fmt.Sprintf("duplicate of %d", size)})
+		}
+		seen[size] = struct{}{}
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Err: ErrInvalidPackSizes, Violations: violations}
+	}
+
+	return nil
+}
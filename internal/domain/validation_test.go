@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestValidatePackSizes(t *testing.T) {
+	limits := PackSizeLimits{MaxSize: 10000, MaxCount: 3}
+	tests := []struct {
+		sizes []int64
+		want  []string
+	}{
+		{sizes: []int64{250, 500, 1000}},
+		{sizes: nil, want: []string{"pack_sizes: must not be empty"}},
+		{sizes: []int64{250, 0, 500, 500}, want: []string{
+			"pack_sizes: must hold at most 3 sizes",
+			"pack_sizes[1]: must be positive",
+			"pack_sizes[3]: duplicate of 500",
+		}},
+		{sizes: []int64{-5, 20000}, want: []string{
+			"pack_sizes[0]: must be positive",
+			"pack_sizes[1]: must be at most 10000",
+		}},
+	}
+
+	for _, tt := range tests {
+		err := ValidatePackSizes(tt.sizes, limits)
+		if tt.want == nil {
+			if err != nil {
+				t.Fatalf("ValidatePackSizes(%v) returned error: %v", tt.sizes, err)
+			}
+			continue
+		}
+
+		var verr *ValidationError
+		if !errors.As(err, &verr) || !errors.Is(err, ErrInvalidPackSizes) {
+			t.Fatalf("ValidatePackSizes(%v) error = %v, want a validation error wrapping ErrInvalidPackSizes", tt.sizes, err)
+		}
+		got := make([]string, 0, len(verr.Violations))
+		for _, v := range verr.Violations {
+			got = append(got, v.String())
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Fatalf("ValidatePackSizes(%v) violations = %q, want %q", tt.sizes, got, tt.want)
+		}
+	}
+
+	// Without limits only the structural rules apply.
+	if err := ValidatePackSizes([]int64{1, 2, 3, 4, 1 << 40}, PackSizeLimits{}); err != nil {
+		t.Fatalf("unlimited validation returned error: %v", err)
+	}
+	if _, err := NewPackConfig([]int64{250, 250}); !errors.Is(err, ErrInvalidPackSizes) {
+		t.Fatalf("expected NewPackConfig to reject duplicates, got %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+
+	"go-packing/internal/domain"
+)
+
+// LogPublisher publishes events as structured log records, for deployments
+// without a message broker.
+type LogPublisher struct {
+	logger *slog.Logger
+}
+
+// NewLogPublisher creates a publisher that writes every event to logger.
+func NewLogPublisher(logger *slog.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger}
+}
+
+// Publish logs the event type and payload; it never fails.
+func (p *LogPublisher) Publish(ctx context.Context, event domain.Event) error {
+	p.logger.InfoContext(ctx, "event published", "type", event.EventType(), "event", event)
+	return nil
+}
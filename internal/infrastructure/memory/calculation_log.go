@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go-packing/internal/domain"
+)
+
+// calculationLog is the calculation history of a store. It is kept apart from
+// the configs: every calculation would otherwise rewrite the whole store file.
+// A log with a path also appends each calculation as one JSON line to that
+// file; only purges rewrite it. Appends are not synced, so a machine crash may
+// lose the newest calculations but never the configs.
+type calculationLog struct {
+	mu     sync.RWMutex
+	calcs  []domain.Calculation // in ID order
+	lastID int64
+	// path is the NDJSON file, empty for a memory-only log; size is its length
+	// after the last complete line.
+	path string
+	size int64
+}
+
+// calculationLogPath is the NDJSON file kept next to a store file:
+// data.json keeps its calculations in data.calculations.ndjson.
+func calculationLogPath(storePath string) string {
+	return strings.TrimSuffix(storePath, filepath.Ext(storePath)) + ".calculations.ndjson"
+}
+
+// openCalculationLog loads the calculations of path, or starts an empty log
+// when the file does not exist yet. A last line cut short by a crash is
+// dropped from the file.
+func openCalculationLog(path string) (*calculationLog, error) {
+	l := &calculationLog{path: path}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read calculation log: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// Without its newline the line was never completely written.
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read calculation log: %w", err)
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			var calc domain.Calculation
+			if err := json.Unmarshal(line, &calc); err != nil {
+				return nil, fmt.Errorf("decode calculation log %s at byte %d: %w", path, l.size, err)
+			}
+			l.calcs = append(l.calcs, calc)
+			l.lastID = max(l.lastID, calc.ID)
+		}
+		l.size += int64(len(line))
+	}
+
+	if info, err := f.Stat(); err == nil && info.Size() > l.size {
+		if err := os.Truncate(path, l.size); err != nil {
+			return nil, fmt.Errorf("truncate calculation log: %w", err)
+		}
+	}
+
+	return l, nil
+}
+
+// read runs fn under a read lock.
+func (l *calculationLog) read(fn func(calcs []domain.Calculation)) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	fn(l.calcs)
+}
+
+// append assigns the next IDs to calcs and stores them, appending them to the
+// file first. Nothing is kept when the file cannot be written.
+func (l *calculationLog) append(calcs []domain.Calculation) ([]int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stored := make([]domain.Calculation, len(calcs))
+	ids := make([]int64, len(calcs))
+	var lines bytes.Buffer
+	for i, calc := range calcs {
+		stored[i] = cloneCalculation(calc)
+		stored[i].ID = l.lastID + int64(i) + 1
+		ids[i] = stored[i].ID
+		if l.path != "" {
+			if err := json.NewEncoder(&lines).Encode(stored[i]); err != nil {
+				return nil, fmt.Errorf("encode calculation: %w", err)
+			}
+		}
+	}
+
+	if l.path != "" {
+		if err := l.appendFile(lines.Bytes()); err != nil {
+			return nil, fmt.Errorf("append calculation log: %w", err)
+		}
+	}
+	l.calcs = append(l.calcs, stored...)
+	l.lastID += int64(len(calcs))
+
+	return ids, nil
+}
+
+// appendFile writes lines at the end of the file. A failed write is cut off
+// again, so the next append starts on a line of its own.
+func (l *calculationLog) appendFile(lines []byte) error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(lines); err != nil {
+		_ = f.Truncate(l.size)
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	l.size += int64(len(lines))
+
+	return nil
+}
+
+// purge deletes the calculations that keep rejects and rewrites the file
+// without them. It returns how many were deleted.
+func (l *calculationLog) purge(keep func(domain.Calculation) bool) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	kept := make([]domain.Calculation, 0, len(l.calcs))
+	var lines bytes.Buffer
+	encoder := json.NewEncoder(&lines)
+	for _, calc := range l.calcs {
+		if !keep(calc) {
+			continue
+		}
+		kept = append(kept, calc)
+		if l.path != "" {
+			if err := encoder.Encode(calc); err != nil {
+				return 0, fmt.Errorf("encode calculation: %w", err)
+			}
+		}
+	}
+	deleted := int64(len(l.calcs) - len(kept))
+	if deleted == 0 {
+		return 0, nil
+	}
+
+	if l.path != "" {
+		if err := writeFileAtomic(l.path, lines.Bytes()); err != nil {
+			return 0, fmt.Errorf("rewrite calculation log: %w", err)
+		}
+		l.size = int64(lines.Len())
+	}
+	l.calcs = kept
+
+	return deleted, nil
+}
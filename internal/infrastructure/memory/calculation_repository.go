@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+type CalculationRepository struct {
+	store *Store
+}
+
+// NewCalculationRepository creates a calculation history kept in store.
+func NewCalculationRepository(store *Store) *CalculationRepository {
+	return &CalculationRepository{store: store}
+}
+
+// CreateCalculations stores calculations and returns their generated IDs.
+func (r *CalculationRepository) CreateCalculations(_ context.Context, calcs []domain.Calculation) ([]int64, error) {
+	return r.store.calcs.append(calcs)
+}
+
+// GetCalculation returns one calculation. It returns nil when it does not exist.
+func (r *CalculationRepository) GetCalculation(_ context.Context, id int64) (*domain.Calculation, error) {
+	var calc *domain.Calculation
+	r.store.calcs.read(func(calcs []domain.Calculation) {
+		// Calculations are kept in ID order.
+		i, ok := slices.BinarySearchFunc(calcs, id, func(c domain.Calculation, id int64) int {
+			return cmp.Compare(c.ID, id)
+		})
+		if ok {
+			c := cloneCalculation(calcs[i])
+			calc = &c
+		}
+	})
+
+	return calc, nil
+}
+
+// ListCalculations returns the calculations matching filter, newest first.
+func (r *CalculationRepository) ListCalculations(_ context.Context, filter domain.CalculationFilter) ([]domain.Calculation, error) {
+	calcs := make([]domain.Calculation, 0)
+	r.store.calcs.read(func(stored []domain.Calculation) {
+		for i := len(stored) - 1; i >= 0 && len(calcs) < filter.Limit; i-- {
+			if c := stored[i]; matchesFilter(c, filter) {
+				calcs = append(calcs, cloneCalculation(c))
+			}
+		}
+	})
+
+	return calcs, nil
+}
+
+// PurgeCalculations deletes the calculations created before a time.
+func (r *CalculationRepository) PurgeCalculations(_ context.Context, before time.Time) (int64, error) {
+	// The log may lose its newest line, so the store file keeps the last ID first.
+	if err := r.store.keepLastCalculationID(); err != nil {
+		return 0, err
+	}
+
+	return r.store.calcs.purge(func(calc domain.Calculation) bool {
+		return !calc.CreatedAt.Before(before)
+	})
+}
+
+func matchesFilter(calc domain.Calculation, filter domain.CalculationFilter) bool {
+	return (filter.SKU == "" || calc.SKU == filter.SKU) &&
+		(filter.From.IsZero() || !calc.CreatedAt.Before(filter.From)) &&
+		(filter.To.IsZero() || calc.CreatedAt.Before(filter.To)) &&
+		calc.Amount >= filter.MinAmount &&
+		(filter.MaxAmount == 0 || calc.Amount <= filter.MaxAmount) &&
+		(filter.BeforeID == 0 || calc.ID < filter.BeforeID)
+}
@@ -0,0 +1,27 @@
+package memory
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go-packing/internal/domain"
+	"go-packing/internal/domain/repotest"
+)
+
+func TestCalculationRepository_Conformance(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		repotest.TestCalculationsRepository(t, func(t *testing.T) domain.CalculationsRepository {
+			return NewCalculationRepository(NewStore())
+		})
+	})
+
+	t.Run("file", func(t *testing.T) {
+		repotest.TestCalculationsRepository(t, func(t *testing.T) domain.CalculationsRepository {
+			store, err := OpenFile(filepath.Join(t.TempDir(), "store.json"))
+			if err != nil {
+				t.Fatalf("open store: %v", err)
+			}
+			return NewCalculationRepository(store)
+		})
+	})
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+// The store keeps its own copies: nothing a caller passes in or gets back
+// shares maps, slices or pointers with it.
+
+// cloneConfig copies a config. Like a stored row, it drops metadata entries
+// that equal the default of their size.
+func cloneConfig(cfg domain.PackConfig) domain.PackConfig {
+	cfg.PackSizes = slices.Clone(cfg.PackSizes)
+	cfg.StockLimits = maps.Clone(cfg.StockLimits)
+	cfg.PackCosts = maps.Clone(cfg.PackCosts)
+	cfg.Rules = slices.Clone(cfg.Rules)
+	cfg.EffectiveFrom = cloneTime(cfg.EffectiveFrom)
+	cfg.EffectiveTo = cloneTime(cfg.EffectiveTo)
+
+	packs := cfg.Packs
+	cfg.Packs = nil
+	for size, pack := range packs {
+		if pack == domain.NewPackSize(size) {
+			continue
+		}
+		if cfg.Packs == nil {
+			cfg.Packs = make(map[int64]domain.PackSize)
+		}
+		cfg.Packs[size] = pack
+	}
+
+	return cfg
+}
+
+// publishedConfig copies a stored version; only published versions are stored.
+func publishedConfig(cfg domain.PackConfig) domain.PackConfig {
+	cfg = cloneConfig(cfg)
+	cfg.Status = domain.StatusPublished
+
+	return cfg
+}
+
+func cloneDraft(draft domain.PackConfigDraft) domain.PackConfigDraft {
+	draft.Config = cloneConfig(draft.Config)
+	draft.BaseVersion = cloneInt64(draft.BaseVersion)
+	draft.ReviewedAt = cloneTime(draft.ReviewedAt)
+	draft.PublishedAt = cloneTime(draft.PublishedAt)
+	draft.PublishedVersion = cloneInt64(draft.PublishedVersion)
+	draft.Comments = slices.Clone(draft.Comments)
+
+	return draft
+}
+
+func cloneCalculation(calc domain.Calculation) domain.Calculation {
+	calc.Options.StockLimits = maps.Clone(calc.Options.StockLimits)
+	calc.Options.Rules = slices.Clone(calc.Options.Rules)
+	calc.Packs = slices.Clone(calc.Packs)
+	if calc.Cost != nil {
+		cost := *calc.Cost
+		cost.Lines = slices.Clone(cost.Lines)
+		calc.Cost = &cost
+	}
+
+	return calc
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+
+	return &c
+}
+
+func cloneInt64(n *int64) *int64 {
+	if n == nil {
+		return nil
+	}
+	c := *n
+
+	return &c
+}
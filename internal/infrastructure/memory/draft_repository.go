@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"go-packing/internal/domain"
+)
+
+type DraftRepository struct {
+	store *Store
+}
+
+// NewDraftRepository creates a draft repository kept in store.
+func NewDraftRepository(store *Store) *DraftRepository {
+	return &DraftRepository{store: store}
+}
+
+// CreateDraft stores a new draft and returns its generated ID.
+func (r *DraftRepository) CreateDraft(_ context.Context, draft domain.PackConfigDraft) (int64, error) {
+	var id int64
+	err := r.store.write(func(data *storeData) error {
+		data.LastDraftID++
+		id = data.LastDraftID
+
+		stored := cloneDraft(draft)
+		stored.ID, stored.Comments = id, nil
+		data.Drafts[id] = &stored
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetDraft returns a draft and its comments. It returns nil when the draft does not exist.
+func (r *DraftRepository) GetDraft(_ context.Context, id int64) (*domain.PackConfigDraft, error) {
+	var draft *domain.PackConfigDraft
+	r.store.read(func(data *storeData) {
+		if stored, ok := data.Drafts[id]; ok {
+			d := cloneDraft(*stored)
+			if d.Comments == nil {
+				d.Comments = make([]domain.DraftComment, 0)
+			}
+			draft = &d
+		}
+	})
+
+	return draft, nil
+}
+
+// ListDrafts returns the drafts of a SKU, newest first; an empty status matches all.
+func (r *DraftRepository) ListDrafts(_ context.Context, sku string, status domain.ConfigStatus) ([]domain.PackConfigDraft, error) {
+	drafts := make([]domain.PackConfigDraft, 0)
+	r.store.read(func(data *storeData) {
+		for _, stored := range data.Drafts {
+			if stored.Config.SKU != sku || (status != "" && stored.Config.Status != status) {
+				continue
+			}
+			d := cloneDraft(*stored)
+			d.Comments = nil
+			drafts = append(drafts, d)
+		}
+	})
+	slices.SortFunc(drafts, func(a, b domain.PackConfigDraft) int {
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	return drafts, nil
+}
+
+// UpdateDraft stores a changed draft using revision CAS. Its SKU, author and
+// comments stay as stored.
+func (r *DraftRepository) UpdateDraft(_ context.Context, draft domain.PackConfigDraft) error {
+	return r.store.write(func(data *storeData) error {
+		stored, ok := data.Drafts[draft.ID]
+		if !ok || stored.Revision != draft.Revision-1 {
+			return domain.ErrConcurrencyConflict
+		}
+
+		updated := cloneDraft(draft)
+		updated.Config.SKU = stored.Config.SKU
+		updated.CreatedBy, updated.CreatedAt = stored.CreatedBy, stored.CreatedAt
+		updated.Comments = stored.Comments
+		data.Drafts[draft.ID] = &updated
+		return nil
+	})
+}
+
+// AddComment appends a comment to a draft and returns its generated ID.
+func (r *DraftRepository) AddComment(_ context.Context, draftID int64, comment domain.DraftComment) (int64, error) {
+	var id int64
+	err := r.store.write(func(data *storeData) error {
+		stored, ok := data.Drafts[draftID]
+		if !ok {
+			return domain.ErrDraftNotFound
+		}
+
+		data.LastCommentID++
+		id = data.LastCommentID
+		comment.ID = id
+		stored.Comments = append(stored.Comments, comment)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
@@ -0,0 +1,179 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+type PackConfigRepository struct {
+	store *Store
+}
+
+// NewPackConfigRepository creates a configuration repository kept in store.
+func NewPackConfigRepository(store *Store) *PackConfigRepository {
+	return &PackConfigRepository{store: store}
+}
+
+// Get returns the config of a SKU. It returns nil when not initialized.
+func (r *PackConfigRepository) Get(_ context.Context, sku string) (*domain.PackConfig, error) {
+	var packCfg *domain.PackConfig
+	r.store.read(func(data *storeData) {
+		if entry, ok := data.Configs[sku]; ok {
+			cfg := publishedConfig(entry.Current)
+			packCfg = &cfg
+		}
+	})
+
+	return packCfg, nil
+}
+
+// List returns the current config of every SKU, ordered by SKU.
+func (r *PackConfigRepository) List(_ context.Context) ([]domain.PackConfig, error) {
+	configs := make([]domain.PackConfig, 0)
+	r.store.read(func(data *storeData) {
+		for _, entry := range data.Configs {
+			configs = append(configs, publishedConfig(entry.Current))
+		}
+	})
+	slices.SortFunc(configs, func(a, b domain.PackConfig) int {
+		return strings.Compare(a.SKU, b.SKU)
+	})
+
+	return configs, nil
+}
+
+// Create stores the first version of a SKU. It returns
+// domain.ErrConcurrencyConflict when the SKU already exists.
+func (r *PackConfigRepository) Create(_ context.Context, packCfg domain.PackConfig) error {
+	return r.store.write(func(data *storeData) error {
+		if _, ok := data.Configs[packCfg.SKU]; ok {
+			return domain.ErrConcurrencyConflict
+		}
+
+		data.Configs[packCfg.SKU] = &configEntry{
+			Current:  publishedConfig(packCfg),
+			Versions: []domain.PackConfig{publishedConfig(packCfg)},
+		}
+		return nil
+	})
+}
+
+// Update stores the next version of a SKU. It returns
+// domain.ErrConcurrencyConflict when the stored version is not the previous one.
+func (r *PackConfigRepository) Update(_ context.Context, packCfg domain.PackConfig) error {
+	return r.store.write(func(data *storeData) error {
+		entry, ok := data.Configs[packCfg.SKU]
+		if !ok || entry.Current.Version != packCfg.Version-1 {
+			return domain.ErrConcurrencyConflict
+		}
+
+		entry.Current = publishedConfig(packCfg)
+		entry.Versions = append(entry.Versions, publishedConfig(packCfg))
+		return nil
+	})
+}
+
+// Delete removes a SKU config together with its history.
+func (r *PackConfigRepository) Delete(_ context.Context, sku string) error {
+	return r.store.write(func(data *storeData) error {
+		if _, ok := data.Configs[sku]; !ok {
+			return domain.ErrPackConfigNotFound
+		}
+
+		delete(data.Configs, sku)
+		return nil
+	})
+}
+
+// ListVersions returns the configuration history of a SKU, newest version first.
+func (r *PackConfigRepository) ListVersions(_ context.Context, sku string) ([]domain.PackConfig, error) {
+	versions := make([]domain.PackConfig, 0)
+	r.store.read(func(data *storeData) {
+		if entry, ok := data.Configs[sku]; ok {
+			for i := len(entry.Versions) - 1; i >= 0; i-- {
+				versions = append(versions, publishedConfig(entry.Versions[i]))
+			}
+		}
+	})
+
+	return versions, nil
+}
+
+// GetVersion returns one historical version. It returns nil when the version does not exist.
+func (r *PackConfigRepository) GetVersion(_ context.Context, sku string, version int64) (*domain.PackConfig, error) {
+	var packCfg *domain.PackConfig
+	r.store.read(func(data *storeData) {
+		if entry, ok := data.Configs[sku]; ok {
+			for _, v := range entry.Versions {
+				if v.Version == version {
+					cfg := publishedConfig(v)
+					packCfg = &cfg
+					return
+				}
+			}
+		}
+	})
+
+	return packCfg, nil
+}
+
+// GetActive returns the version of a SKU that applies at a time. It returns
+// nil when no version applies.
+func (r *PackConfigRepository) GetActive(_ context.Context, sku string, at time.Time) (*domain.PackConfig, error) {
+	var packCfg *domain.PackConfig
+	r.store.read(func(data *storeData) {
+		entry, ok := data.Configs[sku]
+		if !ok {
+			return
+		}
+
+		var active *domain.PackConfig
+		for i := range entry.Versions {
+			v := &entry.Versions[i]
+			if !v.EffectiveAt(at) {
+				continue
+			}
+			// Versions are in ascending order, so a tie goes to the later one.
+			if active == nil || !v.EffectiveStart().Before(active.EffectiveStart()) {
+				active = v
+			}
+		}
+		if active != nil {
+			cfg := publishedConfig(*active)
+			packCfg = &cfg
+		}
+	})
+
+	return packCfg, nil
+}
+
+// ListActivations returns the versions of every SKU scheduled to start in
+// (from, to], ordered by EffectiveFrom, SKU and version.
+func (r *PackConfigRepository) ListActivations(_ context.Context, from, to time.Time) ([]domain.PackConfig, error) {
+	versions := make([]domain.PackConfig, 0)
+	r.store.read(func(data *storeData) {
+		for _, entry := range data.Configs {
+			for _, v := range entry.Versions {
+				if v.EffectiveFrom != nil && v.EffectiveFrom.After(from) && !v.EffectiveFrom.After(to) {
+					versions = append(versions, publishedConfig(v))
+				}
+			}
+		}
+	})
+	slices.SortFunc(versions, func(a, b domain.PackConfig) int {
+		if c := a.EffectiveFrom.Compare(*b.EffectiveFrom); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.SKU, b.SKU); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return versions, nil
+}
@@ -0,0 +1,27 @@
+package memory
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go-packing/internal/domain"
+	"go-packing/internal/domain/repotest"
+)
+
+func TestPackConfigRepository_Conformance(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		repotest.TestPackConfigsRepository(t, func(t *testing.T) domain.PackConfigsRepository {
+			return NewPackConfigRepository(NewStore())
+		})
+	})
+
+	t.Run("file", func(t *testing.T) {
+		repotest.TestPackConfigsRepository(t, func(t *testing.T) domain.PackConfigsRepository {
+			store, err := OpenFile(filepath.Join(t.TempDir(), "store.json"))
+			if err != nil {
+				t.Fatalf("open store: %v", err)
+			}
+			return NewPackConfigRepository(store)
+		})
+	})
+}
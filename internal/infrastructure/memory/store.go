@@ -0,0 +1,244 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"go-packing/internal/domain"
+)
+
+// Store holds pack configs, drafts and calculations for the repositories of
+// this package, which share it the way the PostgreSQL ones share a database.
+// A store from OpenFile also keeps configs and drafts in a JSON file, rewritten
+// atomically on every write, and appends calculations to an NDJSON file next
+// to it, so a single node keeps its data over restarts.
+type Store struct {
+	mu   sync.RWMutex
+	data *storeData
+	// path is the backing file, empty for a memory-only store; saved is what
+	// was last written to it.
+	path  string
+	saved []byte
+	// calcs is the calculation history, locked and saved on its own.
+	calcs *calculationLog
+}
+
+// storeData is the state of a store apart from calculations and the layout of its file.
+type storeData struct {
+	Configs map[string]*configEntry           `json:"configs"`
+	Drafts  map[int64]*domain.PackConfigDraft `json:"drafts"`
+	// Calculations is only read from files written before calculations moved
+	// to their own log; OpenFile moves them there.
+	Calculations  []domain.Calculation `json:"calculations,omitempty"`
+	LastDraftID   int64                `json:"last_draft_id"`
+	LastCommentID int64                `json:"last_comment_id"`
+	// LastCalculationID keeps calculation IDs from being reused after a purge
+	// emptied the calculation log.
+	LastCalculationID int64 `json:"last_calculation_id"`
+}
+
+// configEntry is the current config of a SKU and its history, oldest first.
+type configEntry struct {
+	Current  domain.PackConfig   `json:"current"`
+	Versions []domain.PackConfig `json:"versions"`
+}
+
+// NewStore creates an empty store that lives only in memory.
+func NewStore() *Store {
+	return &Store{data: newStoreData(), calcs: &calculationLog{}}
+}
+
+// OpenFile loads a store from a JSON file and its calculations from the NDJSON
+// file next to it, or starts an empty one when the files do not exist yet.
+// Every config or draft write replaces the JSON file through a rename, so a
+// crash leaves either the old or the new content; calculations are appended.
+func OpenFile(path string) (*Store, error) {
+	saved, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("read store file: %w", err)
+	}
+
+	data, err := decodeStoreData(saved)
+	if err != nil {
+		return nil, fmt.Errorf("decode store file %s: %w", path, err)
+	}
+	calcs, err := openCalculationLog(calculationLogPath(path))
+	if err != nil {
+		return nil, err
+	}
+	calcs.lastID = max(calcs.lastID, data.LastCalculationID)
+
+	s := &Store{data: data, path: path, saved: saved, calcs: calcs}
+	if len(data.Calculations) > 0 {
+		if err := s.moveCalculations(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// moveCalculations moves calculations kept in the JSON file by earlier
+// versions to the calculation log. The log is written first, so a crash in
+// between only repeats the move.
+func (s *Store) moveCalculations() error {
+	logged := make(map[int64]bool, len(s.calcs.calcs))
+	for _, calc := range s.calcs.calcs {
+		logged[calc.ID] = true
+	}
+	moved := make([]domain.Calculation, 0, len(s.data.Calculations)+len(s.calcs.calcs))
+	for _, calc := range s.data.Calculations {
+		if !logged[calc.ID] {
+			moved = append(moved, calc)
+		}
+	}
+	moved = append(moved, s.calcs.calcs...)
+	slices.SortFunc(moved, func(a, b domain.Calculation) int { return cmp.Compare(a.ID, b.ID) })
+
+	var lines bytes.Buffer
+	encoder := json.NewEncoder(&lines)
+	for _, calc := range moved {
+		if err := encoder.Encode(calc); err != nil {
+			return fmt.Errorf("encode calculation: %w", err)
+		}
+		s.calcs.lastID = max(s.calcs.lastID, calc.ID)
+	}
+	if err := writeFileAtomic(s.calcs.path, lines.Bytes()); err != nil {
+		return fmt.Errorf("move calculations to %s: %w", s.calcs.path, err)
+	}
+	s.calcs.calcs, s.calcs.size = moved, int64(lines.Len())
+
+	lastID := s.calcs.lastID
+	return s.write(func(data *storeData) error {
+		data.Calculations = nil
+		data.LastCalculationID = lastID
+		return nil
+	})
+}
+
+func newStoreData() *storeData {
+	return &storeData{
+		Configs: make(map[string]*configEntry),
+		Drafts:  make(map[int64]*domain.PackConfigDraft),
+	}
+}
+
+// decodeStoreData reads the content of a store file; no content is an empty store.
+func decodeStoreData(saved []byte) (*storeData, error) {
+	data := newStoreData()
+	if len(saved) == 0 {
+		return data, nil
+	}
+	if err := json.Unmarshal(saved, data); err != nil {
+		return nil, err
+	}
+	if data.Configs == nil {
+		data.Configs = make(map[string]*configEntry)
+	}
+	if data.Drafts == nil {
+		data.Drafts = make(map[int64]*domain.PackConfigDraft)
+	}
+
+	return data, nil
+}
+
+// read runs fn under a read lock.
+func (s *Store) read(fn func(data *storeData)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fn(s.data)
+}
+
+// write runs fn under the write lock and then saves the store. fn must check
+// everything before it changes data: its error is returned as is, and nothing
+// is saved. When saving fails the store goes back to the saved content.
+func (s *Store) write(fn func(data *storeData) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := fn(s.data); err != nil {
+		return err
+	}
+	if s.path == "" {
+		return nil
+	}
+
+	content, err := json.Marshal(s.data)
+	if err == nil {
+		err = writeFileAtomic(s.path, content)
+	}
+	if err != nil {
+		// The saved content decoded fine when the store was opened.
+		if restored, decodeErr := decodeStoreData(s.saved); decodeErr == nil {
+			s.data = restored
+		}
+		return fmt.Errorf("save store file: %w", err)
+	}
+	s.saved = content
+
+	return nil
+}
+
+// keepLastCalculationID saves the last calculation ID in the store file when
+// it is behind the calculation log.
+func (s *Store) keepLastCalculationID() error {
+	s.calcs.mu.RLock()
+	lastID := s.calcs.lastID
+	s.calcs.mu.RUnlock()
+
+	s.mu.RLock()
+	behind := s.path != "" && s.data.LastCalculationID < lastID
+	s.mu.RUnlock()
+	if !behind {
+		return nil
+	}
+
+	return s.write(func(data *storeData) error {
+		data.LastCalculationID = max(data.LastCalculationID, lastID)
+		return nil
+	})
+}
+
+// writeFileAtomic writes content to a temporary file next to path, syncs it
+// and renames it over path.
+func writeFileAtomic(path string, content []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// Removing fails harmlessly once the rename moved the file.
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Persist the rename itself; not every platform can sync a directory.
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+
+	return nil
+}
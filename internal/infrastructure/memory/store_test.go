@@ -0,0 +1,188 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+func TestPackConfigRepository_CAS(t *testing.T) {
+	repo := NewPackConfigRepository(NewStore())
+	ctx := context.Background()
+
+	cfg, _ := domain.NewPackConfig([]int64{250, 500})
+	cfg.Version = 1
+	if err := repo.Create(ctx, *cfg); err != nil {
+		t.Fatalf("create: unexpected error: %v", err)
+	}
+	if err := repo.Create(ctx, *cfg); !errors.Is(err, domain.ErrConcurrencyConflict) {
+		t.Fatalf("second create: expected ErrConcurrencyConflict, got %v", err)
+	}
+
+	next := *cfg
+	next.Version = 2
+	next.PackSizes = []int64{250, 500, 1000}
+	if err := repo.Update(ctx, next); err != nil {
+		t.Fatalf("update: unexpected error: %v", err)
+	}
+	// Writing version 2 again means a writer did not see the first update.
+	if err := repo.Update(ctx, next); !errors.Is(err, domain.ErrConcurrencyConflict) {
+		t.Fatalf("stale update: expected ErrConcurrencyConflict, got %v", err)
+	}
+
+	// What a caller holds is a copy.
+	next.PackSizes[0] = 1
+	got, _ := repo.Get(ctx, domain.DefaultSKU)
+	if got.Version != 2 || got.PackSizes[0] != 250 || got.Status != domain.StatusPublished {
+		t.Fatalf("stored config = %+v", got)
+	}
+	versions, _ := repo.ListVersions(ctx, domain.DefaultSKU)
+	if len(versions) != 2 || versions[0].Version != 2 {
+		t.Fatalf("versions = %+v", versions)
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	ctx := context.Background()
+
+	store, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("open: unexpected error: %v", err)
+	}
+	cfg, _ := domain.NewPackConfig([]int64{250, 500})
+	cfg.Version = 1
+	if err := NewPackConfigRepository(store).Create(ctx, *cfg); err != nil {
+		t.Fatalf("create: unexpected error: %v", err)
+	}
+	if _, err := NewCalculationRepository(store).CreateCalculations(ctx, []domain.Calculation{{SKU: domain.DefaultSKU, Amount: 501}}); err != nil {
+		t.Fatalf("store calculation: unexpected error: %v", err)
+	}
+
+	reopened, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("reopen: unexpected error: %v", err)
+	}
+	got, _ := NewPackConfigRepository(reopened).Get(ctx, domain.DefaultSKU)
+	if got == nil || got.Version != 1 || len(got.PackSizes) != 2 {
+		t.Fatalf("reopened config = %+v", got)
+	}
+	ids, _ := NewCalculationRepository(reopened).CreateCalculations(ctx, []domain.Calculation{{Amount: 1}})
+	if ids[0] != 2 {
+		t.Fatalf("calculation ID after reopen = %d, want 2", ids[0])
+	}
+
+	// A write that cannot be saved is not kept in memory either.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	reopened.path = filepath.Join(path, "missing", "store.json")
+	next := *got
+	next.Version = 2
+	if err := NewPackConfigRepository(reopened).Update(ctx, next); err == nil {
+		t.Fatal("expected save error")
+	}
+	got, _ = NewPackConfigRepository(reopened).Get(ctx, domain.DefaultSKU)
+	if got.Version != 1 {
+		t.Fatalf("version after failed save = %d, want 1", got.Version)
+	}
+}
+
+func TestOpenFile_CalculationLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	logPath := filepath.Join(filepath.Dir(path), "store.calculations.ndjson")
+	ctx := context.Background()
+
+	store, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("open: unexpected error: %v", err)
+	}
+	cfg, _ := domain.NewPackConfig([]int64{250, 500})
+	if err := NewPackConfigRepository(store).Create(ctx, *cfg); err != nil {
+		t.Fatalf("create: unexpected error: %v", err)
+	}
+	saved, _ := os.ReadFile(path)
+
+	// Calculations are appended to their log and leave the store file alone.
+	calcs := NewCalculationRepository(store)
+	for amount := 1; amount <= 3; amount++ {
+		if _, err := calcs.CreateCalculations(ctx, []domain.Calculation{{Amount: amount, CreatedAt: time.Unix(int64(amount), 0)}}); err != nil {
+			t.Fatalf("store calculation: unexpected error: %v", err)
+		}
+	}
+	if content, _ := os.ReadFile(path); !bytes.Equal(content, saved) {
+		t.Fatalf("store file changed by calculations: %s", content)
+	}
+	logged, _ := os.ReadFile(logPath)
+	if lines := bytes.Count(logged, []byte("\n")); lines != 3 {
+		t.Fatalf("calculation log has %d lines, want 3", lines)
+	}
+
+	// A crash cut the last line short; it is dropped and the next one starts cleanly.
+	if err := os.WriteFile(logPath, append(logged, `{"id":4,"amo`...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("reopen: unexpected error: %v", err)
+	}
+	calcs = NewCalculationRepository(reopened)
+	if ids, err := calcs.CreateCalculations(ctx, []domain.Calculation{{Amount: 4, CreatedAt: time.Unix(4, 0)}}); err != nil || ids[0] != 4 {
+		t.Fatalf("calculation after a torn line = %v, %v", ids, err)
+	}
+
+	// Purging everything keeps the IDs from being reused.
+	if deleted, err := calcs.PurgeCalculations(ctx, time.Unix(5, 0)); err != nil || deleted != 4 {
+		t.Fatalf("purge = %d, %v; want 4", deleted, err)
+	}
+	reopened, err = OpenFile(path)
+	if err != nil {
+		t.Fatalf("reopen after purge: unexpected error: %v", err)
+	}
+	calcs = NewCalculationRepository(reopened)
+	if ids, _ := calcs.CreateCalculations(ctx, []domain.Calculation{{Amount: 5}}); ids[0] != 5 {
+		t.Fatalf("calculation ID after purge = %d, want 5", ids[0])
+	}
+	if got, _ := calcs.ListCalculations(ctx, domain.CalculationFilter{Limit: 10}); len(got) != 1 || got[0].Amount != 5 {
+		t.Fatalf("calculations after purge = %+v", got)
+	}
+}
+
+func TestOpenFile_MovesCalculations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	ctx := context.Background()
+
+	// Earlier versions kept calculations in the store file.
+	legacy := `{"configs":{},"drafts":{},"calculations":[{"id":1,"amount":250},{"id":2,"amount":501}],"last_calculation_id":2}`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("open: unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(path); bytes.Contains(content, []byte(`"calculations"`)) {
+		t.Fatalf("store file still holds calculations: %s", content)
+	}
+
+	reopened, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("reopen: unexpected error: %v", err)
+	}
+	for _, s := range []*Store{store, reopened} {
+		got, _ := NewCalculationRepository(s).GetCalculation(ctx, 2)
+		if got == nil || got.Amount != 501 {
+			t.Fatalf("moved calculation = %+v", got)
+		}
+	}
+	if ids, _ := NewCalculationRepository(reopened).CreateCalculations(ctx, []domain.Calculation{{Amount: 1}}); ids[0] != 3 {
+		t.Fatalf("calculation ID after the move = %d, want 3", ids[0])
+	}
+}
@@ -0,0 +1,233 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+type CalculationRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewCalculationRepository creates a PostgreSQL-backed calculation history.
+func NewCalculationRepository(db *sql.DB, logger *slog.Logger) *CalculationRepository {
+	return &CalculationRepository{db: db, logger: logger}
+}
+
+// calculationColumns lists what scanCalculation reads, in order.
+const calculationColumns = `id, sku, amount, options, config_version, packs, cost, solver, duration_us, created_at`
+
+// CreateCalculations inserts calculations in one transaction and returns their IDs.
+func (r *CalculationRepository) CreateCalculations(ctx context.Context, calcs []domain.Calculation) ([]int64, error) {
+	const insertQuery = `
+		INSERT INTO calculations (sku, amount, options, config_version, packs, cost, solver, duration_us, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+
+	ids := make([]int64, len(calcs))
+	err := withTx(ctx, r.db, r.logger, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, insertQuery)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for i, calc := range calcs {
+			options, packs, cost, err := encodeCalculationJSON(calc)
+			if err != nil {
+				return err
+			}
+
+			err = stmt.QueryRowContext(
+				ctx,
+				calc.SKU,
+				calc.Amount,
+				options,
+				calc.ConfigVersion,
+				packs,
+				cost,
+				calc.Solver,
+				calc.Duration.Microseconds(),
+				calc.CreatedAt,
+			).Scan(&ids[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("failed to store calculations", "error", err, "count", len(calcs))
+		return nil, fmt.Errorf("store calculations: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetCalculation loads one calculation. It returns nil when it does not exist.
+func (r *CalculationRepository) GetCalculation(ctx context.Context, id int64) (*domain.Calculation, error) {
+	const query = `
+		SELECT ` + calculationColumns + `
+		FROM calculations
+		WHERE id = $1
+	`
+
+	calc, err := scanCalculation(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to fetch calculation", "error", err, "calculation_id", id)
+		return nil, fmt.Errorf("fetch calculation: %w", err)
+	}
+
+	return calc, nil
+}
+
+// ListCalculations loads the calculations matching filter, newest first.
+func (r *CalculationRepository) ListCalculations(ctx context.Context, filter domain.CalculationFilter) ([]domain.Calculation, error) {
+	const query = `
+		SELECT ` + calculationColumns + `
+		FROM calculations
+		WHERE ($1 = '' OR sku = $1)
+			AND ($2::TIMESTAMPTZ IS NULL OR created_at >= $2)
+			AND ($3::TIMESTAMPTZ IS NULL OR created_at < $3)
+			AND amount >= $4
+			AND ($5::BIGINT = 0 OR amount <= $5)
+			AND ($6::BIGINT = 0 OR id < $6)
+		ORDER BY id DESC
+		LIMIT $7
+	`
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		query,
+		filter.SKU,
+		nullableTime(filter.From),
+		nullableTime(filter.To),
+		filter.MinAmount,
+		filter.MaxAmount,
+		filter.BeforeID,
+		filter.Limit,
+	)
+	if err != nil {
+		r.logger.Error("failed to list calculations", "error", err, "sku", filter.SKU)
+		return nil, fmt.Errorf("list calculations: %w", err)
+	}
+	defer rows.Close()
+
+	calcs := make([]domain.Calculation, 0)
+	for rows.Next() {
+		calc, err := scanCalculation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan calculation: %w", err)
+		}
+		calcs = append(calcs, *calc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate calculations: %w", err)
+	}
+
+	return calcs, nil
+}
+
+// PurgeCalculations deletes the calculations created before a time.
+func (r *CalculationRepository) PurgeCalculations(ctx context.Context, before time.Time) (int64, error) {
+	const deleteQuery = `
+		DELETE FROM calculations
+		WHERE created_at < $1
+	`
+
+	result, err := r.db.ExecContext(ctx, deleteQuery, before)
+	if err != nil {
+		r.logger.Error("failed to purge calculations", "error", err, "before", before)
+		return 0, fmt.Errorf("purge calculations: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+func scanCalculation(row rowScanner) (*domain.Calculation, error) {
+	var (
+		calc       domain.Calculation
+		options    []byte
+		packs      []byte
+		cost       []byte
+		durationUS int64
+	)
+	err := row.Scan(
+		&calc.ID,
+		&calc.SKU,
+		&calc.Amount,
+		&options,
+		&calc.ConfigVersion,
+		&packs,
+		&cost,
+		&calc.Solver,
+		&durationUS,
+		&calc.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(options, &calc.Options); err != nil {
+		return nil, fmt.Errorf("decode calculation options: %w", err)
+	}
+	if err := json.Unmarshal(packs, &calc.Packs); err != nil {
+		return nil, fmt.Errorf("decode calculation packs: %w", err)
+	}
+	if cost != nil {
+		if err := json.Unmarshal(cost, &calc.Cost); err != nil {
+			return nil, fmt.Errorf("decode calculation cost: %w", err)
+		}
+	}
+	calc.Duration = time.Duration(durationUS) * time.Microsecond
+
+	return &calc, nil
+}
+
+// encodeCalculationJSON encodes the JSONB columns of a calculation; cost is nil
+// without a cost breakdown.
+func encodeCalculationJSON(calc domain.Calculation) ([]byte, []byte, []byte, error) {
+	options, err := json.Marshal(calc.Options)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("encode calculation options: %w", err)
+	}
+	packs, err := json.Marshal(calc.Packs)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("encode calculation packs: %w", err)
+	}
+
+	var cost []byte
+	if calc.Cost != nil {
+		if cost, err = json.Marshal(calc.Cost); err != nil {
+			return nil, nil, nil, fmt.Errorf("encode calculation cost: %w", err)
+		}
+	}
+
+	return options, packs, cost, nil
+}
+
+// nullableTime maps the zero time to NULL.
+func nullableTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
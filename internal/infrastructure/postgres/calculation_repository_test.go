@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"go-packing/internal/domain"
+	"go-packing/internal/domain/repotest"
+)
+
+func TestCalculationRepository_Conformance(t *testing.T) {
+	db := openMigratedDB(t)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repotest.TestCalculationsRepository(t, func(t *testing.T) domain.CalculationsRepository {
+		return NewCalculationRepository(db, logger)
+	})
+}
@@ -0,0 +1,353 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+
+	"go-packing/internal/domain"
+)
+
+type DraftRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewDraftRepository creates a PostgreSQL-backed draft repository.
+func NewDraftRepository(db *sql.DB, logger *slog.Logger) *DraftRepository {
+	return &DraftRepository{db: db, logger: logger}
+}
+
+// draftColumns lists what scanDraft reads, in order.
+const draftColumns = `id, sku, base_version, revision, status,
+	(SELECT COALESCE(json_agg(s ORDER BY s.size), '[]') FROM pack_config_draft_sizes s
+		WHERE s.draft_id = pack_config_drafts.id),
+	stock_limits, pack_costs, overfill_item_cost, rules,
+	effective_from, effective_to, reason, created_by, created_at, updated_at, reviewed_by, reviewed_at,
+	published_by, published_at, published_version`
+
+// CreateDraft inserts a new draft and returns its generated ID.
+func (r *DraftRepository) CreateDraft(ctx context.Context, draft domain.PackConfigDraft) (int64, error) {
+	const insertQuery = `
+		INSERT INTO pack_config_drafts (sku, base_version, revision, status, stock_limits, pack_costs, overfill_item_cost, rules,
+			effective_from, effective_to, reason, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id
+	`
+
+	cfg := draft.Config
+	stockLimits, packCosts, rules, err := encodePackConfigJSON(cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = withTx(ctx, r.db, r.logger, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			insertQuery,
+			cfg.SKU,
+			draft.BaseVersion,
+			draft.Revision,
+			cfg.Status,
+			stockLimits,
+			packCosts,
+			cfg.OverfillItemCost,
+			rules,
+			cfg.EffectiveFrom,
+			cfg.EffectiveTo,
+			cfg.Reason,
+			draft.CreatedBy,
+			draft.CreatedAt,
+			cfg.UpdatedAt,
+		).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		return insertDraftSizes(ctx, tx, id, cfg)
+	})
+	if err != nil {
+		r.logger.Error("failed to create pack config draft", "error", err, "sku", cfg.SKU)
+		return 0, fmt.Errorf("create pack config draft: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetDraft loads a draft and its comments. It returns nil when the draft does not exist.
+func (r *DraftRepository) GetDraft(ctx context.Context, id int64) (*domain.PackConfigDraft, error) {
+	const query = `
+		SELECT ` + draftColumns + `
+		FROM pack_config_drafts
+		WHERE id = $1
+	`
+	const commentsQuery = `
+		SELECT id, author, body, created_at
+		FROM pack_config_draft_comments
+		WHERE draft_id = $1
+		ORDER BY id
+	`
+
+	draft, err := scanDraft(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to fetch pack config draft", "error", err, "draft_id", id)
+		return nil, fmt.Errorf("fetch pack config draft: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, commentsQuery, id)
+	if err != nil {
+		r.logger.Error("failed to fetch draft comments", "error", err, "draft_id", id)
+		return nil, fmt.Errorf("fetch draft comments: %w", err)
+	}
+	defer rows.Close()
+
+	draft.Comments = make([]domain.DraftComment, 0)
+	for rows.Next() {
+		var comment domain.DraftComment
+		if err := rows.Scan(&comment.ID, &comment.Author, &comment.Body, &comment.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan draft comment: %w", err)
+		}
+		draft.Comments = append(draft.Comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate draft comments: %w", err)
+	}
+
+	return draft, nil
+}
+
+// ListDrafts loads the drafts of a SKU, newest first; an empty status matches all.
+func (r *DraftRepository) ListDrafts(ctx context.Context, sku string, status domain.ConfigStatus) ([]domain.PackConfigDraft, error) {
+	const query = `
+		SELECT ` + draftColumns + `
+		FROM pack_config_drafts
+		WHERE sku = $1
+			AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, sku, string(status))
+	if err != nil {
+		r.logger.Error("failed to list pack config drafts", "error", err, "sku", sku)
+		return nil, fmt.Errorf("list pack config drafts: %w", err)
+	}
+	defer rows.Close()
+
+	drafts := make([]domain.PackConfigDraft, 0)
+	for rows.Next() {
+		draft, err := scanDraft(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan pack config draft: %w", err)
+		}
+		drafts = append(drafts, *draft)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pack config drafts: %w", err)
+	}
+
+	return drafts, nil
+}
+
+// UpdateDraft performs an optimistic-concurrency update using revision CAS.
+func (r *DraftRepository) UpdateDraft(ctx context.Context, draft domain.PackConfigDraft) error {
+	const updateQuery = `
+		UPDATE pack_config_drafts
+		SET base_version = $1,
+			revision = $2,
+			status = $3,
+			stock_limits = $4,
+			pack_costs = $5,
+			overfill_item_cost = $6,
+			rules = $7,
+			effective_from = $8,
+			effective_to = $9,
+			reason = $10,
+			updated_at = $11,
+			reviewed_by = $12,
+			reviewed_at = $13,
+			published_by = $14,
+			published_at = $15,
+			published_version = $16
+		WHERE id = $17
+			AND revision = $18
+	`
+	const deleteSizesQuery = `
+		DELETE FROM pack_config_draft_sizes
+		WHERE draft_id = $1
+	`
+
+	cfg := draft.Config
+	stockLimits, packCosts, rules, err := encodePackConfigJSON(cfg)
+	if err != nil {
+		return err
+	}
+
+	return withTx(ctx, r.db, r.logger, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(
+			ctx,
+			updateQuery,
+			draft.BaseVersion,
+			draft.Revision,
+			cfg.Status,
+			stockLimits,
+			packCosts,
+			cfg.OverfillItemCost,
+			rules,
+			cfg.EffectiveFrom,
+			cfg.EffectiveTo,
+			cfg.Reason,
+			cfg.UpdatedAt,
+			draft.ReviewedBy,
+			draft.ReviewedAt,
+			draft.PublishedBy,
+			draft.PublishedAt,
+			draft.PublishedVersion,
+			draft.ID,
+			draft.Revision-1,
+		)
+		if err != nil {
+			r.logger.Error("failed to update pack config draft", "error", err, "draft_id", draft.ID)
+			return fmt.Errorf("update pack config draft: %w", err)
+		}
+
+		// No affected rows means another writer changed the draft first.
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected: %w", err)
+		}
+		if affected == 0 {
+			return domain.ErrConcurrencyConflict
+		}
+
+		// The sizes of an edited draft are replaced as a whole.
+		if _, err := tx.ExecContext(ctx, deleteSizesQuery, draft.ID); err != nil {
+			return fmt.Errorf("delete draft sizes: %w", err)
+		}
+		if err := insertDraftSizes(ctx, tx, draft.ID, cfg); err != nil {
+			r.logger.Error("failed to record draft sizes", "error", err, "draft_id", draft.ID)
+			return fmt.Errorf("record draft sizes: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// insertDraftSizes records the sizes of a draft config with their metadata.
+func insertDraftSizes(ctx context.Context, tx *sql.Tx, draftID int64, cfg domain.PackConfig) error {
+	const insertQuery = `
+		INSERT INTO pack_config_draft_sizes (draft_id, size, label, packaging_sku, length_mm, width_mm, height_mm, tare_weight_g, active)
+		SELECT $1::BIGINT, * FROM unnest($2::BIGINT[], $3::TEXT[], $4::TEXT[], $5::BIGINT[], $6::BIGINT[], $7::BIGINT[], $8::BIGINT[], $9::BOOLEAN[])
+	`
+
+	_, err := tx.ExecContext(ctx, insertQuery, append([]any{draftID}, packSizeArrays(cfg)...)...)
+	return err
+}
+
+// AddComment inserts a comment on a draft and returns its generated ID.
+func (r *DraftRepository) AddComment(ctx context.Context, draftID int64, comment domain.DraftComment) (int64, error) {
+	const insertQuery = `
+		INSERT INTO pack_config_draft_comments (draft_id, author, body, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, insertQuery, draftID, comment.Author, comment.Body, comment.CreatedAt).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return 0, domain.ErrDraftNotFound
+		}
+		r.logger.Error("failed to add draft comment", "error", err, "draft_id", draftID)
+		return 0, fmt.Errorf("add draft comment: %w", err)
+	}
+
+	return id, nil
+}
+
+// foreignKeyViolation is the PostgreSQL error code of a missing referenced row.
+const foreignKeyViolation = "23503"
+
+func scanDraft(row rowScanner) (*domain.PackConfigDraft, error) {
+	var (
+		draft                 domain.PackConfigDraft
+		baseVersion           sql.NullInt64
+		publishedVersion      sql.NullInt64
+		sizes                 []byte
+		stockLimits           []byte
+		packCosts             []byte
+		rules                 []byte
+		from, to              sql.NullTime
+		reviewedAt, published sql.NullTime
+	)
+	cfg := &draft.Config
+	err := row.Scan(
+		&draft.ID,
+		&cfg.SKU,
+		&baseVersion,
+		&draft.Revision,
+		&cfg.Status,
+		&sizes,
+		&stockLimits,
+		&packCosts,
+		&cfg.OverfillItemCost,
+		&rules,
+		&from,
+		&to,
+		&cfg.Reason,
+		&draft.CreatedBy,
+		&draft.CreatedAt,
+		&cfg.UpdatedAt,
+		&draft.ReviewedBy,
+		&reviewedAt,
+		&draft.PublishedBy,
+		&published,
+		&publishedVersion,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := decodePackSizes(sizes, cfg); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(stockLimits, &cfg.StockLimits); err != nil {
+		return nil, fmt.Errorf("decode stock limits: %w", err)
+	}
+	if err := json.Unmarshal(packCosts, &cfg.PackCosts); err != nil {
+		return nil, fmt.Errorf("decode pack costs: %w", err)
+	}
+	if err := json.Unmarshal(rules, &cfg.Rules); err != nil {
+		return nil, fmt.Errorf("decode rules: %w", err)
+	}
+
+	cfg.UpdatedBy = draft.CreatedBy
+	cfg.EffectiveFrom, cfg.EffectiveTo = nullTime(from), nullTime(to)
+	draft.ReviewedAt, draft.PublishedAt = nullTime(reviewedAt), nullTime(published)
+	if baseVersion.Valid {
+		draft.BaseVersion = &baseVersion.Int64
+		cfg.Version = baseVersion.Int64 + 1
+	}
+	if publishedVersion.Valid {
+		draft.PublishedVersion = &publishedVersion.Int64
+	}
+
+	return &draft, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
@@ -0,0 +1,335 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID keys the advisory lock that serializes migrations, so
+// replicas starting at once apply each migration exactly once.
+const migrationLockID = 4_120_517_019
+
+// migrationName matches NNNN_name.up.sql and NNNN_name.down.sql.
+var migrationName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one versioned schema change with the SQL that reverts it.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus tells whether a migration was applied, and when.
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	// AppliedAt is nil for a pending migration.
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	logger     *slog.Logger
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the migrations embedded in the binary.
+func NewMigrator(db *sql.DB, logger *slog.Logger) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, logger: logger, migrations: migrations}, nil
+}
+
+// Up applies every pending migration in version order, each in its own
+// transaction, and returns the versions it applied.
+func (m *Migrator) Up(ctx context.Context) ([]int64, error) {
+	applied := make([]int64, 0)
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if record, ok := done[migration.Version]; ok {
+				// A version applied under another name means the numbering
+				// changed; skipping it would leave the schema half migrated.
+				if record.Name != migration.Name {
+					return fmt.Errorf("migration %d was applied as %q, but this binary calls it %q", migration.Version, record.Name, migration.Name)
+				}
+				continue
+			}
+
+			const recordQuery = `
+				INSERT INTO schema_migrations (version, name, applied_at)
+				VALUES ($1, $2, NOW())
+			`
+			err := withConnTx(ctx, conn, m.logger, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, recordQuery, migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			m.logger.Info("migration applied", "version", migration.Version, "name", migration.Name)
+			applied = append(applied, migration.Version)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// Down reverts the latest steps applied migrations, newest first, and returns
+// the versions it reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]int64, error) {
+	reverted := make([]int64, 0)
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(done))
+		for version := range done {
+			versions = append(versions, version)
+		}
+		slices.Sort(versions)
+		slices.Reverse(versions)
+
+		for _, version := range versions[:min(steps, len(versions))] {
+			i := slices.IndexFunc(m.migrations, func(mig Migration) bool { return mig.Version == version })
+			if i < 0 {
+				return fmt.Errorf("migration %d is not known to this binary", version)
+			}
+			migration := m.migrations[i]
+
+			const forgetQuery = `
+				DELETE FROM schema_migrations
+				WHERE version = $1
+			`
+			err := withConnTx(ctx, conn, m.logger, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, forgetQuery, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("revert migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			m.logger.Info("migration reverted", "version", migration.Version, "name", migration.Name)
+			reverted = append(reverted, migration.Version)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reverted, nil
+}
+
+// Status lists every known migration in version order, plus applied ones this
+// binary does not know. It waits while another process migrates.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		statuses = make([]MigrationStatus, 0, len(m.migrations))
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+			if record, ok := done[migration.Version]; ok {
+				status.AppliedAt = &record.AppliedAt
+				delete(done, migration.Version)
+			}
+			statuses = append(statuses, status)
+		}
+		for version, record := range done {
+			statuses = append(statuses, MigrationStatus{Version: version, Name: record.Name, AppliedAt: &record.AppliedAt})
+		}
+		slices.SortFunc(statuses, func(a, b MigrationStatus) int {
+			return cmp.Compare(a.Version, b.Version)
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return statuses, nil
+}
+
+// withLock runs fn on one connection that holds the migration advisory lock,
+// after making sure the schema_migrations table exists.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	const createQuery = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL
+		)
+	`
+
+	// The advisory lock belongs to a session, so everything runs on one connection.
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("take migration lock: %w", err)
+	}
+	defer func() {
+		// A fresh context: the lock must be released even when ctx is done.
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			m.logger.Error("failed to release migration lock", "error", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, createQuery); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+type migrationRecord struct {
+	Name      string
+	AppliedAt time.Time
+}
+
+// appliedMigrations reads schema_migrations, keyed by version.
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int64]migrationRecord, error) {
+	const query = `
+		SELECT version, name, applied_at
+		FROM schema_migrations
+	`
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	done := make(map[int64]migrationRecord)
+	for rows.Next() {
+		var (
+			version int64
+			record  migrationRecord
+		)
+		if err := rows.Scan(&version, &record.Name, &record.AppliedAt); err != nil {
+			return nil, fmt.Errorf("scan schema_migrations: %w", err)
+		}
+		done[version] = record
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate schema_migrations: %w", err)
+	}
+
+	return done, nil
+}
+
+// loadMigrations reads the migrations in dir, in version order. Every version
+// needs both an up and a down file under the same name.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			return nil, fmt.Errorf("migration file %q is not named NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration file %q needs a positive version", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %q: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names, %q and %q", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return migrations, nil
+}
+
+// withConnTx is withTx on a dedicated connection.
+func withConnTx(ctx context.Context, conn *sql.Conn, logger *slog.Logger, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			logger.Error("failed to roll back transaction", "error", rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
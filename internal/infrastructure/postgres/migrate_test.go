@@ -0,0 +1,313 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	embedded, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("embedded migrations: unexpected error: %v", err)
+	}
+	for i, migration := range embedded {
+		if migration.Version != int64(i+1) {
+			t.Fatalf("embedded migration %d has version %d; versions must not have gaps", i, migration.Version)
+		}
+	}
+	if len(embedded) == 0 || embedded[0].Name != "initial_schema" || !strings.Contains(embedded[0].Up, "CREATE TABLE IF NOT EXISTS pack_configs") {
+		t.Fatalf("embedded migrations = %+v", embedded)
+	}
+
+	file := func(body string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(body)} }
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []int64
+		wantErr string
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"m/0010_tenants.up.sql":   file("up 10"),
+				"m/0010_tenants.down.sql": file("down 10"),
+				"m/0002_index.up.sql":     file("up 2"),
+				"m/0002_index.down.sql":   file("down 2"),
+			},
+			want: []int64{2, 10},
+		},
+		{
+			name:    "missing down",
+			files:   fstest.MapFS{"m/0001_init.up.sql": file("up")},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name: "two names",
+			files: fstest.MapFS{
+				"m/0001_init.up.sql":    file("up"),
+				"m/0001_other.down.sql": file("down"),
+			},
+			wantErr: "two names",
+		},
+		{
+			name:    "bad name",
+			files:   fstest.MapFS{"m/init.sql": file("up")},
+			wantErr: "is not named",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files, "m")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(migrations) != len(tt.want) {
+				t.Fatalf("got %d migrations, want %d", len(migrations), len(tt.want))
+			}
+			for i, version := range tt.want {
+				if migrations[i].Version != version || migrations[i].Down == "" {
+					t.Fatalf("migration %d = %+v, want version %d", i, migrations[i], version)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrator_UpgradesBaselineSchema(t *testing.T) {
+	db := openTestSchema(t)
+	ctx := context.Background()
+
+	execFile(t, db, "testdata/baseline_schema.sql")
+	if _, err := db.ExecContext(ctx, `INSERT INTO pack_configs (id, pack_sizes, version) VALUES (1, '{250,500,1000}', 3)`); err != nil {
+		t.Fatalf("seed baseline config: %v", err)
+	}
+
+	migrate(t, db)
+
+	var sku string
+	if err := db.QueryRowContext(ctx, `SELECT sku FROM pack_configs`).Scan(&sku); err != nil || sku != domain.DefaultSKU {
+		t.Fatalf("upgraded config SKU = %q, %v; want default", sku, err)
+	}
+
+	// The stored config now serves the default profile, with its version as the
+	// first entry of its history.
+	configs := NewPackConfigRepository(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	current, err := configs.Get(ctx, domain.DefaultSKU)
+	if err != nil || current == nil || current.Version != 3 || fmt.Sprint(current.ActiveSizes()) != "[250 500 1000]" {
+		t.Fatalf("upgraded config = %+v, %v", current, err)
+	}
+	versions, err := configs.ListVersions(ctx, domain.DefaultSKU)
+	if err != nil || len(versions) != 1 || versions[0].Version != 3 {
+		t.Fatalf("history = %+v, %v; want version 3 only", versions, err)
+	}
+	if active, err := configs.GetActive(ctx, domain.DefaultSKU, time.Now()); err != nil || active == nil || active.Version != 3 {
+		t.Fatalf("active config = %+v, %v", active, err)
+	}
+
+	next := *current
+	if err := next.Replace([]int64{250, 500}); err != nil {
+		t.Fatal(err)
+	}
+	if err := configs.Update(ctx, next); err != nil {
+		t.Fatalf("update after migration: %v", err)
+	}
+
+	// Running the migrations again finds nothing to do.
+	migrator, err := NewMigrator(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("second Up = %v, %v; want nothing applied", applied, err)
+	}
+}
+
+func TestMigrator_MovesPackSizeArrays(t *testing.T) {
+	db := openTestSchema(t)
+	ctx := context.Background()
+
+	execFile(t, db, "testdata/pack_size_arrays_schema.sql")
+	const seed = `
+		INSERT INTO pack_configs (sku, pack_sizes, version) VALUES ('default', '{250,500}', 1);
+		INSERT INTO pack_config_versions (sku, version, pack_sizes) VALUES ('default', 0, '{250}'), ('default', 1, '{250,500}');
+		INSERT INTO pack_config_drafts (sku, revision, status, pack_sizes, created_by) VALUES ('default', 0, 'draft', '{250,1000}', 'jane');
+	`
+	if _, err := db.ExecContext(ctx, seed); err != nil {
+		t.Fatalf("seed configs: %v", err)
+	}
+
+	migrate(t, db)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	configs := NewPackConfigRepository(db, logger)
+	current, err := configs.Get(ctx, domain.DefaultSKU)
+	if err != nil || current == nil || current.Version != 1 || fmt.Sprint(current.ActiveSizes()) != "[250 500]" {
+		t.Fatalf("current config = %+v, %v", current, err)
+	}
+	first, err := configs.GetVersion(ctx, domain.DefaultSKU, 0)
+	if err != nil || first == nil || fmt.Sprint(first.ActiveSizes()) != "[250]" {
+		t.Fatalf("version 0 = %+v, %v", first, err)
+	}
+	draft, err := NewDraftRepository(db, logger).GetDraft(ctx, 1)
+	if err != nil || draft == nil || fmt.Sprint(draft.Config.PackSizes) != "[250 1000]" {
+		t.Fatalf("draft = %+v, %v", draft, err)
+	}
+
+	// Without the arrays, writes no longer trip over their NOT NULL.
+	next := *current
+	if err := next.Replace([]int64{250, 500, 1000}); err != nil {
+		t.Fatal(err)
+	}
+	if err := configs.Update(ctx, next); err != nil {
+		t.Fatalf("update after migration: %v", err)
+	}
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	db := openTestSchema(t)
+	ctx := context.Background()
+
+	migrator, err := NewMigrator(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := len(migrator.migrations)
+
+	applied, err := migrator.Up(ctx)
+	if err != nil || len(applied) != count {
+		t.Fatalf("Up = %v, %v; want %d migrations", applied, err, count)
+	}
+	assertApplied := func(want int) {
+		t.Helper()
+		statuses, err := migrator.Status(ctx)
+		if err != nil || len(statuses) != count {
+			t.Fatalf("Status = %+v, %v", statuses, err)
+		}
+		for i, status := range statuses {
+			if (status.AppliedAt != nil) != (i < want) {
+				t.Fatalf("migration %d applied = %v, want the first %d applied", status.Version, status.AppliedAt != nil, want)
+			}
+		}
+	}
+	assertApplied(count)
+
+	reverted, err := migrator.Down(ctx, count)
+	if err != nil || len(reverted) != count || reverted[0] != int64(count) {
+		t.Fatalf("Down = %v, %v", reverted, err)
+	}
+	assertApplied(0)
+
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != count {
+		t.Fatalf("Up after Down = %v, %v", applied, err)
+	}
+}
+
+// openTestSchema connects to the database named by PACKING_TEST_DATABASE_URL
+// with a new, empty schema first on the search path, and drops the schema
+// when the test ends. It skips the test without a database.
+func openTestSchema(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv(testDatabaseURLEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+	ctx := context.Background()
+
+	admin, err := NewDB(ctx, dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if _, err := admin.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.ExecContext(context.Background(), "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("drop schema %s: %v", schema, err)
+		}
+		_ = admin.Close()
+	})
+
+	// lib/pq sends unknown settings as run-time parameters of every connection.
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			t.Fatalf("parse %s: %v", testDatabaseURLEnv, err)
+		}
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		dsn = u.String()
+	} else {
+		dsn += " search_path=" + schema
+	}
+	db, err := NewDB(ctx, dsn)
+	if err != nil {
+		t.Fatalf("open schema %s: %v", schema, err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+// execFile runs the statements of an SQL file.
+func execFile(t *testing.T, db *sql.DB, name string) {
+	t.Helper()
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(context.Background(), string(content)); err != nil {
+		t.Fatalf("run %s: %v", name, err)
+	}
+}
+
+// migrate applies every embedded migration.
+func migrate(t *testing.T, db *sql.DB) {
+	t.Helper()
+	migrator, err := NewMigrator(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+}
+
+func TestMigrator_UpRejectsRenamedVersion(t *testing.T) {
+	db := openTestSchema(t)
+	ctx := context.Background()
+
+	migrator, err := NewMigrator(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE schema_migrations SET name = 'pack_configs_by_sku' WHERE version = 1`); err != nil {
+		t.Fatalf("rename version 1: %v", err)
+	}
+
+	if _, err := migrator.Up(ctx); err == nil || !strings.Contains(err.Error(), `applied as "pack_configs_by_sku"`) {
+		t.Fatalf("Up over a renamed version: expected an error, got %v", err)
+	}
+}
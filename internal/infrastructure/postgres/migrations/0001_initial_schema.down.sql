@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS calculations;
+DROP TABLE IF EXISTS pack_config_draft_comments;
+DROP TABLE IF EXISTS pack_config_draft_sizes;
+DROP TABLE IF EXISTS pack_config_drafts;
+DROP TABLE IF EXISTS pack_config_sizes;
+DROP TABLE IF EXISTS pack_config_versions;
+DROP TABLE IF EXISTS pack_configs;
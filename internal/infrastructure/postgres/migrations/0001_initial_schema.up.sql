@@ -0,0 +1,169 @@
+-- Initial schema: pack configs with their history and sizes, drafts under review
+-- and the calculation history. IF NOT EXISTS keeps the tables of a database
+-- created by an older docker init script; 0002_pack_size_rows moves the data
+-- of its pack_sizes arrays and 0003_bigint_sizes_and_amounts widens its columns.
+
+-- The original single-config schema kept one pack_configs row with id = 1; it
+-- becomes the 'default' profile, and pack_configs gains the columns added
+-- since so that the tables below find the layout they expect. A new database
+-- has no pack_configs yet and is left alone.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT FROM information_schema.columns
+        WHERE table_schema = current_schema()
+            AND table_name = 'pack_configs'
+            AND column_name = 'id'
+    ) THEN
+        ALTER TABLE pack_configs ADD COLUMN sku TEXT;
+        -- The CHECK (id = 1) allowed no other row.
+        UPDATE pack_configs SET sku = 'default';
+        -- Dropping id drops its primary key and check as well.
+        ALTER TABLE pack_configs DROP COLUMN id;
+        ALTER TABLE pack_configs ADD PRIMARY KEY (sku);
+    END IF;
+END
+$$;
+
+ALTER TABLE IF EXISTS pack_configs
+    ADD COLUMN IF NOT EXISTS updated_by TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS reason TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS stock_limits JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS pack_costs JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS overfill_item_cost BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS rules JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS effective_from TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS effective_to TIMESTAMPTZ;
+
+-- One row per product SKU; 'default' backs the original single-config endpoints.
+CREATE TABLE IF NOT EXISTS pack_configs (
+    sku TEXT PRIMARY KEY,
+    -- The sizes of the current version live in pack_config_sizes.
+    version BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    -- Maximum available packs keyed by size; sizes without a key are unlimited.
+    stock_limits JSONB NOT NULL DEFAULT '{}',
+    -- Unit and handling cost keyed by size, in minor currency units.
+    pack_costs JSONB NOT NULL DEFAULT '{}',
+    overfill_item_cost BIGINT NOT NULL DEFAULT 0,
+    -- Default rule chain, in order; empty means overfill then pack_count.
+    rules JSONB NOT NULL DEFAULT '[]',
+    -- Scheduling window; a NULL effective_from starts at updated_at, a NULL
+    -- effective_to lasts until a later version starts.
+    effective_from TIMESTAMPTZ,
+    effective_to TIMESTAMPTZ
+);
+
+-- Append-only history: one row per written pack_configs version.
+CREATE TABLE IF NOT EXISTS pack_config_versions (
+    sku TEXT NOT NULL REFERENCES pack_configs (sku) ON DELETE CASCADE,
+    version BIGINT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    changed_by TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    stock_limits JSONB NOT NULL DEFAULT '{}',
+    pack_costs JSONB NOT NULL DEFAULT '{}',
+    overfill_item_cost BIGINT NOT NULL DEFAULT 0,
+    rules JSONB NOT NULL DEFAULT '[]',
+    effective_from TIMESTAMPTZ,
+    effective_to TIMESTAMPTZ,
+    PRIMARY KEY (sku, version)
+);
+
+-- Pack sizes of every version, one row per size with its metadata.
+CREATE TABLE IF NOT EXISTS pack_config_sizes (
+    sku TEXT NOT NULL,
+    version BIGINT NOT NULL,
+    size BIGINT NOT NULL,
+    label TEXT NOT NULL DEFAULT '',
+    packaging_sku TEXT NOT NULL DEFAULT '',
+    -- Outer dimensions in millimetres and tare weight in grams; 0 means unknown.
+    length_mm BIGINT NOT NULL DEFAULT 0,
+    width_mm BIGINT NOT NULL DEFAULT 0,
+    height_mm BIGINT NOT NULL DEFAULT 0,
+    tare_weight_g BIGINT NOT NULL DEFAULT 0,
+    -- Inactive sizes stay configured but calculations never pick them.
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    PRIMARY KEY (sku, version, size),
+    FOREIGN KEY (sku, version) REFERENCES pack_config_versions (sku, version) ON DELETE CASCADE
+);
+
+-- Serves the activation job, which scans for versions starting in a window.
+CREATE INDEX IF NOT EXISTS pack_config_versions_effective_from_idx
+    ON pack_config_versions (effective_from)
+    WHERE effective_from IS NOT NULL;
+
+-- Proposed configs under review; publishing one writes a pack_configs version.
+CREATE TABLE IF NOT EXISTS pack_config_drafts (
+    id BIGSERIAL PRIMARY KEY,
+    sku TEXT NOT NULL,
+    -- Version the draft was made from; NULL for a SKU without a config.
+    base_version BIGINT,
+    revision BIGINT NOT NULL,
+    -- draft, approved, rejected or published.
+    status TEXT NOT NULL,
+    stock_limits JSONB NOT NULL DEFAULT '{}',
+    pack_costs JSONB NOT NULL DEFAULT '{}',
+    overfill_item_cost BIGINT NOT NULL DEFAULT 0,
+    rules JSONB NOT NULL DEFAULT '[]',
+    effective_from TIMESTAMPTZ,
+    effective_to TIMESTAMPTZ,
+    reason TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reviewed_by TEXT NOT NULL DEFAULT '',
+    reviewed_at TIMESTAMPTZ,
+    published_by TEXT NOT NULL DEFAULT '',
+    published_at TIMESTAMPTZ,
+    published_version BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS pack_config_drafts_sku_status_idx
+    ON pack_config_drafts (sku, status);
+
+-- Pack sizes of a draft, shaped like pack_config_sizes.
+CREATE TABLE IF NOT EXISTS pack_config_draft_sizes (
+    draft_id BIGINT NOT NULL REFERENCES pack_config_drafts (id) ON DELETE CASCADE,
+    size BIGINT NOT NULL,
+    label TEXT NOT NULL DEFAULT '',
+    packaging_sku TEXT NOT NULL DEFAULT '',
+    length_mm BIGINT NOT NULL DEFAULT 0,
+    width_mm BIGINT NOT NULL DEFAULT 0,
+    height_mm BIGINT NOT NULL DEFAULT 0,
+    tare_weight_g BIGINT NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    PRIMARY KEY (draft_id, size)
+);
+
+CREATE TABLE IF NOT EXISTS pack_config_draft_comments (
+    id BIGSERIAL PRIMARY KEY,
+    draft_id BIGINT NOT NULL REFERENCES pack_config_drafts (id) ON DELETE CASCADE,
+    author TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Every calculation the service answered; rows older than history.retention are purged.
+CREATE TABLE IF NOT EXISTS calculations (
+    id BIGSERIAL PRIMARY KEY,
+    sku TEXT NOT NULL,
+    amount BIGINT NOT NULL,
+    -- Objective, rule and stock overrides, as_of and the number of alternatives asked for.
+    options JSONB NOT NULL DEFAULT '{}',
+    config_version BIGINT NOT NULL,
+    packs JSONB NOT NULL DEFAULT '[]',
+    -- Itemized cost of a min_cost calculation.
+    cost JSONB,
+    solver TEXT NOT NULL,
+    duration_us BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Serve listings filtered by SKU or time range, and the retention purge.
+CREATE INDEX IF NOT EXISTS calculations_sku_id_idx
+    ON calculations (sku, id DESC);
+CREATE INDEX IF NOT EXISTS calculations_created_at_idx
+    ON calculations (created_at);
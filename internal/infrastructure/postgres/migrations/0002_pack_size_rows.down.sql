@@ -0,0 +1,2 @@
+-- The arrays are not restored: pack_config_sizes and pack_config_draft_sizes
+-- hold the sizes either way.
@@ -0,0 +1,60 @@
+-- Schemas created before pack sizes had metadata kept them as pack_sizes
+-- arrays. This moves every array into pack_config_sizes or
+-- pack_config_draft_sizes, one active row per size without metadata, and
+-- drops the arrays. The original single-config schema kept no history, so
+-- a config without a pack_config_versions row first gets one for its current
+-- version. A database without the arrays is left alone.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT FROM information_schema.columns
+        WHERE table_schema = current_schema()
+            AND table_name = 'pack_config_versions'
+            AND column_name = 'pack_sizes'
+    ) THEN
+        INSERT INTO pack_config_sizes (sku, version, size)
+        SELECT v.sku, v.version, s.size
+        FROM pack_config_versions v, unnest(v.pack_sizes) AS s (size)
+        ON CONFLICT DO NOTHING;
+
+        ALTER TABLE pack_config_versions DROP COLUMN pack_sizes;
+    END IF;
+
+    IF EXISTS (
+        SELECT FROM information_schema.columns
+        WHERE table_schema = current_schema()
+            AND table_name = 'pack_configs'
+            AND column_name = 'pack_sizes'
+    ) THEN
+        INSERT INTO pack_config_versions (
+            sku, version, changed_at, changed_by, reason, stock_limits, pack_costs,
+            overfill_item_cost, rules, effective_from, effective_to
+        )
+        SELECT sku, version, updated_at, updated_by, reason, stock_limits, pack_costs,
+            overfill_item_cost, rules, effective_from, effective_to
+        FROM pack_configs
+        ON CONFLICT DO NOTHING;
+
+        INSERT INTO pack_config_sizes (sku, version, size)
+        SELECT c.sku, c.version, s.size
+        FROM pack_configs c, unnest(c.pack_sizes) AS s (size)
+        ON CONFLICT DO NOTHING;
+
+        ALTER TABLE pack_configs DROP COLUMN pack_sizes;
+    END IF;
+
+    IF EXISTS (
+        SELECT FROM information_schema.columns
+        WHERE table_schema = current_schema()
+            AND table_name = 'pack_config_drafts'
+            AND column_name = 'pack_sizes'
+    ) THEN
+        INSERT INTO pack_config_draft_sizes (draft_id, size)
+        SELECT d.id, s.size
+        FROM pack_config_drafts d, unnest(d.pack_sizes) AS s (size)
+        ON CONFLICT DO NOTHING;
+
+        ALTER TABLE pack_config_drafts DROP COLUMN pack_sizes;
+    END IF;
+END
+$$;
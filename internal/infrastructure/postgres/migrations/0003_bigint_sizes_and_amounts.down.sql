@@ -0,0 +1 @@
+-- The columns are not narrowed back: stored values may not fit an INTEGER.
@@ -0,0 +1,7 @@
+-- The docker init script created pack sizes and calculation amounts as
+-- INTEGER, which stops at 2^31 - 1 while the service takes amounts up to
+-- 10^12. 0001_initial_schema keeps those tables, so this widens them; a
+-- column that already is BIGINT is left as it is.
+ALTER TABLE pack_config_sizes ALTER COLUMN size TYPE BIGINT;
+ALTER TABLE pack_config_draft_sizes ALTER COLUMN size TYPE BIGINT;
+ALTER TABLE calculations ALTER COLUMN amount TYPE BIGINT;
@@ -3,9 +3,11 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/lib/pq"
 
@@ -22,45 +24,112 @@ func NewPackConfigRepository(db *sql.DB, logger *slog.Logger) *PackConfigReposit
 	return &PackConfigRepository{db: db, logger: logger}
 }
 
-// Get loads the single pack config row (id=1). It returns nil when not initialized.
-func (r *PackConfigRepository) Get(ctx context.Context) (*domain.PackConfig, error) {
+// configColumns and versionColumns list what scanPackConfig reads, in order.
+// The sizes of a version are aggregated from pack_config_sizes into one JSON array.
+const (
+	configColumns = `sku, version,
+		(SELECT COALESCE(json_agg(s ORDER BY s.size), '[]') FROM pack_config_sizes s
+			WHERE s.sku = pack_configs.sku AND s.version = pack_configs.version),
+		updated_at, updated_by, reason, stock_limits, pack_costs, overfill_item_cost, rules, effective_from, effective_to`
+	versionColumns = `sku, version,
+		(SELECT COALESCE(json_agg(s ORDER BY s.size), '[]') FROM pack_config_sizes s
+			WHERE s.sku = pack_config_versions.sku AND s.version = pack_config_versions.version),
+		changed_at, changed_by, reason, stock_limits, pack_costs, overfill_item_cost, rules, effective_from, effective_to`
+)
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// Get loads the config row of a SKU. It returns nil when not initialized.
+func (r *PackConfigRepository) Get(ctx context.Context, sku string) (*domain.PackConfig, error) {
 	const query = `
-		SELECT version, COALESCE(pack_sizes, '{}'::INTEGER[]), updated_at
+		SELECT ` + configColumns + `
 		FROM pack_configs
-		WHERE id = 1
+		WHERE sku = $1
 	`
 
-	row := r.db.QueryRowContext(ctx, query)
-
-	var packCfg domain.PackConfig
-	if err := row.Scan(&packCfg.Version, pq.Array(&packCfg.PackSizes), &packCfg.UpdatedAt); err != nil {
+	packCfg, err := scanPackConfig(r.db.QueryRowContext(ctx, query, sku))
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
-		r.logger.Error("failed to fetch pack config", "error", err)
+		r.logger.Error("failed to fetch pack config", "error", err, "sku", sku)
 		return nil, fmt.Errorf("fetch pack config: %w", err)
 	}
 
-	return &packCfg, nil
+	return packCfg, nil
 }
 
-// Create inserts the initial config row if it does not already exist.
+// List loads the current config of every SKU.
+func (r *PackConfigRepository) List(ctx context.Context) ([]domain.PackConfig, error) {
+	const query = `
+		SELECT ` + configColumns + `
+		FROM pack_configs
+		ORDER BY sku
+	`
+
+	configs, err := r.queryPackConfigs(ctx, query)
+	if err != nil {
+		r.logger.Error("failed to list pack configs", "error", err)
+		return nil, fmt.Errorf("list pack configs: %w", err)
+	}
+
+	return configs, nil
+}
+
+// Create inserts the initial config row of a SKU. It returns
+// domain.ErrConcurrencyConflict when another writer created it first.
 func (r *PackConfigRepository) Create(ctx context.Context, packCfg domain.PackConfig) error {
 	const insertQuery = `
-		INSERT INTO pack_configs (id, pack_sizes, version, updated_at)
-		VALUES (1, $1, $2, $3)
+		INSERT INTO pack_configs (sku, version, updated_at, updated_by, reason, stock_limits, pack_costs, overfill_item_cost, rules, effective_from, effective_to)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		ON CONFLICT DO NOTHING
 	`
 
-	_, err := r.db.ExecContext(
-		ctx,
-		insertQuery,
-		pq.Array(packCfg.PackSizes),
-		packCfg.Version,
-		packCfg.UpdatedAt,
-	)
+	stockLimits, packCosts, rules, err := encodePackConfigJSON(packCfg)
 	if err != nil {
-		r.logger.Error("failed to create pack config", "error", err)
+		return err
+	}
+
+	err = r.withTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(
+			ctx,
+			insertQuery,
+			packCfg.SKU,
+			packCfg.Version,
+			packCfg.UpdatedAt,
+			packCfg.UpdatedBy,
+			packCfg.Reason,
+			stockLimits,
+			packCosts,
+			packCfg.OverfillItemCost,
+			rules,
+			packCfg.EffectiveFrom,
+			packCfg.EffectiveTo,
+		)
+		if err != nil {
+			return err
+		}
+
+		// Only the writer that actually created the row records the first version;
+		// a writer that lost the race must not report success.
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected: %w", err)
+		}
+		if affected == 0 {
+			return domain.ErrConcurrencyConflict
+		}
+
+		return insertVersion(ctx, tx, packCfg)
+	})
+	if errors.Is(err, domain.ErrConcurrencyConflict) {
+		return err
+	}
+	if err != nil {
+		r.logger.Error("failed to create pack config", "error", err, "sku", packCfg.SKU)
 		return fmt.Errorf("create pack config: %w", err)
 	}
 
@@ -71,33 +140,396 @@ func (r *PackConfigRepository) Create(ctx context.Context, packCfg domain.PackCo
 func (r *PackConfigRepository) Update(ctx context.Context, packCfg domain.PackConfig) error {
 	const updateQuery = `
 		UPDATE pack_configs
-		SET pack_sizes = $1,
-			version = $2,
-			updated_at = $3
-		WHERE id = 1
-			AND version = $4
+		SET version = $1,
+			updated_at = $2,
+			updated_by = $3,
+			reason = $4,
+			stock_limits = $5,
+			pack_costs = $6,
+			overfill_item_cost = $7,
+			rules = $8,
+			effective_from = $9,
+			effective_to = $10
+		WHERE sku = $11
+			AND version = $12
+	`
+
+	stockLimits, packCosts, rules, err := encodePackConfigJSON(packCfg)
+	if err != nil {
+		return err
+	}
+
+	err = r.withTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(
+			ctx,
+			updateQuery,
+			packCfg.Version,
+			packCfg.UpdatedAt,
+			packCfg.UpdatedBy,
+			packCfg.Reason,
+			stockLimits,
+			packCosts,
+			packCfg.OverfillItemCost,
+			rules,
+			packCfg.EffectiveFrom,
+			packCfg.EffectiveTo,
+			packCfg.SKU,
+			packCfg.Version-1,
+		)
+		if err != nil {
+			r.logger.Error("failed to update pack config", "error", err, "sku", packCfg.SKU)
+			return fmt.Errorf("update pack config: %w", err)
+		}
+
+		// No affected rows means version mismatch (concurrent writer won).
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected: %w", err)
+		}
+		if affected == 0 {
+			return domain.ErrConcurrencyConflict
+		}
+
+		if err := insertVersion(ctx, tx, packCfg); err != nil {
+			r.logger.Error("failed to record pack config version", "error", err, "sku", packCfg.SKU)
+			return fmt.Errorf("record pack config version: %w", err)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// Delete removes a SKU config; its history goes with it through ON DELETE CASCADE.
+func (r *PackConfigRepository) Delete(ctx context.Context, sku string) error {
+	const deleteQuery = `
+		DELETE FROM pack_configs
+		WHERE sku = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, deleteQuery, sku)
+	if err != nil {
+		r.logger.Error("failed to delete pack config", "error", err, "sku", sku)
+		return fmt.Errorf("delete pack config: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return domain.ErrPackConfigNotFound
+	}
+
+	return nil
+}
+
+// ListVersions returns the configuration history of a SKU, newest version first.
+func (r *PackConfigRepository) ListVersions(ctx context.Context, sku string) ([]domain.PackConfig, error) {
+	const query = `
+		SELECT ` + versionColumns + `
+		FROM pack_config_versions
+		WHERE sku = $1
+		ORDER BY version DESC
+	`
+
+	versions, err := r.queryPackConfigs(ctx, query, sku)
+	if err != nil {
+		r.logger.Error("failed to list pack config versions", "error", err, "sku", sku)
+		return nil, fmt.Errorf("list pack config versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetVersion loads one historical version. It returns nil when the version does not exist.
+func (r *PackConfigRepository) GetVersion(ctx context.Context, sku string, version int64) (*domain.PackConfig, error) {
+	const query = `
+		SELECT ` + versionColumns + `
+		FROM pack_config_versions
+		WHERE sku = $1
+			AND version = $2
+	`
+
+	packCfg, err := scanPackConfig(r.db.QueryRowContext(ctx, query, sku, version))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to fetch pack config version", "error", err, "sku", sku, "version", version)
+		return nil, fmt.Errorf("fetch pack config version: %w", err)
+	}
+
+	return packCfg, nil
+}
+
+// GetActive loads the version of a SKU that applies at a time. It returns nil
+// when no version applies.
+func (r *PackConfigRepository) GetActive(ctx context.Context, sku string, at time.Time) (*domain.PackConfig, error) {
+	const query = `
+		SELECT ` + versionColumns + `
+		FROM pack_config_versions
+		WHERE sku = $1
+			AND COALESCE(effective_from, changed_at) <= $2
+			AND (effective_to IS NULL OR effective_to > $2)
+		ORDER BY COALESCE(effective_from, changed_at) DESC, version DESC
+		LIMIT 1
+	`
+
+	packCfg, err := scanPackConfig(r.db.QueryRowContext(ctx, query, sku, at))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to fetch active pack config", "error", err, "sku", sku, "at", at)
+		return nil, fmt.Errorf("fetch active pack config: %w", err)
+	}
+
+	return packCfg, nil
+}
+
+// ListActivations loads the versions of every SKU scheduled to start in (from, to].
+func (r *PackConfigRepository) ListActivations(ctx context.Context, from, to time.Time) ([]domain.PackConfig, error) {
+	const query = `
+		SELECT ` + versionColumns + `
+		FROM pack_config_versions
+		WHERE effective_from > $1
+			AND effective_from <= $2
+		ORDER BY effective_from, sku, version
 	`
 
-	result, err := r.db.ExecContext(
+	versions, err := r.queryPackConfigs(ctx, query, from, to)
+	if err != nil {
+		r.logger.Error("failed to list pack config activations", "error", err)
+		return nil, fmt.Errorf("list pack config activations: %w", err)
+	}
+
+	return versions, nil
+}
+
+func (r *PackConfigRepository) queryPackConfigs(ctx context.Context, query string, args ...any) ([]domain.PackConfig, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	configs := make([]domain.PackConfig, 0)
+	for rows.Next() {
+		packCfg, err := scanPackConfig(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan pack config: %w", err)
+		}
+		configs = append(configs, *packCfg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pack configs: %w", err)
+	}
+
+	return configs, nil
+}
+
+func scanPackConfig(row rowScanner) (*domain.PackConfig, error) {
+	var (
+		packCfg     domain.PackConfig
+		sizes       []byte
+		stockLimits []byte
+		packCosts   []byte
+		rules       []byte
+		from, to    sql.NullTime
+	)
+	err := row.Scan(
+		&packCfg.SKU,
+		&packCfg.Version,
+		&sizes,
+		&packCfg.UpdatedAt,
+		&packCfg.UpdatedBy,
+		&packCfg.Reason,
+		&stockLimits,
+		&packCosts,
+		&packCfg.OverfillItemCost,
+		&rules,
+		&from,
+		&to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	packCfg.EffectiveFrom, packCfg.EffectiveTo = nullTime(from), nullTime(to)
+	// Only published versions are ever stored here; drafts have their own table.
+	packCfg.Status = domain.StatusPublished
+	if err := decodePackSizes(sizes, &packCfg); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(stockLimits, &packCfg.StockLimits); err != nil {
+		return nil, fmt.Errorf("decode stock limits: %w", err)
+	}
+	if err := json.Unmarshal(packCosts, &packCfg.PackCosts); err != nil {
+		return nil, fmt.Errorf("decode pack costs: %w", err)
+	}
+	if err := json.Unmarshal(rules, &packCfg.Rules); err != nil {
+		return nil, fmt.Errorf("decode rules: %w", err)
+	}
+
+	return &packCfg, nil
+}
+
+// encodePackConfigJSON encodes the JSONB columns: stock limits and pack costs are
+// objects keyed by pack size, rules an ordered array.
+func encodePackConfigJSON(packCfg domain.PackConfig) ([]byte, []byte, []byte, error) {
+	stockLimits := packCfg.StockLimits
+	if stockLimits == nil {
+		stockLimits = map[int64]int{}
+	}
+	packCosts := packCfg.PackCosts
+	if packCosts == nil {
+		packCosts = map[int64]domain.PackCost{}
+	}
+
+	stockLimitsJSON, err := json.Marshal(stockLimits)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("encode stock limits: %w", err)
+	}
+	packCostsJSON, err := json.Marshal(packCosts)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("encode pack costs: %w", err)
+	}
+	rules := packCfg.Rules
+	if rules == nil {
+		rules = []domain.Rule{}
+	}
+	rulesJSON, err := json.Marshal(rules)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("encode rules: %w", err)
+	}
+
+	return stockLimitsJSON, packCostsJSON, rulesJSON, nil
+}
+
+// insertVersion records a version in the history together with its sizes.
+func insertVersion(ctx context.Context, tx *sql.Tx, packCfg domain.PackConfig) error {
+	const insertQuery = `
+		INSERT INTO pack_config_versions (sku, version, changed_at, changed_by, reason, stock_limits, pack_costs, overfill_item_cost, rules, effective_from, effective_to)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	const sizesQuery = `
+		INSERT INTO pack_config_sizes (sku, version, size, label, packaging_sku, length_mm, width_mm, height_mm, tare_weight_g, active)
+		SELECT $1::TEXT, $2::BIGINT, * FROM unnest($3::BIGINT[], $4::TEXT[], $5::TEXT[], $6::BIGINT[], $7::BIGINT[], $8::BIGINT[], $9::BIGINT[], $10::BOOLEAN[])
+	`
+
+	stockLimits, packCosts, rules, err := encodePackConfigJSON(packCfg)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
 		ctx,
-		updateQuery,
-		pq.Array(packCfg.PackSizes),
+		insertQuery,
+		packCfg.SKU,
 		packCfg.Version,
 		packCfg.UpdatedAt,
-		packCfg.Version-1,
+		packCfg.UpdatedBy,
+		packCfg.Reason,
+		stockLimits,
+		packCosts,
+		packCfg.OverfillItemCost,
+		rules,
+		packCfg.EffectiveFrom,
+		packCfg.EffectiveTo,
 	)
 	if err != nil {
-		r.logger.Error("failed to update pack config", "error", err)
-		return fmt.Errorf("update pack config: %w", err)
+		return err
 	}
 
-	// No affected rows means version mismatch (concurrent writer won).
-	affected, err := result.RowsAffected()
+	_, err = tx.ExecContext(ctx, sizesQuery, append([]any{packCfg.SKU, packCfg.Version}, packSizeArrays(packCfg)...)...)
+	return err
+}
+
+// packSizeRow is one pack_config_sizes or pack_config_draft_sizes row as the
+// read queries aggregate it to JSON; other columns of the row are ignored.
+type packSizeRow struct {
+	Size            int64  `json:"size"`
+	Label           string `json:"label"`
+	PackagingSKU    string `json:"packaging_sku"`
+	LengthMM        int64  `json:"length_mm"`
+	WidthMM         int64  `json:"width_mm"`
+	HeightMM        int64  `json:"height_mm"`
+	TareWeightGrams int64  `json:"tare_weight_g"`
+	Active          bool   `json:"active"`
+}
+
+// decodePackSizes fills the sizes of a config from their aggregated rows, which
+// arrive in ascending order. Sizes without metadata get no Packs entry.
+func decodePackSizes(data []byte, packCfg *domain.PackConfig) error {
+	var rows []packSizeRow
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return fmt.Errorf("decode pack sizes: %w", err)
+	}
+
+	packCfg.PackSizes = make([]int64, 0, len(rows))
+	for _, row := range rows {
+		pack := domain.PackSize{
+			Size:            row.Size,
+			Label:           row.Label,
+			PackagingSKU:    row.PackagingSKU,
+			Dimensions:      domain.PackDimensions{LengthMM: row.LengthMM, WidthMM: row.WidthMM, HeightMM: row.HeightMM},
+			TareWeightGrams: row.TareWeightGrams,
+			Active:          row.Active,
+		}
+		packCfg.PackSizes = append(packCfg.PackSizes, row.Size)
+		if pack == domain.NewPackSize(row.Size) {
+			continue
+		}
+		if packCfg.Packs == nil {
+			packCfg.Packs = make(map[int64]domain.PackSize)
+		}
+		packCfg.Packs[row.Size] = pack
+	}
+
+	return nil
+}
+
+// packSizeArrays returns the sizes of a config and their metadata as parallel
+// arrays, in the column order of the size tables, for an INSERT ... unnest.
+func packSizeArrays(packCfg domain.PackConfig) []any {
+	packs := packCfg.ListPacks()
+	var (
+		sizes                    = make([]int64, len(packs))
+		labels, skus             = make([]string, len(packs)), make([]string, len(packs))
+		lengths, widths, heights = make([]int64, len(packs)), make([]int64, len(packs)), make([]int64, len(packs))
+		tares                    = make([]int64, len(packs))
+		active                   = make([]bool, len(packs))
+	)
+	for i, pack := range packs {
+		sizes[i], labels[i], skus[i] = pack.Size, pack.Label, pack.PackagingSKU
+		lengths[i], widths[i], heights[i] = pack.Dimensions.LengthMM, pack.Dimensions.WidthMM, pack.Dimensions.HeightMM
+		tares[i], active[i] = pack.TareWeightGrams, pack.Active
+	}
+
+	return []any{pq.Array(sizes), pq.Array(labels), pq.Array(skus), pq.Array(lengths), pq.Array(widths), pq.Array(heights), pq.Array(tares), pq.Array(active)}
+}
+
+// withTx runs fn in a transaction, committing on success and rolling back otherwise.
+func (r *PackConfigRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return withTx(ctx, r.db, r.logger, fn)
+}
+
+func withTx(ctx context.Context, db *sql.DB, logger *slog.Logger, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("rows affected: %w", err)
+		return fmt.Errorf("begin tx: %w", err)
 	}
-	if affected == 0 {
-		return domain.ErrConcurrencyConflict
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			logger.Error("failed to roll back transaction", "error", rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
 	}
 
 	return nil
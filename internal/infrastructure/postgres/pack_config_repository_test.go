@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"go-packing/internal/domain"
+	"go-packing/internal/domain/repotest"
+)
+
+// testDatabaseURLEnv names the DSN of a database the conformance suite may
+// migrate and write to; the suite is skipped without it.
+const testDatabaseURLEnv = "PACKING_TEST_DATABASE_URL"
+
+func TestPackConfigRepository_Conformance(t *testing.T) {
+	db := openMigratedDB(t)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repotest.TestPackConfigsRepository(t, func(t *testing.T) domain.PackConfigsRepository {
+		return NewPackConfigRepository(db, logger)
+	})
+}
+
+// openMigratedDB connects to the database named by PACKING_TEST_DATABASE_URL
+// and applies every embedded migration. It skips the test without a database.
+func openMigratedDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv(testDatabaseURLEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	db, err := NewDB(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	migrate(t, db)
+
+	return db
+}
@@ -0,0 +1,82 @@
+-- The schema of the docker init script before pack sizes had metadata, when
+-- they were kept as pack_sizes arrays.
+-- One row per product SKU; 'default' backs the original single-config endpoints.
+CREATE TABLE IF NOT EXISTS pack_configs (
+    sku TEXT PRIMARY KEY,
+    pack_sizes INTEGER[] NOT NULL,
+    version BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    -- Maximum available packs keyed by size; sizes without a key are unlimited.
+    stock_limits JSONB NOT NULL DEFAULT '{}',
+    -- Unit and handling cost keyed by size, in minor currency units.
+    pack_costs JSONB NOT NULL DEFAULT '{}',
+    overfill_item_cost BIGINT NOT NULL DEFAULT 0,
+    -- Default rule chain, in order; empty means overfill then pack_count.
+    rules JSONB NOT NULL DEFAULT '[]',
+    -- Scheduling window; a NULL effective_from starts at updated_at, a NULL
+    -- effective_to lasts until a later version starts.
+    effective_from TIMESTAMPTZ,
+    effective_to TIMESTAMPTZ
+);
+
+-- Append-only history: one row per written pack_configs version.
+CREATE TABLE IF NOT EXISTS pack_config_versions (
+    sku TEXT NOT NULL REFERENCES pack_configs (sku) ON DELETE CASCADE,
+    version BIGINT NOT NULL,
+    pack_sizes INTEGER[] NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    changed_by TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    stock_limits JSONB NOT NULL DEFAULT '{}',
+    pack_costs JSONB NOT NULL DEFAULT '{}',
+    overfill_item_cost BIGINT NOT NULL DEFAULT 0,
+    rules JSONB NOT NULL DEFAULT '[]',
+    effective_from TIMESTAMPTZ,
+    effective_to TIMESTAMPTZ,
+    PRIMARY KEY (sku, version)
+);
+
+-- Serves the activation job, which scans for versions starting in a window.
+CREATE INDEX IF NOT EXISTS pack_config_versions_effective_from_idx
+    ON pack_config_versions (effective_from)
+    WHERE effective_from IS NOT NULL;
+
+-- Proposed configs under review; publishing one writes a pack_configs version.
+CREATE TABLE IF NOT EXISTS pack_config_drafts (
+    id BIGSERIAL PRIMARY KEY,
+    sku TEXT NOT NULL,
+    -- Version the draft was made from; NULL for a SKU without a config.
+    base_version BIGINT,
+    revision BIGINT NOT NULL,
+    -- draft, approved, rejected or published.
+    status TEXT NOT NULL,
+    pack_sizes INTEGER[] NOT NULL,
+    stock_limits JSONB NOT NULL DEFAULT '{}',
+    pack_costs JSONB NOT NULL DEFAULT '{}',
+    overfill_item_cost BIGINT NOT NULL DEFAULT 0,
+    rules JSONB NOT NULL DEFAULT '[]',
+    effective_from TIMESTAMPTZ,
+    effective_to TIMESTAMPTZ,
+    reason TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reviewed_by TEXT NOT NULL DEFAULT '',
+    reviewed_at TIMESTAMPTZ,
+    published_by TEXT NOT NULL DEFAULT '',
+    published_at TIMESTAMPTZ,
+    published_version BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS pack_config_drafts_sku_status_idx
+    ON pack_config_drafts (sku, status);
+
+CREATE TABLE IF NOT EXISTS pack_config_draft_comments (
+    id BIGSERIAL PRIMARY KEY,
+    draft_id BIGINT NOT NULL REFERENCES pack_config_drafts (id) ON DELETE CASCADE,
+    author TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+// ActivationJob watches for scheduled config versions that reach their
+// EffectiveFrom, and logs and publishes a domain.PackConfigActivated for each.
+type ActivationJob struct {
+	repo      domain.PackConfigsRepository
+	publisher domain.EventPublisher
+	logger    *slog.Logger
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewActivationJob creates a job that checks for activations every interval.
+func NewActivationJob(repo domain.PackConfigsRepository, publisher domain.EventPublisher, logger *slog.Logger, interval time.Duration) *ActivationJob {
+	return &ActivationJob{
+		repo:      repo,
+		publisher: publisher,
+		logger:    logger,
+		interval:  interval,
+		now:       func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Run checks for activations until ctx is done. Versions that started before
+// Run was called are not reported; a failed check is retried on the next tick
+// over the same window.
+func (j *ActivationJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	since := j.now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			until := j.now()
+			if err := j.check(ctx, since, until); err != nil {
+				j.logger.Error("failed to check scheduled pack configs", "error", err)
+				continue
+			}
+			since = until
+		}
+	}
+}
+
+// check reports the versions that started in (since, until] and still apply at
+// until; one that was superseded or retired inside the window never took effect.
+func (j *ActivationJob) check(ctx context.Context, since, until time.Time) error {
+	started, err := j.repo.ListActivations(ctx, since, until)
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range started {
+		active, err := j.repo.GetActive(ctx, cfg.SKU, until)
+		if err != nil {
+			return err
+		}
+		if active == nil || active.Version != cfg.Version {
+			continue
+		}
+
+		event := domain.PackConfigActivated{
+			SKU:           cfg.SKU,
+			Version:       cfg.Version,
+			PackSizes:     cfg.ActiveSizes(),
+			EffectiveFrom: *cfg.EffectiveFrom,
+			ActivatedAt:   until,
+		}
+		j.logger.Info("scheduled pack config activated", "sku", cfg.SKU, "version", cfg.Version, "effective_from", event.EffectiveFrom)
+		if err := j.publisher.Publish(ctx, event); err != nil {
+			j.logger.Error("failed to publish pack config activation", "error", err, "sku", cfg.SKU, "version", cfg.Version)
+		}
+	}
+
+	return nil
+}
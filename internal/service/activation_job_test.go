@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+type recordingPublisher struct {
+	events []domain.Event
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event domain.Event) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func TestActivationJob_Check(t *testing.T) {
+	start := time.Now().UTC()
+	soon, later := start.Add(time.Minute), start.Add(2*time.Minute)
+
+	current, _ := domain.NewPackConfig([]int64{250, 500})
+	current.UpdatedAt = start.Add(-time.Hour)
+	activated, _ := domain.NewPackConfig([]int64{250, 500, 750})
+	activated.Version, activated.EffectiveFrom = 1, &soon
+	// Version 2 starts inside the same window but is retired before its end, so it never applied.
+	retired, _ := domain.NewPackConfig([]int64{750})
+	retired.Version, retired.EffectiveFrom, retired.EffectiveTo = 2, &soon, &later
+	repo := &stubConfigRepo{versions: map[string][]domain.PackConfig{domain.DefaultSKU: {*current, *activated, *retired}}}
+
+	publisher := &recordingPublisher{}
+	job := NewActivationJob(repo, publisher, slog.Default(), time.Minute)
+
+	if err := job.check(context.Background(), start, soon.Add(-time.Second)); err != nil || len(publisher.events) != 0 {
+		t.Fatalf("before activation: error = %v, events = %v", err, publisher.events)
+	}
+	if err := job.check(context.Background(), soon.Add(-time.Second), later); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(publisher.events) != 1 {
+		t.Fatalf("events = %+v, want one activation", publisher.events)
+	}
+	event, ok := publisher.events[0].(domain.PackConfigActivated)
+	if !ok || event.EventType() != "pack_config.activated" || event.Version != 1 || !event.EffectiveFrom.Equal(soon) || !event.ActivatedAt.Equal(later) {
+		t.Fatalf("event = %+v", publisher.events[0])
+	}
+}
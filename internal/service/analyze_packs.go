@@ -0,0 +1,124 @@
+package service
+
+import "fmt"
+
+const (
+	// MaxAnalysisBound caps how many amounts one analysis solves.
+	MaxAnalysisBound = 1_000_000
+	// defaultAnalysisSpan sets the default bound, in multiples of the largest pack.
+	defaultAnalysisSpan = 10
+	// analysisRanges is how many equal ranges the worst overfill is reported for.
+	analysisRanges = 10
+)
+
+// PackAnalysis describes what a set of pack sizes can and cannot ship.
+type PackAnalysis struct {
+	GCD int
+	// FrobeniusNumber is the largest amount no combination hits exactly, 0 when
+	// every amount can be hit. Nil when GCD > 1 leaves infinitely many such amounts.
+	FrobeniusNumber *int
+	// UnreachableCount is how many amounts cannot be hit exactly; nil when GCD > 1.
+	UnreachableCount *int
+	// RedundantForOverfill are sizes that are sums of smaller sizes, so removing
+	// them never raises the shipped total of any amount.
+	RedundantForOverfill []int64
+	// UnusedSizes never appear in a calculated breakdown for amounts up to UpTo.
+	UnusedSizes []int64
+	UpTo        int
+	// OverfillRanges splits [1, UpTo] into equal ranges with their worst overfill.
+	OverfillRanges []OverfillRange
+	Warnings       []string
+}
+
+// OverfillRange is the worst overfill over amounts From to To inclusive.
+type OverfillRange struct {
+	From        int
+	To          int
+	MaxOverfill int
+	// WorstAmount is the smallest amount in the range with MaxOverfill.
+	WorstAmount int
+}
+
+// defaultAnalysisBound is the bound used when the caller does not pick one.
+func defaultAnalysisBound(sizes []int64) int {
+	largest := 1
+	for _, s := range sizes {
+		largest = max(largest, int(s))
+	}
+
+	return min(defaultAnalysisSpan*largest, MaxAnalysisBound)
+}
+
+// analyzePackSizes solves every amount up to upTo with the table of a config.
+func analyzePackSizes(t *packTable, upTo int) PackAnalysis {
+	sizes := t.sizes
+	smallest := sizes[0]
+	analysis := PackAnalysis{UpTo: upTo, GCD: smallest}
+	for _, s := range sizes[1:] {
+		analysis.GCD = gcd(analysis.GCD, s)
+	}
+
+	if analysis.GCD == 1 {
+		// Class r has (reach[r]-r)/smallest unreachable amounts below its reach.
+		frobenius, unreachable := max(t.maxReach-smallest, 0), 0
+		for r, node := range t.reach {
+			unreachable += (node.cost - r) / smallest
+		}
+		analysis.FrobeniusNumber, analysis.UnreachableCount = &frobenius, &unreachable
+	} else {
+		analysis.Warnings = append(analysis.Warnings, fmt.Sprintf("only multiples of %d can be shipped exactly", analysis.GCD))
+	}
+
+	for i, s := range sizes[1:] {
+		if isSumOf(s, sizes[:i+1]) {
+			analysis.RedundantForOverfill = append(analysis.RedundantForOverfill, int64(s))
+			analysis.Warnings = append(analysis.Warnings, fmt.Sprintf("size %d is a sum of smaller sizes and never lowers overfill", s))
+		}
+	}
+
+	amounts := make([]int, upTo)
+	for i := range amounts {
+		amounts[i] = i + 1
+	}
+	totals := t.minimalTotals(amounts)
+	counter := newPackCounter(t, totals[upTo-1])
+
+	width := (upTo + analysisRanges - 1) / analysisRanges
+	used := make(map[int]bool, len(sizes))
+	for i, amount := range amounts {
+		total := totals[i]
+		for size := range counter.counts(total) {
+			used[size] = true
+		}
+
+		if (amount-1)%width == 0 {
+			analysis.OverfillRanges = append(analysis.OverfillRanges, OverfillRange{From: amount, To: min(amount+width-1, upTo), WorstAmount: amount})
+		}
+		current := &analysis.OverfillRanges[len(analysis.OverfillRanges)-1]
+		if overfill := total - amount; overfill > current.MaxOverfill {
+			current.MaxOverfill, current.WorstAmount = overfill, amount
+		}
+	}
+
+	for _, s := range sizes {
+		if !used[s] {
+			analysis.UnusedSizes = append(analysis.UnusedSizes, int64(s))
+		}
+	}
+
+	return analysis
+}
+
+// isSumOf reports whether total is an exact sum of the given sizes.
+func isSumOf(total int, sizes []int) bool {
+	reach := residueShortestPaths(sizes[0], sizes, func(size int) int { return size })
+	return reach[total%sizes[0]].cost <= total
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzePackSizes(t *testing.T) {
+	tests := []struct {
+		name        string
+		sizes       []int64
+		upTo        int
+		gcd         int
+		frobenius   *int
+		unreachable *int
+		redundant   []int64
+		unused      []int64
+	}{
+		{
+			name:        "nuggets",
+			sizes:       []int64{6, 9, 20},
+			upTo:        200,
+			gcd:         1,
+			frobenius:   intPtr(43),
+			unreachable: intPtr(22),
+		},
+		{
+			name:        "sum of smaller sizes",
+			sizes:       []int64{3, 5, 8},
+			upTo:        80,
+			gcd:         1,
+			frobenius:   intPtr(7),
+			unreachable: intPtr(4),
+			redundant:   []int64{8},
+		},
+		{
+			name:        "every amount reachable",
+			sizes:       []int64{1, 3},
+			upTo:        30,
+			gcd:         1,
+			frobenius:   intPtr(0),
+			unreachable: intPtr(0),
+			redundant:   []int64{3},
+		},
+		{
+			name:      "common divisor",
+			sizes:     []int64{250, 500, 1000, 2000, 5000},
+			upTo:      10_000,
+			gcd:       250,
+			redundant: []int64{500, 1000, 2000, 5000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := analyzePackSizes(newPackTable(normalizePackSizes(tt.sizes)), tt.upTo)
+
+			if got.GCD != tt.gcd {
+				t.Fatalf("gcd = %d, want %d", got.GCD, tt.gcd)
+			}
+			if !reflect.DeepEqual(got.FrobeniusNumber, tt.frobenius) {
+				t.Fatalf("frobenius = %v, want %v", deref(got.FrobeniusNumber), deref(tt.frobenius))
+			}
+			if !reflect.DeepEqual(got.UnreachableCount, tt.unreachable) {
+				t.Fatalf("unreachable = %v, want %v", deref(got.UnreachableCount), deref(tt.unreachable))
+			}
+			if !reflect.DeepEqual(got.RedundantForOverfill, tt.redundant) {
+				t.Fatalf("redundant = %v, want %v", got.RedundantForOverfill, tt.redundant)
+			}
+			if !reflect.DeepEqual(got.UnusedSizes, tt.unused) {
+				t.Fatalf("unused = %v, want %v", got.UnusedSizes, tt.unused)
+			}
+		})
+	}
+}
+
+func TestAnalyzePackSizes_OverfillRanges(t *testing.T) {
+	sizes := []int64{250, 500, 1000, 2000, 5000}
+	got := analyzePackSizes(newPackTable(normalizePackSizes(sizes)), 1000)
+
+	if len(got.OverfillRanges) != analysisRanges {
+		t.Fatalf("ranges = %d, want %d", len(got.OverfillRanges), analysisRanges)
+	}
+	first := got.OverfillRanges[0]
+	if first != (OverfillRange{From: 1, To: 100, MaxOverfill: 249, WorstAmount: 1}) {
+		t.Fatalf("first range = %+v", first)
+	}
+	last := got.OverfillRanges[analysisRanges-1]
+	if last != (OverfillRange{From: 901, To: 1000, MaxOverfill: 99, WorstAmount: 901}) {
+		t.Fatalf("last range = %+v", last)
+	}
+	if !reflect.DeepEqual(got.UnusedSizes, []int64{2000, 5000}) {
+		t.Fatalf("unused = %v, want [2000 5000]", got.UnusedSizes)
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func deref(v *int) any {
+	if v == nil {
+		return nil
+	}
+
+	return *v
+}
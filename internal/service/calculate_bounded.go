@@ -0,0 +1,359 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+
+	"go-packing/internal/domain"
+)
+
+// costModel prices breakdowns for the min_cost objective.
+type costModel struct {
+	pack     map[int]int64 // unit plus handling cost of one pack, by size
+	overfill int64         // cost of every item shipped above the order
+}
+
+// isLimited reports whether stock limits any of the pack sizes.
+func isLimited(packSizes []int64, stock map[int64]int) bool {
+	for _, p := range packSizes {
+		if _, limited := stock[p]; limited {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newCostModel(costs map[int64]domain.PackCost, overfillItemCost int64) *costModel {
+	model := &costModel{pack: make(map[int]int64, len(costs)), overfill: overfillItemCost}
+	for size, cost := range costs {
+		model.pack[int(size)] = cost.Total()
+	}
+
+	return model
+}
+
+// costRunnersUp returns up to limit other non-redundant breakdowns, cheapest first,
+// then with less overfill and fewer packs, and whether the candidate search
+// stopped after budget steps.
+func costRunnersUp(order int, packSizes []int64, stock map[int64]int, model *costModel, solved []domain.PackBreakdown, limit, budget int) ([]Alternative, bool) {
+	sizes := normalizePackSizes(packSizes)
+	first := newCandidate(solved, sizes)
+
+	type priced struct {
+		candidate
+		cost int64
+	}
+	price := func(c candidate) int64 {
+		total := int64(c.total-order) * model.overfill
+		for i, n := range c.counts {
+			total += int64(n) * model.pack[sizes[i]]
+		}
+		return total
+	}
+
+	others := make([]priced, 0)
+	pool, truncated := enumerateCandidates(order, sizes, stock, budget)
+	for _, c := range pool {
+		if !slices.Equal(c.counts, first.counts) {
+			others = append(others, priced{candidate: c, cost: price(c)})
+		}
+	}
+	sort.SliceStable(others, func(i, j int) bool {
+		a, b := others[i], others[j]
+		if a.cost != b.cost {
+			return a.cost < b.cost
+		}
+		if a.total != b.total {
+			return a.total < b.total
+		}
+		return a.packs < b.packs
+	})
+
+	// Alternatives rank below the solver's answer, so the first difference is why they lost.
+	firstCost := price(first)
+	alternatives := make([]Alternative, 0, min(limit, len(others)))
+	for _, c := range others[:min(limit, len(others))] {
+		lostOn := string(domain.CriterionPackCount)
+		switch {
+		case c.cost != firstCost:
+			lostOn = "cost"
+		case c.total != first.total:
+			lostOn = string(domain.CriterionOverfill)
+		}
+		alternatives = append(alternatives, newAlternative(c.breakdown(sizes), order, lostOn))
+	}
+
+	return alternatives, truncated
+}
+
+// solveWindow solves orders where the residue solver does not apply: limited
+// stock or cost-based optimization.
+//
+// Sizes are ranked by preference: the largest first by default, the cheapest
+// per item first under a cost model (largest on ties). Replacing a/g packs of a
+// size b with b/g packs of a more preferred size a, g = gcd(a, b), ships the
+// same items at no more cost in fewer packs, so an optimal breakdown has a
+// pivot size with spare stock, uses fewer than a/g packs of every less
+// preferred size b, and all but a few packs of every more preferred size.
+// Each pivot fixes all but a window of packs bounded by the pack sizes, never
+// by the order, and that window is solved exactly as a bounded knapsack.
+func solveWindow(order int, packSizes []int64, stock map[int64]int, model *costModel) ([]domain.PackBreakdown, error) {
+	sizes := normalizePackSizes(packSizes)
+	if order <= 0 || len(sizes) == 0 {
+		return nil, domain.ErrCouldNotCalculate
+	}
+
+	// No optimal breakdown overfills by a whole pack, so nothing beyond this is ever
+	// needed and an unlimited size never takes more than reach/s packs.
+	maxPack := sizes[len(sizes)-1]
+	reach := order + maxPack - 1
+
+	available := make(map[int]int, len(sizes))
+	usable := make([]int, 0, len(sizes))
+	capacity := 0
+	for _, s := range sizes {
+		n := reach / s
+		if limit, ok := stock[int64(s)]; ok {
+			n = min(n, limit)
+		}
+		if n > 0 {
+			available[s] = n
+			usable = append(usable, s)
+			capacity += n * s
+		}
+	}
+	if capacity < order {
+		return nil, fmt.Errorf("%w: available stock covers at most %d of %d items", domain.ErrCouldNotCalculate, capacity, order)
+	}
+
+	ranked := rankSizes(usable, model)
+	var (
+		best      map[int]int
+		bestTotal int
+		bestPacks int
+		bestCost  int64
+		found     bool
+	)
+	for pivot := range ranked {
+		counts, ok := solvePivot(order, reach, usable, ranked, pivot, available, model)
+		if !ok {
+			continue
+		}
+
+		total, packs := 0, 0
+		var cost int64
+		for s, n := range counts {
+			total += s * n
+			packs += n
+			if model != nil {
+				cost += int64(n) * model.pack[s]
+			}
+		}
+		if model != nil {
+			cost += int64(total-order) * model.overfill
+		}
+
+		// Pivots are tried in a fixed order and ties keep the first, so results are deterministic.
+		better := !found
+		switch {
+		case better:
+		case model != nil && cost != bestCost:
+			better = cost < bestCost
+		case total != bestTotal:
+			better = total < bestTotal
+		default:
+			better = packs < bestPacks
+		}
+		if better {
+			best, bestTotal, bestPacks, bestCost, found = counts, total, packs, cost, true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: available stock cannot cover %d items", domain.ErrCouldNotCalculate, order)
+	}
+
+	return toBreakdown(best), nil
+}
+
+// solvePivot returns the best breakdown in which ranked[pivot] is the first size
+// with spare stock.
+//
+// Sizes ranked before the pivot are fixed to their stock minus the packs that
+// could still be exchanged, sizes ranked after it are capped at the count that
+// the pivot could replace, and the pivot fills the order down to the window of
+// the others. Only that window is left to the knapsack.
+func solvePivot(order, reach int, sizes, ranked []int, pivot int, available map[int]int, model *costModel) (map[int]int, bool) {
+	p := ranked[pivot]
+	fixed := make(map[int]int, len(ranked))
+	caps := make(map[int]int, len(ranked))
+	committed, window := 0, 0
+	for i, s := range ranked {
+		switch {
+		case i < pivot:
+			// Without spare stock, fewer packs than a less preferred size could
+			// exchange for are left unused.
+			spare := 0
+			for _, b := range ranked[i+1:] {
+				spare = max(spare, b/gcd(s, b))
+			}
+			fixed[s] = max(0, available[s]-spare+1)
+			caps[s] = available[s] - fixed[s]
+		case i > pivot:
+			caps[s] = min(available[s], p/gcd(p, s)-1)
+		}
+		committed += fixed[s] * s
+		window += caps[s] * s
+	}
+	if committed > reach {
+		return nil, false
+	}
+
+	rest := order - committed
+	if rest > window {
+		fixed[p] = (rest - window) / p
+		if fixed[p] > available[p] {
+			return nil, false
+		}
+		rest -= fixed[p] * p
+	}
+	caps[p] = available[p] - fixed[p]
+
+	counts, ok := boundedKnapsack(max(rest, 0), rest+reach-order, sizes, caps, model)
+	if !ok {
+		return nil, false
+	}
+	for s, n := range fixed {
+		if n > 0 {
+			counts[s] += n
+		}
+	}
+
+	return counts, true
+}
+
+// rankSizes orders sizes from the most to the least preferred: the largest first
+// or, under a cost model, the cheapest per item first (largest on ties).
+func rankSizes(sizes []int, model *costModel) []int {
+	ranked := slices.Clone(sizes)
+	slices.SortFunc(ranked, func(a, b int) int {
+		if model != nil {
+			// cost(a)/a against cost(b)/b, compared without division.
+			if c := cmp.Compare(model.pack[a]*int64(b), model.pack[b]*int64(a)); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(b, a)
+	})
+
+	return ranked
+}
+
+// boundedKnapsack picks the best sum in [order, limit] and the packs reaching it,
+// using at most limited[s] packs of each size s.
+//
+// Without a cost model the best sum is the smallest reachable one, reached with
+// the fewest packs. With a cost model it is the cheapest one including overfill,
+// then the smallest, then the one with the fewest packs.
+//
+// Each size is split into power-of-two bundles, turning the problem into a 0/1
+// knapsack whose choices are kept as one bit per bundle and sum. Sizes are
+// visited in the given order so ties resolve deterministically.
+// Time complexity: O(limit * bundles); space complexity: O(limit * bundles / 64).
+func boundedKnapsack(order, limit int, sizes []int, limited map[int]int, model *costModel) (map[int]int, bool) {
+	type bundle struct {
+		size, count int
+		cost        int64
+	}
+
+	bundles := make([]bundle, 0)
+	for _, s := range sizes {
+		available := min(limited[s], limit/s)
+		for chunk := 1; available > 0; chunk *= 2 {
+			take := min(chunk, available)
+			b := bundle{size: s, count: take}
+			if model != nil {
+				b.cost = model.pack[s] * int64(take)
+			}
+			bundles = append(bundles, b)
+			available -= take
+		}
+	}
+
+	// dp[i] = fewest packs reaching sum i; with a cost model, cost[i] is compared first.
+	dp := make([]int, limit+1)
+	var cost []int64
+	if model != nil {
+		cost = make([]int64, limit+1)
+	}
+	for i := 1; i <= limit; i++ {
+		dp[i] = math.MaxInt32
+	}
+
+	better := func(i int, c int64, packs int) bool {
+		if cost != nil && c != cost[i] {
+			return c < cost[i]
+		}
+		return packs < dp[i]
+	}
+
+	words := limit/64 + 1
+	taken := make([][]uint64, len(bundles))
+	for b, bd := range bundles {
+		taken[b] = make([]uint64, words)
+		weight := bd.size * bd.count
+		for i := limit; i >= weight; i-- {
+			from := i - weight
+			if dp[from] == math.MaxInt32 {
+				continue
+			}
+
+			var c int64
+			if cost != nil {
+				c = cost[from] + bd.cost
+			}
+			if dp[i] == math.MaxInt32 || better(i, c, dp[from]+bd.count) {
+				dp[i] = dp[from] + bd.count
+				if cost != nil {
+					cost[i] = c
+				}
+				taken[b][i/64] |= 1 << (i % 64)
+			}
+		}
+	}
+
+	best := -1
+	var bestCost int64
+	for i := order; i <= limit; i++ {
+		if dp[i] == math.MaxInt32 {
+			continue
+		}
+		if model == nil {
+			best = i
+			break
+		}
+
+		// Ties keep the earlier, smaller sum.
+		total := cost[i] + int64(i-order)*model.overfill
+		if best == -1 || total < bestCost {
+			best, bestCost = i, total
+		}
+	}
+	if best == -1 {
+		return nil, false
+	}
+
+	// Walk bundles backwards; a set bit means the bundle produced the value at that sum.
+	counts := make(map[int]int)
+	for b, curr := len(bundles)-1, best; b >= 0 && curr > 0; b-- {
+		if taken[b][curr/64]&(1<<(curr%64)) != 0 {
+			counts[bundles[b].size] += bundles[b].count
+			curr -= bundles[b].size * bundles[b].count
+		}
+	}
+
+	return counts, true
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"go-packing/internal/domain"
+)
+
+// solveStock solves an order against a config of packSizes with stock overrides.
+func solveStock(order int, packSizes []int64, stock map[int64]int) ([]domain.PackBreakdown, error) {
+	cfg, err := domain.NewPackConfig(packSizes)
+	if err != nil {
+		return nil, err
+	}
+
+	var cache *SolverCache
+	result, err := solveConfig(cfg, CalculateInput{Amount: order, StockLimits: stock}, cache.table)
+	if err != nil {
+		return nil, err
+	}
+	return result.Packs, nil
+}
+
+// solveMinCost solves an order for the min_cost objective against a config of
+// packSizes with the given costs.
+func solveMinCost(order int, packSizes []int64, stock map[int64]int, costs map[int64]domain.PackCost, overfillItemCost int64) ([]domain.PackBreakdown, error) {
+	cfg, err := domain.NewPackConfig(packSizes)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.SetCosts(costs, overfillItemCost); err != nil {
+		return nil, err
+	}
+
+	var cache *SolverCache
+	in := CalculateInput{Amount: order, StockLimits: stock, Objective: domain.ObjectiveMinCost}
+	result, err := solveConfig(cfg, in, cache.table)
+	if err != nil {
+		return nil, err
+	}
+	return result.Packs, nil
+}
+
+func TestSolveStock_LimitedLargePack(t *testing.T) {
+	packSizes := []int64{250, 500, 1000, 2000, 5000}
+	stock := map[int64]int{5000: 1}
+
+	got, err := solveStock(12001, packSizes, stock)
+	if err != nil {
+		t.Fatalf("solveStock returned error: %v", err)
+	}
+
+	expected := []domain.PackBreakdown{
+		{Size: 5000, Count: 1},
+		{Size: 2000, Count: 3},
+		{Size: 1000, Count: 1},
+		{Size: 250, Count: 1},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("unexpected result, got=%#v want=%#v", got, expected)
+	}
+}
+
+func TestSolveStock_InsufficientStock(t *testing.T) {
+	_, err := solveStock(1001, []int64{250, 500}, map[int64]int{250: 1, 500: 1})
+	if !errors.Is(err, domain.ErrCouldNotCalculate) {
+		t.Fatalf("expected ErrCouldNotCalculate, got %v", err)
+	}
+}
+
+func TestSolveStock_MatchesBruteForce(t *testing.T) {
+	cases := []struct {
+		packSizes []int64
+		stock     map[int64]int
+	}{
+		{packSizes: []int64{250, 500, 1000}, stock: map[int64]int{500: 1}},
+		{packSizes: []int64{3, 7, 10}, stock: map[int64]int{10: 2, 3: 1}},
+		{packSizes: []int64{6, 9, 20}, stock: map[int64]int{20: 3, 9: 2, 6: 4}},
+		{packSizes: []int64{4, 6, 15}, stock: map[int64]int{4: 0}},
+		{packSizes: []int64{4, 9, 23}, stock: map[int64]int{23: 5, 9: 7}},
+		{packSizes: []int64{5, 12, 18}, stock: map[int64]int{18: 40, 12: 3}},
+	}
+
+	for _, tc := range cases {
+		for amount := 1; amount <= 200; amount++ {
+			wantTotal, wantPacks := bruteForceStock(amount, tc.packSizes, tc.stock)
+
+			got, err := solveStock(amount, tc.packSizes, tc.stock)
+			if wantTotal == -1 {
+				if !errors.Is(err, domain.ErrCouldNotCalculate) {
+					t.Fatalf("solveStock(%d, %v, %v) expected ErrCouldNotCalculate, got %v", amount, tc.packSizes, tc.stock, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("solveStock(%d, %v, %v) returned error: %v", amount, tc.packSizes, tc.stock, err)
+			}
+
+			gotTotal, gotPacks := breakdownTotals(got)
+			if gotTotal != wantTotal || gotPacks != wantPacks {
+				t.Fatalf("solveStock(%d, %v, %v) shipped %d in %d packs, want %d in %d packs",
+					amount, tc.packSizes, tc.stock, gotTotal, gotPacks, wantTotal, wantPacks)
+			}
+			for _, p := range got {
+				if available, ok := tc.stock[int64(p.Size)]; ok && p.Count > available {
+					t.Fatalf("solveStock(%d) used %d packs of %d, only %d available", amount, p.Count, p.Size, available)
+				}
+			}
+		}
+	}
+}
+
+func TestSolveStock_LargeOrders(t *testing.T) {
+	packSizes := []int64{250, 500, 1000, 2000, 5000}
+	tests := []struct {
+		name   string
+		amount int
+		stock  map[int64]int
+		want   []domain.PackBreakdown
+	}{
+		{
+			name:   "stock above the order",
+			amount: 50_000_001,
+			stock:  map[int64]int{5000: 1_000_000},
+			want:   []domain.PackBreakdown{{Size: 5000, Count: 10_000}, {Size: 250, Count: 1}},
+		},
+		{
+			name:   "large pack runs out",
+			amount: 50_000_000,
+			stock:  map[int64]int{5000: 5000},
+			want:   []domain.PackBreakdown{{Size: 5000, Count: 5000}, {Size: 2000, Count: 12_500}},
+		},
+		{
+			name:   "every size limited",
+			amount: 10_000_250,
+			stock:  map[int64]int{5000: 1000, 2000: 2000, 1000: 1000, 500: 1_000_000},
+			want:   []domain.PackBreakdown{{Size: 5000, Count: 1000}, {Size: 2000, Count: 2000}, {Size: 1000, Count: 1000}, {Size: 250, Count: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			got, err := solveStock(tt.amount, packSizes, tt.stock)
+			runtime.ReadMemStats(&after)
+			if err != nil {
+				t.Fatalf("solveStock returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected result, got=%#v want=%#v", got, tt.want)
+			}
+
+			// The knapsack window depends on the pack sizes, not on the amount.
+			if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+				t.Fatalf("allocated %d bytes for %d items", allocated, tt.amount)
+			}
+		})
+	}
+}
+
+// bruteForceStock enumerates every count combination within stock.
+func bruteForceStock(order int, packSizes []int64, stock map[int64]int) (int, int) {
+	maxPack := 0
+	for _, p := range packSizes {
+		maxPack = max(maxPack, int(p))
+	}
+
+	bestTotal, bestPacks := -1, -1
+	var walk func(i, total, packs int)
+	walk = func(i, total, packs int) {
+		if i == len(packSizes) {
+			if total >= order && (bestTotal == -1 || total < bestTotal || (total == bestTotal && packs < bestPacks)) {
+				bestTotal, bestPacks = total, packs
+			}
+			return
+		}
+		size := int(packSizes[i])
+		limit := (order + maxPack) / size
+		if available, ok := stock[packSizes[i]]; ok {
+			limit = min(limit, available)
+		}
+		for n := 0; n <= limit; n++ {
+			walk(i+1, total+n*size, packs+n)
+		}
+	}
+	walk(0, 0, 0)
+
+	return bestTotal, bestPacks
+}
+
+func TestSolveMinCost_PrefersCheaperPacks(t *testing.T) {
+	packSizes := []int64{250, 500, 1000}
+	costs := map[int64]domain.PackCost{
+		250:  {UnitCost: 10, HandlingCost: 5},
+		500:  {UnitCost: 40, HandlingCost: 5},
+		1000: {UnitCost: 50, HandlingCost: 5},
+	}
+
+	// Two 250 packs (30) beat one 500 pack (45) for the same 500 items.
+	got, err := solveMinCost(501, packSizes, nil, costs, 0)
+	if err != nil {
+		t.Fatalf("solveMinCost returned error: %v", err)
+	}
+
+	expected := []domain.PackBreakdown{{Size: 250, Count: 3}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("unexpected result, got=%#v want=%#v", got, expected)
+	}
+}
+
+func TestSolveMinCost_MatchesBruteForce(t *testing.T) {
+	cases := []struct {
+		packSizes []int64
+		costs     map[int64]domain.PackCost
+		overfill  int64
+		stock     map[int64]int
+	}{
+		{
+			packSizes: []int64{3, 7, 10},
+			costs:     map[int64]domain.PackCost{3: {UnitCost: 4}, 7: {UnitCost: 6, HandlingCost: 1}, 10: {UnitCost: 12}},
+			overfill:  1,
+		},
+		{
+			packSizes: []int64{6, 9, 20},
+			costs:     map[int64]domain.PackCost{6: {UnitCost: 5}, 9: {UnitCost: 9}, 20: {UnitCost: 14, HandlingCost: 3}},
+			overfill:  2,
+			stock:     map[int64]int{20: 2},
+		},
+		{
+			packSizes: []int64{4, 5},
+			costs:     map[int64]domain.PackCost{4: {UnitCost: 1}, 5: {UnitCost: 1}},
+			overfill:  0,
+		},
+		{
+			packSizes: []int64{4, 9, 23},
+			costs:     map[int64]domain.PackCost{4: {UnitCost: 1}, 9: {UnitCost: 3}, 23: {UnitCost: 8}},
+			overfill:  1,
+			stock:     map[int64]int{4: 10, 23: 3},
+		},
+	}
+
+	for _, tc := range cases {
+		for amount := 1; amount <= 150; amount++ {
+			got, err := solveMinCost(amount, tc.packSizes, tc.stock, tc.costs, tc.overfill)
+			if err != nil {
+				t.Fatalf("solveMinCost(%d) returned error: %v", amount, err)
+			}
+
+			want := bruteForceMinCost(amount, tc.packSizes, tc.stock, tc.costs, tc.overfill)
+			gotTotal, _ := breakdownTotals(got)
+			if gotCost := breakdownCost(got, tc.costs) + int64(gotTotal-amount)*tc.overfill; gotCost != want {
+				t.Fatalf("solveMinCost(%d, %v) cost %d, want %d (%#v)", amount, tc.packSizes, gotCost, want, got)
+			}
+		}
+	}
+}
+
+func breakdownCost(packs []domain.PackBreakdown, costs map[int64]domain.PackCost) int64 {
+	var total int64
+	for _, p := range packs {
+		total += costs[int64(p.Size)].Total() * int64(p.Count)
+	}
+	return total
+}
+
+// bruteForceMinCost enumerates every count combination within stock and returns the lowest cost.
+func bruteForceMinCost(order int, packSizes []int64, stock map[int64]int, costs map[int64]domain.PackCost, overfill int64) int64 {
+	maxPack := 0
+	for _, p := range packSizes {
+		maxPack = max(maxPack, int(p))
+	}
+
+	best := int64(-1)
+	var walk func(i, total int, cost int64)
+	walk = func(i, total int, cost int64) {
+		if i == len(packSizes) {
+			if total >= order {
+				if c := cost + int64(total-order)*overfill; best == -1 || c < best {
+					best = c
+				}
+			}
+			return
+		}
+		size := int(packSizes[i])
+		limit := (order + maxPack) / size
+		if available, ok := stock[packSizes[i]]; ok {
+			limit = min(limit, available)
+		}
+		for n := 0; n <= limit; n++ {
+			walk(i+1, total+n*size, cost+int64(n)*costs[packSizes[i]].Total())
+		}
+	}
+	walk(0, 0, 0)
+
+	return best
+}
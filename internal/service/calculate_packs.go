@@ -0,0 +1,375 @@
+package service
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+
+	"go-packing/internal/domain"
+)
+
+// packTable holds the residue structures of one set of pack sizes. They depend
+// on the sizes only, so a table built once answers every amount.
+type packTable struct {
+	sizes []int
+	// reach[r] holds the smallest reachable sum congruent to r modulo the smallest
+	// pack; every larger sum in the same class is reachable by adding smallest packs.
+	reach []residueNode
+	// maxReach is the largest finite reach; from there on every reachable class
+	// has a sum at or just above any order.
+	maxReach int
+	// next[r] is the distance from r to the closest reachable residue at or above it.
+	next []int
+	// paths are the cheapest sets of smaller packs per residue modulo the largest pack.
+	paths []residueNode
+}
+
+// newPackTable builds the residue structures of sorted, unique, positive sizes.
+// Time complexity: O((smallest + largest) * len(sizes) * log(largest)).
+func newPackTable(sizes []int) *packTable {
+	smallest, largest := sizes[0], sizes[len(sizes)-1]
+	t := &packTable{
+		sizes: sizes,
+		reach: residueShortestPaths(smallest, sizes, func(size int) int { return size }),
+		next:  make([]int, smallest),
+	}
+
+	// Walk residues downwards twice so that classes wrap around to the next reachable one.
+	gap := math.MaxInt
+	for k := 2*smallest - 1; k >= 0; k-- {
+		r := k % smallest
+		if t.reach[r].cost != math.MaxInt {
+			gap = 0
+			t.maxReach = max(t.maxReach, t.reach[r].cost)
+		} else if gap != math.MaxInt {
+			gap++
+		}
+		t.next[r] = gap
+	}
+
+	if len(sizes) > 1 {
+		t.paths = residueShortestPaths(largest, sizes[:len(sizes)-1], func(size int) int { return largest - size })
+	}
+
+	return t
+}
+
+// calculate finds a pack combination that minimizes total shipped quantity,
+// and among those, minimizes the number of packs.
+//
+// Memory depends on pack sizes only, never on the order amount:
+//   - the minimal shipped total comes from the smallest reachable sum of each
+//     residue class modulo the smallest pack;
+//   - the pack count is minimized over residue classes modulo the largest pack,
+//     which then fills the bulk of the order.
+func (t *packTable) calculate(order int) ([]domain.PackBreakdown, error) {
+	if order <= 0 {
+		return nil, domain.ErrCouldNotCalculate
+	}
+
+	// Rule #1 and #2: smallest reachable total that still covers the order.
+	total := t.minimalTotal(order)
+
+	// Rule #3: fewest packs that add up to exactly that total.
+	counts, ok := t.fewestPacks(total)
+	if !ok {
+		// Only small totals end up here, so the table stays bounded by pack sizes.
+		counts, ok = fewestPacksDP(total, t.sizes)
+	}
+	if !ok {
+		return nil, domain.ErrCouldNotCalculate
+	}
+
+	return toBreakdown(counts), nil
+}
+
+// bytes estimates the memory held by the table.
+func (t *packTable) bytes() int64 {
+	const nodeBytes, intBytes = 24, 8
+	return int64(len(t.reach)+len(t.paths))*nodeBytes + int64(len(t.next)+len(t.sizes))*intBytes
+}
+
+// normalizePackSizes returns positive, unique pack sizes in ascending order.
+func normalizePackSizes(packSizes []int64) []int {
+	seen := make(map[int]struct{}, len(packSizes))
+	sizes := make([]int, 0, len(packSizes))
+	for _, p := range packSizes {
+		s := int(p)
+		if s <= 0 {
+			continue
+		}
+		if _, exists := seen[s]; exists {
+			continue
+		}
+		seen[s] = struct{}{}
+		sizes = append(sizes, s)
+	}
+	sort.Ints(sizes)
+
+	return sizes
+}
+
+// minimalTotal returns the smallest sum of packs that is >= order.
+// Orders above every reach only need the next reachable residue; smaller ones
+// check each residue class.
+func (t *packTable) minimalTotal(order int) int {
+	smallest := t.sizes[0]
+	if order >= t.maxReach {
+		return order + t.next[order%smallest]
+	}
+
+	best := math.MaxInt
+	for r, node := range t.reach {
+		if node.cost == math.MaxInt {
+			continue
+		}
+
+		candidate := node.cost
+		if candidate < order {
+			// First value >= order in the residue class r.
+			candidate = order + ((r-order)%smallest+smallest)%smallest
+		}
+		best = min(best, candidate)
+	}
+
+	return best
+}
+
+// minimalTotals is minimalTotal for many orders. Orders below maxReach share one
+// scan over the sums up to the largest of them instead of a residue scan each.
+func (t *packTable) minimalTotals(orders []int) []int {
+	smallest := t.sizes[0]
+	// Class 0 is reached at 0, so an answer below maxReach is at most order+smallest-1.
+	limit := -1
+	for _, order := range orders {
+		if order < t.maxReach {
+			limit = max(limit, order+smallest-1)
+		}
+	}
+
+	// above[x] is the smallest reachable sum >= x.
+	above := make([]int, limit+1)
+	next := math.MaxInt
+	for x := limit; x >= 0; x-- {
+		if t.reach[x%smallest].cost <= x {
+			next = x
+		}
+		above[x] = next
+	}
+
+	totals := make([]int, len(orders))
+	for i, order := range orders {
+		if order < t.maxReach {
+			totals[i] = above[max(order, 0)]
+		} else {
+			totals[i] = t.minimalTotal(order)
+		}
+	}
+
+	return totals
+}
+
+// fewestPacks returns the pack counts for exactly total using the fewest packs.
+//
+// A breakdown is n largest packs plus a multiset S of smaller packs, so
+// count*largest = total + sum(largest - size) over S. Minimizing the pack count
+// therefore means finding the cheapest S per residue class modulo the largest
+// pack. The result is only valid when S fits into total, which always holds for
+// large totals; ok is false otherwise.
+func (t *packTable) fewestPacks(total int) (map[int]int, bool) {
+	largest := t.sizes[len(t.sizes)-1]
+	counts := make(map[int]int)
+	if t.paths == nil {
+		if total%largest != 0 {
+			return nil, false
+		}
+		counts[largest] = total / largest
+		return counts, true
+	}
+
+	target := total % largest
+	node := t.paths[target]
+	if node.cost == math.MaxInt || node.sum > total {
+		return nil, false
+	}
+
+	for r := target; r != 0; {
+		size := t.paths[r].via
+		counts[size]++
+		r = ((r-size)%largest + largest) % largest
+	}
+	if bulk := (total - node.sum) / largest; bulk > 0 {
+		counts[largest] = bulk
+	}
+
+	return counts, true
+}
+
+// fewestPacksDP is the exact-sum unbounded knapsack used for small totals.
+func fewestPacksDP(total int, sizes []int) (map[int]int, bool) {
+	dp, parent := exactFewestPacks(total, sizes)
+	return dpCounts(total, dp, parent)
+}
+
+// packCounter answers fewestPacks for many totals of one table. Totals the
+// residue paths cannot split share one exact knapsack table up to limit,
+// built on first use.
+type packCounter struct {
+	t      *packTable
+	limit  int
+	dp     []int
+	parent []int
+}
+
+// newPackCounter serves totals up to limit; the table never grows past the
+// largest residue path, since larger totals need no knapsack.
+func newPackCounter(t *packTable, limit int) *packCounter {
+	pathLimit := 0
+	for _, node := range t.paths {
+		if node.cost != math.MaxInt {
+			pathLimit = max(pathLimit, node.sum)
+		}
+	}
+
+	return &packCounter{t: t, limit: min(limit, pathLimit)}
+}
+
+// counts returns the pack counts calculate picks for exactly total.
+func (c *packCounter) counts(total int) map[int]int {
+	if counts, ok := c.t.fewestPacks(total); ok {
+		return counts
+	}
+	if total > c.limit {
+		return nil
+	}
+
+	if c.dp == nil {
+		c.dp, c.parent = exactFewestPacks(c.limit, c.t.sizes)
+	}
+	counts, _ := dpCounts(total, c.dp, c.parent)
+
+	return counts
+}
+
+// exactFewestPacks fills the knapsack table for every sum up to limit.
+// Entries do not depend on limit, so one table serves every smaller total.
+func exactFewestPacks(limit int, sizes []int) ([]int, []int) {
+	// dp[i] = minimum number of packs needed to reach sum i
+	// parent[i] = pack size last used to reach sum i
+	dp := make([]int, limit+1)
+	parent := make([]int, limit+1)
+
+	for i := 1; i <= limit; i++ {
+		dp[i] = math.MaxInt32
+	}
+
+	for _, s := range sizes {
+		for i := s; i <= limit; i++ {
+			if dp[i-s] != math.MaxInt32 && dp[i-s]+1 < dp[i] {
+				dp[i] = dp[i-s] + 1
+				parent[i] = s
+			}
+		}
+	}
+
+	return dp, parent
+}
+
+// dpCounts reconstructs the packs of total from an exactFewestPacks table.
+func dpCounts(total int, dp, parent []int) (map[int]int, bool) {
+	if dp[total] == math.MaxInt32 {
+		return nil, false
+	}
+
+	counts := make(map[int]int)
+	for curr := total; curr > 0; curr -= parent[curr] {
+		counts[parent[curr]]++
+	}
+
+	return counts, true
+}
+
+// residueNode is the best known path to one residue class.
+type residueNode struct {
+	cost int // accumulated edge weight
+	sum  int // accumulated pack sizes, breaks cost ties towards smaller sums
+	via  int // last pack size used to reach this residue
+}
+
+// residueShortestPaths runs Dijkstra over residues modulo mod, where adding a
+// pack of size s moves from r to (r+s) mod mod at the given weight.
+// Time complexity: O(mod * len(sizes) * log(mod)); space complexity: O(mod).
+func residueShortestPaths(mod int, sizes []int, weight func(size int) int) []residueNode {
+	nodes := make([]residueNode, mod)
+	for i := range nodes {
+		nodes[i] = residueNode{cost: math.MaxInt, sum: math.MaxInt}
+	}
+	nodes[0] = residueNode{}
+
+	done := make([]bool, mod)
+	queue := &residueQueue{{residue: 0}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(residueItem)
+		if done[item.residue] {
+			continue
+		}
+		done[item.residue] = true
+
+		from := nodes[item.residue]
+		for _, s := range sizes {
+			next := (item.residue + s) % mod
+			cost, sum := from.cost+weight(s), from.sum+s
+			to := nodes[next]
+			if cost < to.cost || (cost == to.cost && sum < to.sum) {
+				nodes[next] = residueNode{cost: cost, sum: sum, via: s}
+				heap.Push(queue, residueItem{residue: next, cost: cost, sum: sum})
+			}
+		}
+	}
+
+	return nodes
+}
+
+type residueItem struct {
+	residue int
+	cost    int
+	sum     int
+}
+
+// residueQueue is a min-heap ordered by (cost, sum).
+type residueQueue []residueItem
+
+func (q residueQueue) Len() int { return len(q) }
+
+func (q residueQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	return q[i].sum < q[j].sum
+}
+
+func (q residueQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *residueQueue) Push(x any) { *q = append(*q, x.(residueItem)) }
+
+func (q *residueQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// toBreakdown converts pack counts into a breakdown ordered by size descending.
+func toBreakdown(counts map[int]int) []domain.PackBreakdown {
+	result := make([]domain.PackBreakdown, 0, len(counts))
+	for size, count := range counts {
+		if count > 0 {
+			result = append(result, domain.PackBreakdown{Size: size, Count: count})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Size > result[j].Size
+	})
+
+	return result
+}
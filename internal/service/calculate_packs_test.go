@@ -1,6 +1,7 @@
 package service
 
 import (
+	"math"
 	"reflect"
 	"testing"
 
@@ -52,7 +53,7 @@ func TestOptimizePacks_TableExamples(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := calculate(tt.amount, packSizes)
+			got, err := newPackTable(normalizePackSizes(packSizes)).calculate(tt.amount)
 			if err != nil {
 				t.Fatalf("optimizePacks returned error: %v", err)
 			}
@@ -63,3 +64,133 @@ func TestOptimizePacks_TableExamples(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculate_MatchesDynamicProgramming(t *testing.T) {
+	packSets := [][]int64{
+		{250, 500, 1000, 2000, 5000},
+		{23, 31, 53},
+		{6, 9, 20},
+		{3, 7},
+		{4, 6},
+		{1},
+	}
+
+	for _, packSizes := range packSets {
+		table := newPackTable(normalizePackSizes(packSizes))
+		for amount := 1; amount <= 3000; amount++ {
+			got, err := table.calculate(amount)
+			if err != nil {
+				t.Fatalf("calculate(%d, %v) returned error: %v", amount, packSizes, err)
+			}
+
+			wantTotal, wantPacks := referenceDP(amount, packSizes)
+			gotTotal, gotPacks := breakdownTotals(got)
+			if gotTotal != wantTotal || gotPacks != wantPacks {
+				t.Fatalf("calculate(%d, %v) shipped %d in %d packs, want %d in %d packs",
+					amount, packSizes, gotTotal, gotPacks, wantTotal, wantPacks)
+			}
+		}
+	}
+}
+
+func TestCalculate_LargeAmount(t *testing.T) {
+	packSizes := []int64{250, 500, 1000, 2000, 5000}
+
+	got, err := newPackTable(normalizePackSizes(packSizes)).calculate(2_000_000_001)
+	if err != nil {
+		t.Fatalf("calculate returned error: %v", err)
+	}
+
+	expected := []domain.PackBreakdown{
+		{Size: 5000, Count: 400_000},
+		{Size: 250, Count: 1},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("unexpected result, got=%#v want=%#v", got, expected)
+	}
+}
+
+// referenceDP is the original bounded DP: minimal shipped total, then fewest packs.
+func referenceDP(order int, packSizes []int64) (int, int) {
+	maxPack := 0
+	for _, p := range packSizes {
+		maxPack = max(maxPack, int(p))
+	}
+	limit := order + maxPack
+
+	dp := make([]int, limit+1)
+	for i := 1; i <= limit; i++ {
+		dp[i] = math.MaxInt32
+	}
+	for _, p := range packSizes {
+		s := int(p)
+		for i := s; i <= limit; i++ {
+			if dp[i-s] != math.MaxInt32 && dp[i-s]+1 < dp[i] {
+				dp[i] = dp[i-s] + 1
+			}
+		}
+	}
+
+	for i := order; i <= limit; i++ {
+		if dp[i] != math.MaxInt32 {
+			return i, dp[i]
+		}
+	}
+	return -1, -1
+}
+
+func breakdownTotals(packs []domain.PackBreakdown) (int, int) {
+	total, count := 0, 0
+	for _, p := range packs {
+		total += p.Size * p.Count
+		count += p.Count
+	}
+	return total, count
+}
+
+func TestPackTable_MinimalTotalAboveReach(t *testing.T) {
+	for _, sizes := range [][]int{{6, 9, 20}, {4, 6}, {23, 31, 53}, {250, 500, 1000, 2000, 5000}} {
+		table := newPackTable(sizes)
+		for order := table.maxReach; order < table.maxReach+3*sizes[0]; order++ {
+			want := order
+			for !reachable(want, sizes) {
+				want++
+			}
+			if got := table.minimalTotal(order); got != want {
+				t.Fatalf("sizes=%v order=%d: minimalTotal = %d, want %d", sizes, order, got, want)
+			}
+		}
+	}
+}
+
+func TestPackTable_MinimalTotals(t *testing.T) {
+	for _, sizes := range [][]int{{6, 9, 20}, {4, 6}, {23, 31, 53}, {499, 500}} {
+		table := newPackTable(sizes)
+		orders := make([]int, 0, 600)
+		for order := 1; order < 3*table.maxReach; order += 1 + table.maxReach/200 {
+			orders = append(orders, order)
+		}
+
+		got := table.minimalTotals(orders)
+		for i, order := range orders {
+			if want := table.minimalTotal(order); got[i] != want {
+				t.Fatalf("sizes=%v order=%d: minimalTotals = %d, want %d", sizes, order, got[i], want)
+			}
+		}
+	}
+}
+
+// reachable reports whether total is an exact sum of packs.
+func reachable(total int, sizes []int) bool {
+	ok := make([]bool, total+1)
+	ok[0] = true
+	for i := 1; i <= total; i++ {
+		for _, s := range sizes {
+			if s <= i && ok[i-s] {
+				ok[i] = true
+				break
+			}
+		}
+	}
+	return ok[total]
+}
@@ -0,0 +1,446 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"go-packing/internal/domain"
+)
+
+// maxCandidateNodes bounds the candidate search of custom rule chains unless
+// the input sets a smaller budget.
+const maxCandidateNodes = 200_000
+
+// candidate is one breakdown a rule chain can choose from.
+type candidate struct {
+	counts []int // packs per size, aligned with the ascending sizes
+	total  int
+	packs  int
+}
+
+// ruleChoice is the outcome of a rule chain over a pool of candidates.
+type ruleChoice struct {
+	sizes      []int
+	candidates []candidate
+	chain      []domain.Rule
+	winner     int
+	// decidedAt is the chain step that left only the winner, or len(chain) when
+	// the solver's own answer settled a tie no rule could break.
+	decidedAt int
+	// tied counts the candidates still in the running before the deciding step.
+	tied int
+	// lostAt is the chain step that eliminated each candidate; -1 for the winner.
+	lostAt []int
+	// truncated reports that the candidate search stopped after budget steps,
+	// so breakdowns the chain would prefer may be missing from the pool.
+	truncated bool
+	budget    int
+}
+
+// chooseByRules ranks the candidate pool, searched in at most budget steps, by
+// a rule chain. solved is the answer of the exact solver; it is always a
+// candidate and wins ties the rules leave open.
+func chooseByRules(order int, packSizes []int64, stock map[int64]int, rules []domain.Rule, solved []domain.PackBreakdown, budget int) *ruleChoice {
+	sizes := normalizePackSizes(packSizes)
+	first := newCandidate(solved, sizes)
+
+	candidates := []candidate{first}
+	pool, truncated := enumerateCandidates(order, sizes, stock, budget)
+	for _, c := range pool {
+		if !slices.Equal(c.counts, first.counts) {
+			candidates = append(candidates, c)
+		}
+	}
+
+	// Ties left after the chain fall back to the default chain, then larger packs.
+	chain := append([]domain.Rule(nil), rules...)
+	chain = append(chain, domain.DefaultRules...)
+
+	choice := &ruleChoice{sizes: sizes, candidates: candidates, chain: chain, lostAt: make([]int, len(candidates)), truncated: truncated, budget: budget}
+	remaining := make([]int, len(candidates))
+	for i := range remaining {
+		remaining[i] = i
+	}
+
+	for step, rule := range chain {
+		if len(remaining) == 1 {
+			break
+		}
+		choice.tied, choice.decidedAt = len(remaining), step
+		remaining = choice.applyRule(remaining, rule, order, step)
+	}
+	if len(remaining) > 1 {
+		choice.tied, choice.decidedAt = len(remaining), len(chain)
+		if slices.Contains(remaining, 0) {
+			remaining = choice.keep(remaining, []int{0}, len(chain))
+		} else {
+			choice.chain = append(choice.chain, domain.Rule{Criterion: domain.CriterionPreferLarge})
+			remaining = choice.applyRule(remaining, domain.Rule{Criterion: domain.CriterionPreferLarge}, order, len(chain))
+		}
+	}
+
+	choice.winner = remaining[0]
+	choice.lostAt[choice.winner] = -1
+
+	return choice
+}
+
+// enumerateCandidates lists non-redundant breakdowns: dropping any pack would
+// fall short of the order, so each overfills by less than its smallest pack.
+//
+// The largest unlimited size is the bulk and covers whatever the other sizes
+// leave. The other sizes are enumerated up to a window of a few largest packs,
+// which covers every breakdown of small orders and those with few non-bulk packs
+// otherwise. The search stops after budget steps and then reports the list as
+// truncated.
+func enumerateCandidates(order int, sizes []int, stock map[int64]int, budget int) ([]candidate, bool) {
+	maxPack := sizes[len(sizes)-1]
+
+	bulk := -1
+	limits := make([]int, len(sizes))
+	for i, s := range sizes {
+		available, ok := stock[int64(s)]
+		if !ok {
+			bulk, available = i, math.MaxInt
+		}
+		limits[i] = available
+	}
+
+	bound := order + maxPack - 1
+	if bulk >= 0 {
+		bound = min(bound, maxPack*max(2, len(sizes)-1))
+	}
+
+	candidates := make([]candidate, 0)
+	counts := make([]int, len(sizes))
+	nodes := 0
+
+	var walk func(i, sum int)
+	walk = func(i, sum int) {
+		nodes++
+		if nodes > budget {
+			return
+		}
+		// Once the order is covered, any further smaller pack would be redundant.
+		if i < 0 || sum >= order {
+			if c, ok := completeCandidate(order, sizes, counts, sum, bulk); ok {
+				candidates = append(candidates, c)
+			}
+			return
+		}
+		if i == bulk {
+			walk(i-1, sum)
+			return
+		}
+
+		for n := min(limits[i], (bound-sum)/sizes[i]); n >= 0; n-- {
+			counts[i] = n
+			walk(i-1, sum+n*sizes[i])
+		}
+		counts[i] = 0
+	}
+	walk(len(sizes)-1, 0)
+
+	return candidates, nodes > budget
+}
+
+// completeCandidate adds the bulk packs that counts still needs and keeps the
+// result only when it is non-redundant.
+func completeCandidate(order int, sizes, counts []int, sum, bulk int) (candidate, bool) {
+	c := candidate{counts: append([]int(nil), counts...), total: sum}
+	if sum < order {
+		if bulk < 0 {
+			return candidate{}, false
+		}
+		n := (order - sum + sizes[bulk] - 1) / sizes[bulk]
+		c.counts[bulk] = n
+		c.total += n * sizes[bulk]
+	}
+
+	smallest := 0
+	for i, n := range c.counts {
+		c.packs += n
+		if n > 0 && smallest == 0 {
+			smallest = sizes[i]
+		}
+	}
+	if smallest == 0 || c.total-smallest >= order {
+		return candidate{}, false
+	}
+
+	return c, true
+}
+
+// newCandidate converts a solver breakdown into a candidate over sizes.
+func newCandidate(packs []domain.PackBreakdown, sizes []int) candidate {
+	c := candidate{counts: make([]int, len(sizes))}
+	for _, p := range packs {
+		for i, s := range sizes {
+			if s == p.Size {
+				c.counts[i] = p.Count
+			}
+		}
+		c.total += p.Size * p.Count
+		c.packs += p.Count
+	}
+
+	return c
+}
+
+func (c candidate) breakdown(sizes []int) []domain.PackBreakdown {
+	counts := make(map[int]int, len(sizes))
+	for i, n := range c.counts {
+		counts[sizes[i]] = n
+	}
+
+	return toBreakdown(counts)
+}
+
+// applyRule keeps the candidates of remaining that rule accepts: those within
+// its tolerance of the best one. The others are marked as lost at step.
+func (r *ruleChoice) applyRule(remaining []int, rule domain.Rule, order, step int) []int {
+	kept := make([]int, 0, len(remaining))
+
+	switch rule.Criterion {
+	case domain.CriterionPreferLarge, domain.CriterionPreferSmall:
+		best := remaining[0]
+		for _, i := range remaining[1:] {
+			if comparePreference(r.candidates[i], r.candidates[best], rule.Criterion) < 0 {
+				best = i
+			}
+		}
+		for _, i := range remaining {
+			if comparePreference(r.candidates[i], r.candidates[best], rule.Criterion) == 0 {
+				kept = append(kept, i)
+			}
+		}
+	default:
+		best := math.MaxInt
+		for _, i := range remaining {
+			best = min(best, r.candidates[i].metric(rule.Criterion, order))
+		}
+		limit := saturatingAdd(best, ruleTolerance(rule, order))
+		for _, i := range remaining {
+			// The best candidate always passes, so kept is never empty.
+			if metric := r.candidates[i].metric(rule.Criterion, order); metric == best || metric <= limit {
+				kept = append(kept, i)
+			}
+		}
+	}
+
+	return r.keep(remaining, kept, step)
+}
+
+// ruleTolerance is the margin rule allows above the best candidate, saturating
+// at math.MaxInt instead of overflowing.
+func ruleTolerance(rule domain.Rule, order int) int {
+	pct := rule.TolerancePct * float64(order) / 100
+	if !(pct < math.MaxInt) {
+		return math.MaxInt
+	}
+
+	return saturatingAdd(max(rule.Tolerance, 0), max(int(pct), 0))
+}
+
+// saturatingAdd returns a+b for non-negative b, or math.MaxInt when that overflows.
+func saturatingAdd(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+
+	return a + b
+}
+
+// keep marks every candidate of remaining missing from kept as lost at step;
+// a negative step records nothing. kept must preserve the order of remaining.
+func (r *ruleChoice) keep(remaining, kept []int, step int) []int {
+	if step < 0 {
+		return kept
+	}
+
+	k := 0
+	for _, i := range remaining {
+		if k < len(kept) && kept[k] == i {
+			k++
+			continue
+		}
+		r.lostAt[i] = step
+	}
+
+	return kept
+}
+
+// top returns up to k candidates in rank order, the winner first. Each next one
+// is what the chain chooses once the better ones are gone.
+func (r *ruleChoice) top(order, k int) []int {
+	ranked := []int{r.winner}
+	taken := make([]bool, len(r.candidates))
+	taken[r.winner] = true
+
+	chain := append(slices.Clip(r.chain), domain.Rule{Criterion: domain.CriterionPreferLarge})
+	for len(ranked) < min(k, len(r.candidates)) {
+		remaining := make([]int, 0, len(r.candidates)-len(ranked))
+		for i, done := range taken {
+			if !done {
+				remaining = append(remaining, i)
+			}
+		}
+		for _, rule := range chain {
+			if len(remaining) == 1 {
+				break
+			}
+			remaining = r.applyRule(remaining, rule, order, -1)
+		}
+
+		ranked = append(ranked, remaining[0])
+		taken[remaining[0]] = true
+	}
+
+	return ranked
+}
+
+// decidedBy names what settled the choice: a criterion, "solver" for ties no
+// rule could break, or "only_candidate".
+func (r *ruleChoice) decidedBy() string {
+	switch {
+	case len(r.candidates) == 1:
+		return "only_candidate"
+	case r.decidedAt == len(r.chain):
+		return "solver"
+	default:
+		return string(r.chain[r.decidedAt].Criterion)
+	}
+}
+
+// explanation is a one-sentence account of why the winner was chosen, plus a
+// warning when the candidate search was truncated.
+func (r *ruleChoice) explanation() string {
+	summary := r.decision()
+	if r.truncated {
+		summary += fmt.Sprintf(" The candidate search stopped after %d steps, so a breakdown the rules prefer may have been missed.", r.budget)
+	}
+
+	return summary
+}
+
+// decision says why the winner was chosen.
+func (r *ruleChoice) decision() string {
+	switch decidedBy := r.decidedBy(); decidedBy {
+	case "only_candidate":
+		return "The only breakdown that meets the amount without a pack to spare."
+	case "solver":
+		return fmt.Sprintf("%d breakdowns tied on every rule; the solver's choice was kept.", r.tied)
+	default:
+		criterion := r.chain[r.decidedAt].Criterion
+		if r.decidedAt == 0 {
+			return fmt.Sprintf("%s decided among %d candidate breakdowns: this one has the %s.", decidedBy, r.tied, criterionGoal(criterion))
+		}
+		return fmt.Sprintf("%d breakdowns tied on %s; %s decided: this one has the %s.", r.tied, r.tiedOn(), decidedBy, criterionGoal(criterion))
+	}
+}
+
+// tiedOn lists the distinct criteria applied before the deciding step.
+func (r *ruleChoice) tiedOn() string {
+	names := make([]string, 0, r.decidedAt)
+	for _, rule := range r.chain[:r.decidedAt] {
+		if !slices.Contains(names, string(rule.Criterion)) {
+			names = append(names, string(rule.Criterion))
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// runnersUp returns up to limit losing candidates, those that lasted longest first.
+func (r *ruleChoice) runnersUp(order, limit int) []Alternative {
+	losers := make([]int, 0, len(r.candidates)-1)
+	for i := range r.candidates {
+		if i != r.winner {
+			losers = append(losers, i)
+		}
+	}
+	sort.SliceStable(losers, func(x, y int) bool {
+		a, b := losers[x], losers[y]
+		if r.lostAt[a] != r.lostAt[b] {
+			return r.lostAt[a] > r.lostAt[b]
+		}
+		if step := r.lostAt[a]; step < len(r.chain) {
+			criterion := r.chain[step].Criterion
+			if criterion == domain.CriterionPreferLarge || criterion == domain.CriterionPreferSmall {
+				return comparePreference(r.candidates[a], r.candidates[b], criterion) < 0
+			}
+			return r.candidates[a].metric(criterion, order) < r.candidates[b].metric(criterion, order)
+		}
+		return false
+	})
+
+	alternatives := make([]Alternative, 0, min(limit, len(losers)))
+	for _, i := range losers[:min(limit, len(losers))] {
+		lostOn := "solver"
+		if r.lostAt[i] < len(r.chain) {
+			lostOn = string(r.chain[r.lostAt[i]].Criterion)
+		}
+		alternatives = append(alternatives, newAlternative(r.candidates[i].breakdown(r.sizes), order, lostOn))
+	}
+
+	return alternatives
+}
+
+// criterionGoal describes what a criterion looks for.
+func criterionGoal(criterion domain.Criterion) string {
+	switch criterion {
+	case domain.CriterionOverfill:
+		return "least overfill"
+	case domain.CriterionPackCount:
+		return "fewest packs"
+	case domain.CriterionDistinctSizes:
+		return "fewest distinct sizes"
+	case domain.CriterionPreferLarge:
+		return "most large packs"
+	case domain.CriterionPreferSmall:
+		return "most small packs"
+	default:
+		return string(criterion)
+	}
+}
+
+// metric returns the value a scalar criterion minimizes.
+func (c candidate) metric(criterion domain.Criterion, order int) int {
+	switch criterion {
+	case domain.CriterionOverfill:
+		return c.total - order
+	case domain.CriterionPackCount:
+		return c.packs
+	case domain.CriterionDistinctSizes:
+		distinct := 0
+		for _, n := range c.counts {
+			if n > 0 {
+				distinct++
+			}
+		}
+		return distinct
+	default:
+		return 0
+	}
+}
+
+// comparePreference orders two candidates by pack counts, starting from the
+// largest size for prefer_large and the smallest for prefer_small; more packs
+// of the favored size win. It returns a negative value when a is preferred.
+func comparePreference(a, b candidate, criterion domain.Criterion) int {
+	n := len(a.counts)
+	for k := 0; k < n; k++ {
+		i := k
+		if criterion == domain.CriterionPreferLarge {
+			i = n - 1 - k
+		}
+		if a.counts[i] != b.counts[i] {
+			return b.counts[i] - a.counts[i]
+		}
+	}
+
+	return 0
+}
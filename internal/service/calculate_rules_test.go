@@ -0,0 +1,227 @@
+package service
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-packing/internal/domain"
+)
+
+// solveWithRules solves an order against a config of sizes through the rule chain path.
+func solveWithRules(t *testing.T, order int, sizes []int64, stock map[int64]int, rules []domain.Rule) []domain.PackBreakdown {
+	t.Helper()
+	cfg, err := domain.NewPackConfig(sizes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cache *SolverCache
+	result, err := solveConfig(cfg, CalculateInput{Amount: order, StockLimits: stock, Rules: rules}, cache.table)
+	if err != nil {
+		t.Fatalf("order=%d sizes=%v rules=%v: unexpected error: %v", order, sizes, rules, err)
+	}
+
+	return result.Packs
+}
+
+func TestSolveConfig_Rules(t *testing.T) {
+	defaultSizes := []int64{250, 500, 1000, 2000, 5000}
+
+	tests := []struct {
+		name  string
+		order int
+		sizes []int64
+		stock map[int64]int
+		rules []domain.Rule
+		want  []domain.PackBreakdown
+	}{
+		{
+			name:  "default chain",
+			order: 12001,
+			sizes: defaultSizes,
+			rules: domain.DefaultRules,
+			want:  []domain.PackBreakdown{{Size: 5000, Count: 2}, {Size: 2000, Count: 1}, {Size: 250, Count: 1}},
+		},
+		{
+			name:  "fewest packs first",
+			order: 12001,
+			sizes: defaultSizes,
+			rules: []domain.Rule{{Criterion: domain.CriterionPackCount}},
+			want:  []domain.PackBreakdown{{Size: 5000, Count: 3}},
+		},
+		{
+			name:  "fewest packs when overfill is within 2%",
+			order: 263,
+			sizes: []int64{23, 31, 53},
+			rules: []domain.Rule{{Criterion: domain.CriterionOverfill, TolerancePct: 2}, {Criterion: domain.CriterionPackCount}},
+			want:  []domain.PackBreakdown{{Size: 53, Count: 5}},
+		},
+		{
+			name:  "tolerance too small to matter",
+			order: 263,
+			sizes: []int64{23, 31, 53},
+			rules: []domain.Rule{{Criterion: domain.CriterionOverfill, Tolerance: 1}, {Criterion: domain.CriterionPackCount}},
+			want:  []domain.PackBreakdown{{Size: 31, Count: 7}, {Size: 23, Count: 2}},
+		},
+		{
+			name:  "fewest distinct sizes",
+			order: 1251,
+			sizes: []int64{250, 500, 1000},
+			rules: []domain.Rule{{Criterion: domain.CriterionDistinctSizes}},
+			want:  []domain.PackBreakdown{{Size: 500, Count: 3}},
+		},
+		{
+			name:  "prefer small packs",
+			order: 1251,
+			sizes: []int64{250, 500, 1000},
+			rules: []domain.Rule{{Criterion: domain.CriterionOverfill}, {Criterion: domain.CriterionPreferSmall}},
+			want:  []domain.PackBreakdown{{Size: 250, Count: 6}},
+		},
+		{
+			name:  "prefer large packs",
+			order: 1250,
+			sizes: []int64{250, 500, 1000},
+			rules: []domain.Rule{{Criterion: domain.CriterionPreferLarge}},
+			want:  []domain.PackBreakdown{{Size: 1000, Count: 2}},
+		},
+		{
+			name:  "respects stock",
+			order: 12001,
+			sizes: defaultSizes,
+			stock: map[int64]int{5000: 2},
+			rules: []domain.Rule{{Criterion: domain.CriterionPackCount}},
+			want:  []domain.PackBreakdown{{Size: 5000, Count: 2}, {Size: 2000, Count: 1}, {Size: 250, Count: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		got := solveWithRules(t, tt.order, tt.sizes, tt.stock, tt.rules)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSolveConfig_RulesNonRedundant(t *testing.T) {
+	sizes := []int64{3, 7, 11}
+	chains := [][]domain.Rule{
+		{{Criterion: domain.CriterionPackCount}},
+		{{Criterion: domain.CriterionDistinctSizes}, {Criterion: domain.CriterionPreferSmall}},
+		{{Criterion: domain.CriterionPreferLarge}},
+		{{Criterion: domain.CriterionOverfill, Tolerance: 3}, {Criterion: domain.CriterionPackCount}},
+	}
+
+	for order := 1; order <= 200; order++ {
+		for _, rules := range chains {
+			got := solveWithRules(t, order, sizes, nil, rules)
+
+			total, _ := breakdownTotals(got)
+			smallest := got[len(got)-1].Size
+			if total < order || total-smallest >= order {
+				t.Fatalf("order=%d rules=%v: %v is short or has a redundant pack", order, rules, got)
+			}
+		}
+	}
+}
+
+func TestChooseByRules_Explains(t *testing.T) {
+	sizes := []int64{250, 500, 1000, 2000, 5000}
+	solved, err := newPackTable(normalizePackSizes(sizes)).calculate(501)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	choice := chooseByRules(501, sizes, nil, domain.DefaultRules, solved, maxCandidateNodes)
+	if got := choice.candidates[choice.winner].breakdown(choice.sizes); !reflect.DeepEqual(got, solved) {
+		t.Fatalf("winner %v, want the solver answer %v", got, solved)
+	}
+	if got := choice.decidedBy(); got != "pack_count" {
+		t.Fatalf("decidedBy = %q, want pack_count", got)
+	}
+	if got, want := choice.explanation(), "2 breakdowns tied on overfill; pack_count decided: this one has the fewest packs."; got != want {
+		t.Fatalf("explanation = %q, want %q", got, want)
+	}
+
+	runnersUp := choice.runnersUp(501, 2)
+	want := []Alternative{
+		{Packs: []domain.PackBreakdown{{Size: 250, Count: 3}}, Total: 750, Overfill: 249, PackCount: 3, LostOn: "pack_count"},
+		{Packs: []domain.PackBreakdown{{Size: 1000, Count: 1}}, Total: 1000, Overfill: 499, PackCount: 1, LostOn: "overfill"},
+	}
+	if !reflect.DeepEqual(runnersUp, want) {
+		t.Fatalf("runnersUp = %+v, want %+v", runnersUp, want)
+	}
+}
+
+func TestChooseByRules_Top(t *testing.T) {
+	sizes := []int64{1, 250, 500}
+	solved, err := newPackTable(normalizePackSizes(sizes)).calculate(501)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	choice := chooseByRules(501, sizes, nil, domain.DefaultRules, solved, maxCandidateNodes)
+	var got [][]domain.PackBreakdown
+	for _, i := range choice.top(501, 3) {
+		got = append(got, choice.candidates[i].breakdown(choice.sizes))
+	}
+
+	want := [][]domain.PackBreakdown{
+		{{Size: 500, Count: 1}, {Size: 1, Count: 1}},
+		{{Size: 250, Count: 2}, {Size: 1, Count: 1}},
+		{{Size: 250, Count: 1}, {Size: 1, Count: 251}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("top = %v, want %v", got, want)
+	}
+}
+
+func TestSolveConfig_CandidateSearchTruncated(t *testing.T) {
+	var cache *SolverCache
+	rules := []domain.Rule{{Criterion: domain.CriterionDistinctSizes}}
+
+	// Many small sizes below one large one make the search give up long before
+	// it has seen every breakdown.
+	crowded, _ := domain.NewPackConfig([]int64{3, 7, 11, 13, 17, 200})
+	result, err := solveConfig(crowded, CalculateInput{Amount: 100_000, Rules: rules, Explain: true}, cache.table)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.CandidatesTruncated || !strings.Contains(result.Explanation, "candidate search stopped after 200000 steps") {
+		t.Fatalf("truncated = %v, explanation = %q", result.CandidatesTruncated, result.Explanation)
+	}
+
+	defaults, _ := domain.NewPackConfig([]int64{250, 500, 1000, 2000, 5000})
+	result, err = solveConfig(defaults, CalculateInput{Amount: 100_000, Rules: rules, Explain: true}, cache.table)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.CandidatesTruncated || strings.Contains(result.Explanation, "stopped") {
+		t.Fatalf("default sizes: truncated = %v, explanation = %q", result.CandidatesTruncated, result.Explanation)
+	}
+}
+
+func TestSolveConfig_RulesHugeTolerance(t *testing.T) {
+	// Chains stored before tolerances were capped must not overflow the filter:
+	// a huge tolerance keeps every candidate in the running.
+	fewest := []domain.PackBreakdown{{Size: 5000, Count: 3}}
+	tests := []struct {
+		rules []domain.Rule
+		want  []domain.PackBreakdown
+	}{
+		{rules: []domain.Rule{{Criterion: domain.CriterionOverfill, Tolerance: math.MaxInt}, {Criterion: domain.CriterionPackCount}}, want: fewest},
+		{rules: []domain.Rule{{Criterion: domain.CriterionOverfill, TolerancePct: 1e300}, {Criterion: domain.CriterionPackCount}}, want: fewest},
+		{
+			rules: []domain.Rule{{Criterion: domain.CriterionPackCount, Tolerance: math.MaxInt}},
+			want:  []domain.PackBreakdown{{Size: 5000, Count: 2}, {Size: 2000, Count: 1}, {Size: 250, Count: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		got := solveWithRules(t, 12001, []int64{250, 500, 1000, 2000, 5000}, nil, tt.rules)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("rules=%v: got %v, want %v", tt.rules, got, tt.want)
+		}
+	}
+}
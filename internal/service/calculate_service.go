@@ -2,109 +2,432 @@ package service
 
 import (
 	"context"
-	"math"
-	"sort"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
 
 	"go-packing/internal/domain"
 )
 
+// Solver names reported with each calculation.
+const (
+	SolverResidue         = "residue"
+	SolverBoundedKnapsack = "bounded_knapsack"
+	SolverMinCost         = "min_cost"
+	SolverRuleChain       = "rule_chain"
+)
+
+// maxRunnersUp caps how many losing candidates an explanation lists.
+const maxRunnersUp = 5
+
+// MaxAlternatives caps how many ranked breakdowns one calculation returns.
+const MaxAlternatives = 10
+
 type CalculateService struct {
-	repo domain.PackConfigsRepository
+	repo         domain.PackConfigsRepository
+	cache        *SolverCache
+	batchWorkers int
+	limits       domain.PackSizeLimits
+	history      domain.CalculationsRepository
 }
 
-// NewCalculateService creates a calculation service backed by pack configuration storage.
-func NewCalculateService(repo domain.PackConfigsRepository) *CalculateService {
-	return &CalculateService{repo: repo}
+// CalculateInput describes one calculation request.
+type CalculateInput struct {
+	Amount int
+	// SKU selects the product configuration; empty means the default profile.
+	SKU string
+	// StockLimits overrides the stored availability of individual pack sizes.
+	StockLimits map[int64]int
+	// Objective selects the optimization; empty means domain.ObjectiveMinOverfill.
+	Objective domain.Objective
+	// Rules overrides the rule chain stored on the config. The min_cost objective
+	// ranks by cost and ignores the chain.
+	Rules []domain.Rule
+	// Explain fills DecidedBy and Explanation of the result; RunnersUp also lists
+	// the candidates that lost.
+	Explain   bool
+	RunnersUp bool
+	// TopK asks for the K best distinct breakdowns, up to MaxAlternatives.
+	TopK int
+	// AsOf selects the config version that applies at that time; zero means now.
+	AsOf time.Time
+	// CandidateNodes caps the candidate search behind custom rule chains,
+	// explanations and alternatives; zero means maxCandidateNodes.
+	CandidateNodes int
+}
+
+// CalculateResult is a chosen breakdown; Cost is set for the min_cost objective.
+type CalculateResult struct {
+	Packs []domain.PackBreakdown
+	Cost  *domain.CostBreakdown
+	// ConfigVersion is the pack config version the calculation used.
+	ConfigVersion int64
+	Solver        string
+	// Rules is the chain that ranked the candidates; empty for min_cost.
+	Rules []domain.Rule
+	// DecidedBy names the criterion that settled the choice, "cost" for min_cost.
+	DecidedBy   string
+	Explanation string
+	RunnersUp   []Alternative
+	// Alternatives holds the TopK best breakdowns in rank order, Packs first.
+	Alternatives []Alternative
+	// CandidatesTruncated reports that the candidate search behind a custom rule
+	// chain, the explanation or the alternatives stopped at its limit, so they
+	// come from an incomplete pool.
+	CandidatesTruncated bool
+	// SizeDetails is the metadata of the config sizes, keyed by size; sizes
+	// without an entry carry none. It is shared with the config and read-only.
+	SizeDetails map[int64]domain.PackSize
+	// CalculationID identifies the stored calculation; 0 when history is off.
+	CalculationID int64
 }
 
-// Calculate returns an optimal pack breakdown for the requested amount.
-func (s *CalculateService) Calculate(ctx context.Context, amount int) ([]domain.PackBreakdown, error) {
-	if amount <= 0 {
-		return nil, domain.ErrInvalidAmount
+// Alternative is a breakdown that was considered but not chosen.
+type Alternative struct {
+	Packs     []domain.PackBreakdown
+	Total     int
+	Overfill  int
+	PackCount int
+	// LostOn names the criterion that eliminated this breakdown.
+	LostOn string
+}
+
+// BatchItemResult is the outcome of one batch item: either Result or Err is set.
+type BatchItemResult struct {
+	Result *CalculateResult
+	Err    error
+}
+
+// NewCalculateService creates a calculation service backed by pack configuration storage.
+// cache may be nil to disable table caching. batchWorkers bounds the parallelism
+// of CalculateBatch; zero or less means one per CPU. Simulated candidate sizes
+// must stay within limits, like stored ones. Every calculation is stored in
+// history, which may be nil to keep none.
+func NewCalculateService(repo domain.PackConfigsRepository, cache *SolverCache, batchWorkers int, limits domain.PackSizeLimits, history domain.CalculationsRepository) *CalculateService {
+	if batchWorkers <= 0 {
+		batchWorkers = runtime.NumCPU()
 	}
 
-	cfg, err := s.repo.Get(ctx)
+	return &CalculateService{repo: repo, cache: cache, batchWorkers: batchWorkers, limits: limits, history: history}
+}
+
+// Calculate returns an optimal pack breakdown for the requested amount and
+// stores it in history.
+func (s *CalculateService) Calculate(ctx context.Context, in CalculateInput) (*CalculateResult, error) {
+	started := time.Now()
+	in, err := normalizeCalculateInput(in)
 	if err != nil {
 		return nil, err
 	}
-	if cfg == nil || len(cfg.PackSizes) == 0 {
-		return nil, domain.ErrPackSizesNotConfigured
+	if in.AsOf.IsZero() {
+		in.AsOf = started.UTC()
 	}
 
-	result, err := calculate(amount, cfg.PackSizes)
+	cfg, err := s.loadConfig(ctx, in.SKU, in.AsOf)
 	if err != nil {
 		return nil, err
 	}
 
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].Size > result[j].Size
-	})
+	result, err := s.solve(cfg, in)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.record(ctx, []CalculateInput{in}, []*CalculateResult{result}, []time.Duration{time.Since(started)}); err != nil {
+		return nil, err
+	}
 
 	return result, nil
 }
 
-// calculate finds a pack combination that minimizes total shipped quantity,
-// and among those, minimizes the number of packs.
-func calculate(order int, packSizes []int64) ([]domain.PackBreakdown, error) {
-	// Convert pack sizes to int and track the maximum size
-	sizes := make([]int, len(packSizes))
-	maxPack := 0
-	for i, p := range packSizes {
-		sizes[i] = int(p)
-		maxPack = max(maxPack, sizes[i])
-	}
+// configKey identifies a config lookup of a batch: a SKU at a point in time.
+type configKey struct {
+	sku string
+	at  time.Time
+}
 
-	// Allow overfilling up to the largest pack size
-	limit := order + maxPack
+// CalculateBatch solves many inputs at once. Each SKU config is loaded once per
+// AsOf and items are solved on at most batchWorkers goroutines. Failures are
+// reported per item, in input order, and never fail the whole batch, not even
+// a panic while solving one item. Solved
+// items are stored in history together; when that fails, they all fail.
+func (s *CalculateService) CalculateBatch(ctx context.Context, items []CalculateInput) []BatchItemResult {
+	results := make([]BatchItemResult, len(items))
+	inputs := make([]CalculateInput, len(items))
+	durations := make([]time.Duration, len(items))
+	configs := make(map[configKey]*domain.PackConfig)
+	configErrs := make(map[configKey]error)
+	// Items without AsOf share one "now", so the whole batch sees one version.
+	now := time.Now().UTC()
 
-	// dp[i] = minimum number of packs needed to reach sum i
-	// parent[i] = pack size last used to reach sum i
-	dp := make([]int, limit+1)
-	parent := make([]int, limit+1)
+	for i, item := range items {
+		in, err := normalizeCalculateInput(item)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if in.AsOf.IsZero() {
+			in.AsOf = now
+		}
+		inputs[i] = in
 
-	for i := 1; i <= limit; i++ {
-		dp[i] = math.MaxInt32
+		key := configKey{sku: in.SKU, at: in.AsOf}
+		if _, loaded := configErrs[key]; !loaded {
+			configs[key], configErrs[key] = s.loadConfig(ctx, in.SKU, in.AsOf)
+		}
+		results[i].Err = configErrs[key]
 	}
 
-	// Unbounded knapsack: minimize pack count for each achievable sum
-	for _, s := range sizes {
-		for i := s; i <= limit; i++ {
-			// Rule #3: If using this pack reduces the total number of packs, update.
-			if dp[i-s] != math.MaxInt32 && dp[i-s]+1 < dp[i] {
-				dp[i] = dp[i-s] + 1
-				parent[i] = s
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(s.batchWorkers, len(items)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				started := time.Now()
+				results[i].Result, results[i].Err = s.solveRecovered(configs[configKey{sku: inputs[i].SKU, at: inputs[i].AsOf}], inputs[i])
+				durations[i] = time.Since(started)
 			}
+		}()
+	}
+	for i := range items {
+		if results[i].Err == nil {
+			jobs <- i
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	solved := make([]int, 0, len(items))
+	for i := range results {
+		if results[i].Err == nil {
+			solved = append(solved, i)
+		}
+	}
+	recordInputs := make([]CalculateInput, len(solved))
+	recordResults := make([]*CalculateResult, len(solved))
+	recordDurations := make([]time.Duration, len(solved))
+	for j, i := range solved {
+		recordInputs[j], recordResults[j], recordDurations[j] = inputs[i], results[i].Result, durations[i]
+	}
+	if err := s.record(ctx, recordInputs, recordResults, recordDurations); err != nil {
+		for _, i := range solved {
+			results[i] = BatchItemResult{Err: err}
+		}
+	}
+
+	return results
+}
+
+// normalizeCalculateInput validates the request part of an input and returns it
+// with a normalized SKU, objective and AsOf.
+func normalizeCalculateInput(in CalculateInput) (CalculateInput, error) {
+	if err := domain.ValidateAmount(in.Amount); err != nil {
+		return in, err
+	}
+
+	objective, err := domain.ParseObjective(string(in.Objective))
+	if err != nil {
+		return in, err
+	}
+	in.Objective = objective
+
+	if err := domain.ValidateRules(in.Rules); err != nil {
+		return in, err
+	}
+
+	in.SKU, err = domain.NormalizeSKU(in.SKU)
+	if err != nil {
+		return in, err
+	}
+	// Map keys compare time.Time by location too.
+	in.AsOf = in.AsOf.UTC()
+
+	return in, nil
+}
+
+// loadConfig returns the config version of a SKU that applies at a time; zero means now.
+func (s *CalculateService) loadConfig(ctx context.Context, sku string, at time.Time) (*domain.PackConfig, error) {
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	cfg, err := s.repo.GetActive(ctx, sku, at)
+	if err != nil {
+		return nil, err
+	}
+	// Drafts never reach calculations, whatever a repository returns.
+	if cfg == nil || cfg.Status != domain.StatusPublished || len(cfg.ActiveSizes()) == 0 {
+		return nil, domain.ErrPackSizesNotConfigured
+	}
+
+	return cfg, nil
+}
+
+// solve calculates a normalized input against a loaded config. It only reads
+// cfg, so batch workers share one config.
+func (s *CalculateService) solve(cfg *domain.PackConfig, in CalculateInput) (*CalculateResult, error) {
+	return solveConfig(cfg, in, s.cache.table)
+}
+
+// solveRecovered is solve for goroutines outside the request, which
+// gin.Recovery does not cover: a panic becomes the error of the one input
+// instead of taking down the process. The error goes into batch items and
+// replay reports, so it names the panic but carries no stack.
+func (s *CalculateService) solveRecovered(cfg *domain.PackConfig, in CalculateInput) (result *CalculateResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("solve amount %d: panic: %v", in.Amount, r)
 		}
+	}()
+
+	return s.solve(cfg, in)
+}
+
+// solveConfig is solve with the residue table of cfg taken from table, so
+// configs that are never stored can be solved without touching the cache.
+func solveConfig(cfg *domain.PackConfig, in CalculateInput, table func(*domain.PackConfig) *packTable) (*CalculateResult, error) {
+	stock, err := mergeStockLimits(cfg, in.StockLimits)
+	if err != nil {
+		return nil, err
 	}
+	// Inactive sizes stay configured, with their limits and costs, but are never picked.
+	sizes := cfg.ActiveSizes()
 
-	// Rule #2: Choose the smallest reachable sum >= order (minimal overfill)
-	bestSum := -1
-	for i := order; i <= limit; i++ {
-		if dp[i] != math.MaxInt32 {
-			bestSum = i
-			break
+	if in.Objective == domain.ObjectiveMinCost {
+		if !cfg.HasCosts() {
+			return nil, domain.ErrPackCostsNotConfigured
 		}
+
+		model := newCostModel(cfg.PackCosts, cfg.OverfillItemCost)
+		packs, err := solveWindow(in.Amount, sizes, stock, model)
+		if err != nil {
+			return nil, err
+		}
+
+		cost := cfg.CostBreakdown(packs, shippedTotal(packs)-in.Amount)
+		result := &CalculateResult{Packs: packs, Cost: &cost, ConfigVersion: cfg.Version, Solver: SolverMinCost, SizeDetails: cfg.Packs}
+		if in.Explain {
+			result.DecidedBy = "cost"
+			result.Explanation = "The cheapest breakdown including overfill cost."
+		}
+		if in.RunnersUp || in.TopK > 1 {
+			others, truncated := costRunnersUp(in.Amount, sizes, stock, model, packs, max(maxRunnersUp, in.TopK), candidateBudget(in))
+			result.CandidatesTruncated = truncated
+			if in.RunnersUp {
+				result.RunnersUp = others[:min(maxRunnersUp, len(others))]
+			}
+			result.Alternatives = rankedAlternatives(packs, in.Amount, others, in.TopK)
+		}
+		return result, nil
 	}
 
-	if bestSum == -1 {
-		return nil, domain.ErrCouldNotCalculate
+	rules := in.Rules
+	if len(rules) == 0 {
+		rules = cfg.EffectiveRules()
 	}
 
-	// Reconstruct pack selection
-	counts := make(map[int]int)
-	curr := bestSum
-	for curr > 0 {
-		p := parent[curr]
-		counts[p]++
-		curr -= p
+	result := &CalculateResult{ConfigVersion: cfg.Version, Solver: SolverResidue, Rules: rules, SizeDetails: cfg.Packs}
+	if isLimited(sizes, stock) {
+		result.Solver = SolverBoundedKnapsack
+		result.Packs, err = solveWindow(in.Amount, sizes, stock, nil)
+	} else {
+		result.Packs, err = table(cfg).calculate(in.Amount)
 	}
+	if err != nil {
+		return nil, err
+	}
+	packs := result.Packs
 
-	result := make([]domain.PackBreakdown, 0, len(counts))
-	for size, count := range counts {
-		result = append(result, domain.PackBreakdown{Size: size, Count: count})
+	// The default chain is what the solvers compute; the pool is only needed to explain it.
+	custom := !domain.IsDefaultRules(rules)
+	if !custom && !in.Explain && !in.RunnersUp && in.TopK <= 1 {
+		result.Alternatives = rankedAlternatives(packs, in.Amount, nil, in.TopK)
+		return result, nil
+	}
+
+	choice := chooseByRules(in.Amount, sizes, stock, rules, packs, candidateBudget(in))
+	result.CandidatesTruncated = choice.truncated
+	if custom {
+		result.Packs = choice.candidates[choice.winner].breakdown(choice.sizes)
+		result.Solver = SolverRuleChain
+	}
+	if in.Explain {
+		result.DecidedBy, result.Explanation = choice.decidedBy(), choice.explanation()
+	}
+	if in.RunnersUp {
+		result.RunnersUp = choice.runnersUp(in.Amount, maxRunnersUp)
+	}
+	if in.TopK > 0 {
+		for _, i := range choice.top(in.Amount, min(in.TopK, MaxAlternatives)) {
+			result.Alternatives = append(result.Alternatives, newAlternative(choice.candidates[i].breakdown(choice.sizes), in.Amount, ""))
+		}
 	}
 
-	// Time complexity: O((order + maxPack) * len(packSizes))
-	// Space complexity: O(order + maxPack)
 	return result, nil
 }
+
+// candidateBudget is how many steps the candidate search of in may take.
+func candidateBudget(in CalculateInput) int {
+	if in.CandidateNodes > 0 {
+		return min(in.CandidateNodes, maxCandidateNodes)
+	}
+	return maxCandidateNodes
+}
+
+// rankedAlternatives lists the chosen packs and then the best others, k in total.
+func rankedAlternatives(packs []domain.PackBreakdown, amount int, others []Alternative, k int) []Alternative {
+	k = min(k, MaxAlternatives)
+	if k <= 0 {
+		return nil
+	}
+
+	ranked := []Alternative{newAlternative(packs, amount, "")}
+	for _, alt := range others[:min(k-1, len(others))] {
+		alt.LostOn = ""
+		ranked = append(ranked, alt)
+	}
+
+	return ranked
+}
+
+func newAlternative(packs []domain.PackBreakdown, amount int, lostOn string) Alternative {
+	total := shippedTotal(packs)
+	count := 0
+	for _, p := range packs {
+		count += p.Count
+	}
+
+	return Alternative{Packs: packs, Total: total, Overfill: total - amount, PackCount: count, LostOn: lostOn}
+}
+
+// mergeStockLimits overlays per-request limits on the stored ones.
+func mergeStockLimits(cfg *domain.PackConfig, overrides map[int64]int) (map[int64]int, error) {
+	if err := cfg.ValidateStockLimits(overrides); err != nil {
+		return nil, err
+	}
+
+	stock := make(map[int64]int, len(cfg.StockLimits)+len(overrides))
+	for size, available := range cfg.StockLimits {
+		stock[size] = available
+	}
+	for size, available := range overrides {
+		stock[size] = available
+	}
+
+	return stock, nil
+}
+
+func shippedTotal(packs []domain.PackBreakdown) int {
+	total := 0
+	for _, p := range packs {
+		total += p.Size * p.Count
+	}
+
+	return total
+}
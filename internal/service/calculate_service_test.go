@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+// stubConfigRepo serves fixed configs and counts reads; other methods are unused.
+// A SKU with versions resolves GetActive from them, otherwise from configs.
+type stubConfigRepo struct {
+	domain.PackConfigsRepository
+
+	mu       sync.Mutex
+	configs  map[string]*domain.PackConfig
+	versions map[string][]domain.PackConfig
+	gets     int
+}
+
+func (r *stubConfigRepo) Get(_ context.Context, sku string) (*domain.PackConfig, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.gets++
+	return r.configs[sku], nil
+}
+
+func (r *stubConfigRepo) GetActive(_ context.Context, sku string, at time.Time) (*domain.PackConfig, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.gets++
+	versions, ok := r.versions[sku]
+	if !ok {
+		return r.configs[sku], nil
+	}
+
+	var active *domain.PackConfig
+	for i := range versions {
+		v := &versions[i]
+		if v.EffectiveAt(at) && (active == nil || !v.EffectiveStart().Before(active.EffectiveStart())) {
+			active = v
+		}
+	}
+	return active, nil
+}
+
+func (r *stubConfigRepo) ListActivations(_ context.Context, from, to time.Time) ([]domain.PackConfig, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var started []domain.PackConfig
+	for _, versions := range r.versions {
+		for _, v := range versions {
+			if v.EffectiveFrom != nil && v.EffectiveFrom.After(from) && !v.EffectiveFrom.After(to) {
+				started = append(started, v)
+			}
+		}
+	}
+	return started, nil
+}
+
+func TestCalculateBatch(t *testing.T) {
+	widget, _ := domain.NewPackConfig([]int64{23, 31, 53})
+	widget.SKU = "widget"
+	defaultCfg, _ := domain.NewPackConfig([]int64{250, 500, 1000, 2000, 5000})
+	repo := &stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: defaultCfg, "widget": widget}}
+	svc := NewCalculateService(repo, NewSolverCache(1<<20), 2, domain.PackSizeLimits{}, nil)
+
+	items := []CalculateInput{
+		{Amount: 251},
+		{Amount: 0},
+		{Amount: 263, SKU: "widget"},
+		{Amount: 12001},
+		{Amount: 10, SKU: "missing"},
+		{Amount: 501, SKU: "widget"},
+	}
+	results := svc.CalculateBatch(context.Background(), items)
+
+	if len(results) != len(items) {
+		t.Fatalf("got %d results, want %d", len(results), len(items))
+	}
+	if repo.gets != 3 {
+		t.Fatalf("repo.Get called %d times, want once per SKU", repo.gets)
+	}
+
+	wantErrs := []error{nil, domain.ErrInvalidAmount, nil, nil, domain.ErrPackSizesNotConfigured, nil}
+	for i, result := range results {
+		if !errors.Is(result.Err, wantErrs[i]) {
+			t.Fatalf("item %d: error = %v, want %v", i, result.Err, wantErrs[i])
+		}
+		if result.Err != nil {
+			continue
+		}
+
+		want, err := svc.Calculate(context.Background(), items[i])
+		if err != nil {
+			t.Fatalf("item %d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(result.Result.Packs, want.Packs) {
+			t.Fatalf("item %d: got %v, want %v", i, result.Result.Packs, want.Packs)
+		}
+	}
+}
+
+func TestCalculateBatch_RecoversPanics(t *testing.T) {
+	defaultCfg, _ := domain.NewPackConfig([]int64{250, 500, 1000, 2000, 5000})
+	// A corrupt stored config whose sizes normalize to nothing makes solve panic.
+	broken := &domain.PackConfig{SKU: "broken", PackSizes: []int64{-1}, Status: domain.StatusPublished}
+	repo := &stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: defaultCfg, "broken": broken}}
+	svc := NewCalculateService(repo, NewSolverCache(1<<20), 2, domain.PackSizeLimits{}, nil)
+
+	results := svc.CalculateBatch(context.Background(), []CalculateInput{{Amount: 251}, {Amount: 10, SKU: "broken"}, {Amount: 501}})
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "panic") {
+		t.Fatalf("broken item: error = %v, want the recovered panic", results[1].Err)
+	}
+	for _, i := range []int{0, 2} {
+		if results[i].Err != nil || results[i].Result == nil {
+			t.Fatalf("item %d: result = %+v, error = %v", i, results[i].Result, results[i].Err)
+		}
+	}
+}
+
+func TestCalculate_AsOf(t *testing.T) {
+	now := time.Now().UTC()
+	march := now.Add(30 * 24 * time.Hour)
+	endOfQuarter := march.Add(60 * 24 * time.Hour)
+
+	current, _ := domain.NewPackConfig([]int64{500, 1000, 2000})
+	current.UpdatedAt = now.Add(-time.Hour)
+	scheduled, _ := domain.NewPackConfig([]int64{500, 750, 1000, 2000})
+	scheduled.Version = 1
+	scheduled.EffectiveFrom, scheduled.EffectiveTo = &march, &endOfQuarter
+	repo := &stubConfigRepo{versions: map[string][]domain.PackConfig{domain.DefaultSKU: {*current, *scheduled}}}
+	svc := NewCalculateService(repo, NewSolverCache(1<<20), 1, domain.PackSizeLimits{}, nil)
+
+	tests := []struct {
+		name        string
+		asOf        time.Time
+		wantVersion int64
+		wantTotal   int
+	}{
+		{name: "now", wantVersion: 0, wantTotal: 1000},
+		{name: "after activation", asOf: march.Add(time.Hour), wantVersion: 1, wantTotal: 750},
+		{name: "after retirement", asOf: endOfQuarter, wantVersion: 0, wantTotal: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.Calculate(context.Background(), CalculateInput{Amount: 700, AsOf: tt.asOf})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.ConfigVersion != tt.wantVersion || shippedTotal(result.Packs) != tt.wantTotal {
+				t.Fatalf("version = %d, total = %d, want %d and %d", result.ConfigVersion, shippedTotal(result.Packs), tt.wantVersion, tt.wantTotal)
+			}
+		})
+	}
+
+	if _, err := svc.Calculate(context.Background(), CalculateInput{Amount: 1, AsOf: now.Add(-24 * time.Hour)}); !errors.Is(err, domain.ErrPackSizesNotConfigured) {
+		t.Fatalf("before the first version: error = %v, want ErrPackSizesNotConfigured", err)
+	}
+
+	results := svc.CalculateBatch(context.Background(), []CalculateInput{{Amount: 700}, {Amount: 700, AsOf: march}})
+	if results[0].Result.ConfigVersion != 0 || results[1].Result.ConfigVersion != 1 {
+		t.Fatalf("batch versions = %d, %d, want 0 and 1", results[0].Result.ConfigVersion, results[1].Result.ConfigVersion)
+	}
+}
+
+func TestCalculate_IgnoresInactiveSizes(t *testing.T) {
+	cfg, _ := domain.NewPackConfig([]int64{500, 750, 1000})
+	box := domain.PackSize{Size: 1000, Label: "Large box", PackagingSKU: "BOX-L", Active: true}
+	if err := cfg.SetPacks([]domain.PackSize{{Size: 750, Label: "Seasonal box"}, box}); err != nil {
+		t.Fatalf("set packs: %v", err)
+	}
+	cfg.PackCosts = map[int64]domain.PackCost{500: {UnitCost: 5}, 1000: {UnitCost: 8}}
+	repo := &stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: cfg}}
+	svc := NewCalculateService(repo, NewSolverCache(1<<20), 1, domain.PackSizeLimits{}, nil)
+
+	for _, objective := range []domain.Objective{domain.ObjectiveMinOverfill, domain.ObjectiveMinCost} {
+		result, err := svc.Calculate(context.Background(), CalculateInput{Amount: 700, Objective: objective})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", objective, err)
+		}
+		if !reflect.DeepEqual(result.Packs, []domain.PackBreakdown{{Size: 1000, Count: 1}}) {
+			t.Fatalf("%s: packs = %+v, want one 1000 pack", objective, result.Packs)
+		}
+		if result.SizeDetails[1000] != box {
+			t.Fatalf("%s: size details = %+v", objective, result.SizeDetails)
+		}
+	}
+
+	if !cfg.HasSize(750) || len(cfg.PackSizes) != 3 {
+		t.Fatalf("inactive size was dropped: %v", cfg.PackSizes)
+	}
+}
+
+func TestCalculate_MaxAmount(t *testing.T) {
+	cfg, _ := domain.NewPackConfig([]int64{250, 500, 1000, 2000, 5000})
+	cfg.PackCosts = map[int64]domain.PackCost{250: {UnitCost: 3}, 500: {UnitCost: 5}, 1000: {UnitCost: 9}, 2000: {UnitCost: 17}, 5000: {UnitCost: 40}}
+	repo := &stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: cfg}}
+	svc := NewCalculateService(repo, NewSolverCache(1<<20), 1, domain.PackSizeLimits{}, nil)
+
+	inputs := map[string]CalculateInput{
+		"default":   {Explain: true, RunnersUp: true, TopK: 3},
+		"stock":     {StockLimits: map[int64]int{5000: 100}, Explain: true},
+		"min_cost":  {Objective: domain.ObjectiveMinCost, Explain: true, RunnersUp: true},
+		"rules":     {Rules: []domain.Rule{{Criterion: domain.CriterionOverfill, TolerancePct: 100}, {Criterion: domain.CriterionPackCount}}, Explain: true},
+		"max stock": {StockLimits: map[int64]int{250: math.MaxInt, 5000: math.MaxInt}},
+	}
+	for name, in := range inputs {
+		in.Amount = domain.MaxAmount
+		result, err := svc.Calculate(context.Background(), in)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if total := shippedTotal(result.Packs); total < domain.MaxAmount || total >= domain.MaxAmount+250 {
+			t.Fatalf("%s: shipped %d for %d", name, total, domain.MaxAmount)
+		}
+
+		for _, amount := range []int{domain.MaxAmount + 1, math.MaxInt - 10, math.MaxInt} {
+			in.Amount = amount
+			if _, err := svc.Calculate(context.Background(), in); !errors.Is(err, domain.ErrInvalidAmount) {
+				t.Fatalf("%s: amount %d: error = %v, want ErrInvalidAmount", name, amount, err)
+			}
+		}
+	}
+}
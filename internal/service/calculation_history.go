@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+// Page sizes of a calculation listing.
+const (
+	DefaultCalculationPageSize = 50
+	MaxCalculationPageSize     = 500
+)
+
+// CalculationPage is one page of stored calculations, newest first.
+type CalculationPage struct {
+	Calculations []domain.Calculation
+	// NextBeforeID continues the listing with the next page; 0 on the last page.
+	NextBeforeID int64
+}
+
+// record stores solved calculations and sets their CalculationID. The slices
+// are parallel; results were solved from inputs in the given durations.
+func (s *CalculateService) record(ctx context.Context, inputs []CalculateInput, results []*CalculateResult, durations []time.Duration) error {
+	if s.history == nil || len(results) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	calcs := make([]domain.Calculation, len(results))
+	for i, result := range results {
+		in := inputs[i]
+		calcs[i] = domain.Calculation{
+			SKU:    in.SKU,
+			Amount: in.Amount,
+			Options: domain.CalculationOptions{
+				StockLimits:  in.StockLimits,
+				Objective:    in.Objective,
+				Rules:        in.Rules,
+				AsOf:         in.AsOf,
+				Alternatives: in.TopK,
+			},
+			ConfigVersion: result.ConfigVersion,
+			Packs:         result.Packs,
+			Cost:          result.Cost,
+			Solver:        result.Solver,
+			Duration:      durations[i],
+			CreatedAt:     now,
+		}
+	}
+
+	ids, err := s.history.CreateCalculations(ctx, calcs)
+	if err != nil {
+		return err
+	}
+	for i, id := range ids {
+		results[i].CalculationID = id
+	}
+
+	return nil
+}
+
+// GetCalculation returns a stored calculation, or ErrCalculationNotFound.
+func (s *CalculateService) GetCalculation(ctx context.Context, id int64) (*domain.Calculation, error) {
+	if s.history == nil {
+		return nil, domain.ErrCalculationNotFound
+	}
+
+	calc, err := s.history.GetCalculation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if calc == nil {
+		return nil, domain.ErrCalculationNotFound
+	}
+
+	return calc, nil
+}
+
+// ListCalculations returns one page of the stored calculations that match
+// filter. A zero Limit means DefaultCalculationPageSize.
+func (s *CalculateService) ListCalculations(ctx context.Context, filter domain.CalculationFilter) (*CalculationPage, error) {
+	filter, err := normalizeCalculationFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &CalculationPage{Calculations: make([]domain.Calculation, 0)}
+	if s.history == nil {
+		return page, nil
+	}
+
+	// One extra row tells whether another page follows.
+	limit := filter.Limit
+	filter.Limit++
+	calcs, err := s.history.ListCalculations(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(calcs) > limit {
+		calcs = calcs[:limit]
+		page.NextBeforeID = calcs[limit-1].ID
+	}
+	page.Calculations = calcs
+
+	return page, nil
+}
+
+// normalizeCalculationFilter validates a filter and returns it with a
+// normalized SKU and a default Limit.
+func normalizeCalculationFilter(filter domain.CalculationFilter) (domain.CalculationFilter, error) {
+	if filter.Limit == 0 {
+		filter.Limit = DefaultCalculationPageSize
+	}
+	if filter.Limit < 0 || filter.Limit > MaxCalculationPageSize ||
+		filter.MinAmount < 0 || filter.MaxAmount < 0 || filter.BeforeID < 0 ||
+		(filter.MaxAmount > 0 && filter.MinAmount > filter.MaxAmount) ||
+		(!filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To)) {
+		return filter, domain.ErrInvalidCalculationFilter
+	}
+	if filter.SKU != "" {
+		sku, err := domain.NormalizeSKU(filter.SKU)
+		if err != nil {
+			return filter, err
+		}
+		filter.SKU = sku
+	}
+
+	return filter, nil
+}
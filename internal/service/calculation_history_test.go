@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+// stubHistoryRepo keeps calculations in memory, in ID order.
+type stubHistoryRepo struct {
+	mu    sync.Mutex
+	calcs []domain.Calculation
+}
+
+func (r *stubHistoryRepo) CreateCalculations(_ context.Context, calcs []domain.Calculation) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]int64, len(calcs))
+	for i, calc := range calcs {
+		calc.ID = int64(len(r.calcs) + 1)
+		r.calcs = append(r.calcs, calc)
+		ids[i] = calc.ID
+	}
+	return ids, nil
+}
+
+func (r *stubHistoryRepo) GetCalculation(_ context.Context, id int64) (*domain.Calculation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, calc := range r.calcs {
+		if calc.ID == id {
+			return &calc, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *stubHistoryRepo) ListCalculations(_ context.Context, f domain.CalculationFilter) ([]domain.Calculation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var calcs []domain.Calculation
+	for i := len(r.calcs) - 1; i >= 0 && len(calcs) < f.Limit; i-- {
+		c := r.calcs[i]
+		if (f.SKU == "" || c.SKU == f.SKU) && (f.BeforeID == 0 || c.ID < f.BeforeID) &&
+			c.Amount >= f.MinAmount && (f.MaxAmount == 0 || c.Amount <= f.MaxAmount) {
+			calcs = append(calcs, c)
+		}
+	}
+	return calcs, nil
+}
+
+func (r *stubHistoryRepo) PurgeCalculations(_ context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.calcs[:0]
+	for _, calc := range r.calcs {
+		if !calc.CreatedAt.Before(before) {
+			kept = append(kept, calc)
+		}
+	}
+	deleted := int64(len(r.calcs) - len(kept))
+	r.calcs = kept
+	return deleted, nil
+}
+
+func TestCalculate_RecordsHistory(t *testing.T) {
+	cfg, _ := domain.NewPackConfig([]int64{250, 500, 1000})
+	cfg.Version = 3
+	repo := &stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: cfg}}
+	history := &stubHistoryRepo{}
+	svc := NewCalculateService(repo, nil, 1, domain.PackSizeLimits{}, history)
+
+	result, err := svc.Calculate(context.Background(), CalculateInput{Amount: 501, StockLimits: map[int64]int{1000: 0}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.CalculationID != 1 {
+		t.Fatalf("calculation ID = %d, want 1", result.CalculationID)
+	}
+
+	calc, err := svc.GetCalculation(context.Background(), result.CalculationID)
+	if err != nil {
+		t.Fatalf("get: unexpected error: %v", err)
+	}
+	if calc.SKU != domain.DefaultSKU || calc.Amount != 501 || calc.ConfigVersion != 3 || calc.Solver != result.Solver ||
+		len(calc.Packs) != len(result.Packs) || calc.Options.StockLimits[1000] != 0 ||
+		calc.Options.Objective != domain.ObjectiveMinOverfill || calc.Options.AsOf.IsZero() {
+		t.Fatalf("stored calculation = %+v", calc)
+	}
+	if _, err := svc.GetCalculation(context.Background(), 99); !errors.Is(err, domain.ErrCalculationNotFound) {
+		t.Fatalf("expected ErrCalculationNotFound, got %v", err)
+	}
+
+	// Only solved batch items are stored.
+	results := svc.CalculateBatch(context.Background(), []CalculateInput{{Amount: 250}, {Amount: 0}, {Amount: 1000}})
+	if results[0].Result.CalculationID != 2 || results[1].Err == nil || results[2].Result.CalculationID != 3 {
+		t.Fatalf("batch results = %+v", results)
+	}
+}
+
+func TestListCalculations(t *testing.T) {
+	history := &stubHistoryRepo{}
+	for amount := 1; amount <= 5; amount++ {
+		_, _ = history.CreateCalculations(context.Background(), []domain.Calculation{{SKU: domain.DefaultSKU, Amount: amount * 100}})
+	}
+	svc := NewCalculateService(&stubConfigRepo{}, nil, 1, domain.PackSizeLimits{}, history)
+
+	page, err := svc.ListCalculations(context.Background(), domain.CalculationFilter{MinAmount: 200, Limit: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Calculations) != 2 || page.Calculations[0].ID != 5 || page.NextBeforeID != 4 {
+		t.Fatalf("first page = %+v", page)
+	}
+
+	page, err = svc.ListCalculations(context.Background(), domain.CalculationFilter{MinAmount: 200, Limit: 2, BeforeID: page.NextBeforeID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Calculations) != 2 || page.Calculations[1].ID != 2 || page.NextBeforeID != 0 {
+		t.Fatalf("last page = %+v", page)
+	}
+
+	for _, filter := range []domain.CalculationFilter{
+		{Limit: MaxCalculationPageSize + 1},
+		{MinAmount: 500, MaxAmount: 100},
+		{From: time.Now(), To: time.Now().Add(-time.Hour)},
+	} {
+		if _, err := svc.ListCalculations(context.Background(), filter); !errors.Is(err, domain.ErrInvalidCalculationFilter) {
+			t.Fatalf("filter %+v: expected ErrInvalidCalculationFilter, got %v", filter, err)
+		}
+	}
+}
+
+func TestHistoryPurgeJob_Purge(t *testing.T) {
+	now := time.Now().UTC()
+	history := &stubHistoryRepo{calcs: []domain.Calculation{
+		{ID: 1, CreatedAt: now.Add(-48 * time.Hour)},
+		{ID: 2, CreatedAt: now.Add(-time.Hour)},
+	}}
+	job := NewHistoryPurgeJob(history, slog.Default(), time.Hour, 24*time.Hour)
+	job.now = func() time.Time { return now }
+
+	if err := job.purge(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history.calcs) != 1 || history.calcs[0].ID != 2 {
+		t.Fatalf("kept calculations = %+v", history.calcs)
+	}
+}
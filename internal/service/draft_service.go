@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go-packing/internal/domain"
+)
+
+// DraftService runs the review workflow of pack configs: a draft is edited and
+// commented on, approved by someone other than its author, then published as a
+// new config version. Calculations only ever see published versions.
+type DraftService struct {
+	drafts  domain.PackConfigDraftsRepository
+	configs *PackConfigService
+	logger  *slog.Logger
+}
+
+// NewDraftService creates a draft workflow that publishes through configs.
+func NewDraftService(drafts domain.PackConfigDraftsRepository, configs *PackConfigService, logger *slog.Logger) *DraftService {
+	return &DraftService{drafts: drafts, configs: configs, logger: logger}
+}
+
+// CreateDraft validates a replacement exactly like PackConfigService.ReplacePackSizes
+// and stores it as a draft authored by in.Actor, without changing the config.
+func (s *DraftService) CreateDraft(ctx context.Context, in ReplacePackSizesInput) (*domain.PackConfigDraft, error) {
+	current, proposed, err := s.configs.prepareReplace(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := domain.NewPackConfigDraft(*proposed, versionOf(current), in.Actor)
+	if err != nil {
+		return nil, err
+	}
+	draft.ID, err = s.drafts.CreateDraft(ctx, *draft)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("pack config draft created", "sku", draft.Config.SKU, "draft_id", draft.ID, "author", draft.CreatedBy)
+	draft.Comments = []domain.DraftComment{}
+	return draft, nil
+}
+
+// UpdateDraft replaces the proposed config of a draft that is not yet approved
+// on behalf of its author, in.Actor. The draft is rebased on the current version of the SKU.
+func (s *DraftService) UpdateDraft(ctx context.Context, id int64, in ReplacePackSizesInput) (*domain.PackConfigDraft, error) {
+	draft, err := s.GetDraft(ctx, in.SKU, id)
+	if err != nil {
+		return nil, err
+	}
+
+	current, proposed, err := s.configs.prepareReplace(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if err := draft.Edit(*proposed, versionOf(current), in.Actor); err != nil {
+		return nil, err
+	}
+	if err := s.drafts.UpdateDraft(ctx, *draft); err != nil {
+		return nil, err
+	}
+
+	return draft, nil
+}
+
+// GetDraft returns a draft of a SKU with its comments.
+func (s *DraftService) GetDraft(ctx context.Context, sku string, id int64) (*domain.PackConfigDraft, error) {
+	sku, err := domain.NormalizeSKU(sku)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := s.drafts.GetDraft(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// A draft of another SKU is not found under this one.
+	if draft == nil || draft.Config.SKU != sku {
+		return nil, domain.ErrDraftNotFound
+	}
+
+	return draft, nil
+}
+
+// ListDrafts returns the drafts of a SKU, newest first; an empty status lists all.
+func (s *DraftService) ListDrafts(ctx context.Context, sku string, status domain.ConfigStatus) ([]domain.PackConfigDraft, error) {
+	sku, err := domain.NormalizeSKU(sku)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.drafts.ListDrafts(ctx, sku, status)
+}
+
+// AddComment attaches a review remark to a draft and returns the draft with it.
+func (s *DraftService) AddComment(ctx context.Context, sku string, id int64, author, body string) (*domain.PackConfigDraft, error) {
+	comment, err := domain.NewDraftComment(author, body)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := s.GetDraft(ctx, sku, id)
+	if err != nil {
+		return nil, err
+	}
+	comment.ID, err = s.drafts.AddComment(ctx, id, comment)
+	if err != nil {
+		return nil, err
+	}
+	draft.Comments = append(draft.Comments, comment)
+
+	return draft, nil
+}
+
+// Approve signs a draft off on behalf of reviewer, who must not be its author.
+// Identities are taken as given: the author-is-not-reviewer check only holds
+// when the caller authenticated reviewer.
+func (s *DraftService) Approve(ctx context.Context, sku string, id int64, reviewer string) (*domain.PackConfigDraft, error) {
+	draft, err := s.GetDraft(ctx, sku, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := draft.Approve(reviewer); err != nil {
+		return nil, err
+	}
+	if err := s.drafts.UpdateDraft(ctx, *draft); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("pack config draft approved", "sku", draft.Config.SKU, "draft_id", id, "reviewer", draft.ReviewedBy)
+	return draft, nil
+}
+
+// Reject closes a draft that was not published. A non-empty comment is attached
+// as the reason.
+func (s *DraftService) Reject(ctx context.Context, sku string, id int64, reviewer, comment string) (*domain.PackConfigDraft, error) {
+	draft, err := s.GetDraft(ctx, sku, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := draft.Reject(reviewer); err != nil {
+		return nil, err
+	}
+	var remark domain.DraftComment
+	if comment != "" {
+		if remark, err = domain.NewDraftComment(reviewer, comment); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.drafts.UpdateDraft(ctx, *draft); err != nil {
+		return nil, err
+	}
+	if comment != "" {
+		if remark.ID, err = s.drafts.AddComment(ctx, id, remark); err != nil {
+			return nil, err
+		}
+		draft.Comments = append(draft.Comments, remark)
+	}
+
+	s.logger.Info("pack config draft rejected", "sku", draft.Config.SKU, "draft_id", id, "reviewer", draft.ReviewedBy)
+	return draft, nil
+}
+
+// Publish writes an approved draft as the next config version of its SKU. It
+// fails with domain.ErrVersionMismatch when another version was written since
+// the draft was made, so a review never approves changes it did not see.
+func (s *DraftService) Publish(ctx context.Context, sku string, id int64, actor string) (*domain.PackConfigDraft, *domain.PackConfig, error) {
+	draft, err := s.GetDraft(ctx, sku, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := draft.CanPublish(actor); err != nil {
+		return nil, nil, err
+	}
+
+	if draft.BaseVersion == nil {
+		current, err := s.configs.GetCurrent(ctx, draft.Config.SKU)
+		if err != nil {
+			return nil, nil, err
+		}
+		if current != nil {
+			return nil, nil, domain.ErrVersionMismatch
+		}
+	}
+
+	proposed := draft.Config
+	reason := proposed.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("publish draft %d", id)
+	}
+	publish := replaceInputOf(&proposed)
+	publish.EffectiveFrom, publish.EffectiveTo = proposed.EffectiveFrom, proposed.EffectiveTo
+	publish.ExpectedVersion, publish.Actor, publish.Reason = draft.BaseVersion, draft.CreatedBy, reason
+	cfg, err := s.configs.replace(ctx, publish)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The version is already live, so a failure here only leaves the draft
+	// approved; publishing it again fails on the version check.
+	if err := draft.MarkPublished(actor, cfg.Version); err != nil {
+		return nil, nil, err
+	}
+	if err := s.drafts.UpdateDraft(ctx, *draft); err != nil {
+		s.logger.Error("failed to mark pack config draft published", "error", err, "draft_id", id, "version", cfg.Version)
+		return nil, nil, err
+	}
+
+	s.logger.Info("pack config draft published", "sku", cfg.SKU, "draft_id", id, "version", cfg.Version, "actor", draft.PublishedBy)
+	return draft, cfg, nil
+}
+
+// versionOf is the version of cfg, or nil for a SKU without a config.
+func versionOf(cfg *domain.PackConfig) *int64 {
+	if cfg == nil {
+		return nil
+	}
+
+	version := cfg.Version
+	return &version
+}
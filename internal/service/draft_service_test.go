@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"go-packing/internal/domain"
+)
+
+// writableConfigRepo is stubConfigRepo that also stores writes, without history.
+type writableConfigRepo struct {
+	*stubConfigRepo
+}
+
+func (r writableConfigRepo) Create(_ context.Context, cfg domain.PackConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.configs[cfg.SKU] = &cfg
+	return nil
+}
+
+func (r writableConfigRepo) Update(_ context.Context, cfg domain.PackConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current := r.configs[cfg.SKU]; current == nil || current.Version != cfg.Version-1 {
+		return domain.ErrConcurrencyConflict
+	}
+	r.configs[cfg.SKU] = &cfg
+	return nil
+}
+
+// stubDraftRepo keeps drafts in memory with revision checks.
+type stubDraftRepo struct {
+	drafts map[int64]domain.PackConfigDraft
+}
+
+func (r *stubDraftRepo) CreateDraft(_ context.Context, draft domain.PackConfigDraft) (int64, error) {
+	draft.ID = int64(len(r.drafts) + 1)
+	r.drafts[draft.ID] = draft
+	return draft.ID, nil
+}
+
+func (r *stubDraftRepo) GetDraft(_ context.Context, id int64) (*domain.PackConfigDraft, error) {
+	draft, ok := r.drafts[id]
+	if !ok {
+		return nil, nil
+	}
+	return &draft, nil
+}
+
+func (r *stubDraftRepo) ListDrafts(_ context.Context, sku string, status domain.ConfigStatus) ([]domain.PackConfigDraft, error) {
+	var drafts []domain.PackConfigDraft
+	for _, draft := range r.drafts {
+		if draft.Config.SKU == sku && (status == "" || draft.Config.Status == status) {
+			drafts = append(drafts, draft)
+		}
+	}
+	return drafts, nil
+}
+
+func (r *stubDraftRepo) UpdateDraft(_ context.Context, draft domain.PackConfigDraft) error {
+	if r.drafts[draft.ID].Revision != draft.Revision-1 {
+		return domain.ErrConcurrencyConflict
+	}
+	r.drafts[draft.ID] = draft
+	return nil
+}
+
+func (r *stubDraftRepo) AddComment(_ context.Context, draftID int64, comment domain.DraftComment) (int64, error) {
+	draft, ok := r.drafts[draftID]
+	if !ok {
+		return 0, domain.ErrDraftNotFound
+	}
+	comment.ID = int64(len(draft.Comments) + 1)
+	draft.Comments = append(draft.Comments, comment)
+	r.drafts[draftID] = draft
+	return comment.ID, nil
+}
+
+func TestDraftService_Workflow(t *testing.T) {
+	stored, _ := domain.NewPackConfig([]int64{500, 1000})
+	configs := writableConfigRepo{&stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: stored}}}
+	drafts := &stubDraftRepo{drafts: map[int64]domain.PackConfigDraft{}}
+	svc := NewDraftService(drafts, NewPackConfigService(configs, NewSolverCache(1<<20), slog.Default(), domain.PackSizeLimits{}, true), slog.Default())
+	calc := NewCalculateService(configs, nil, 1, domain.PackSizeLimits{}, nil)
+	ctx := context.Background()
+
+	draft, err := svc.CreateDraft(ctx, ReplacePackSizesInput{PackSizes: []int64{500, 750, 1000}, Actor: "jane", Reason: "new 750 box"})
+	if err != nil {
+		t.Fatalf("create draft: %v", err)
+	}
+	if draft.Config.Status != domain.StatusDraft || *draft.BaseVersion != 0 || draft.Config.Version != 1 {
+		t.Fatalf("draft = %+v", draft)
+	}
+
+	// Drafts are invisible to calculations until they are published.
+	result, err := calc.Calculate(ctx, CalculateInput{Amount: 700})
+	if err != nil || result.ConfigVersion != 0 || shippedTotal(result.Packs) != 1000 {
+		t.Fatalf("calculate before publish = %+v, %v", result, err)
+	}
+
+	if _, err := svc.Approve(ctx, "", draft.ID, "jane"); !errors.Is(err, domain.ErrSelfApproval) {
+		t.Fatalf("self approval: error = %v, want ErrSelfApproval", err)
+	}
+	if _, _, err := svc.Publish(ctx, "", draft.ID, "sam"); !errors.Is(err, domain.ErrInvalidDraftTransition) {
+		t.Fatalf("publish before approval: error = %v, want ErrInvalidDraftTransition", err)
+	}
+	if _, err := svc.AddComment(ctx, "", draft.ID, "sam", "750 boxes arrive on March 1st"); err != nil {
+		t.Fatalf("add comment: %v", err)
+	}
+	if _, err := svc.Approve(ctx, "widget", draft.ID, "sam"); !errors.Is(err, domain.ErrDraftNotFound) {
+		t.Fatalf("approve under another SKU: error = %v, want ErrDraftNotFound", err)
+	}
+	if _, err := svc.Approve(ctx, "", draft.ID, "sam"); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+
+	published, cfg, err := svc.Publish(ctx, "", draft.ID, "sam")
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if published.Config.Status != domain.StatusPublished || *published.PublishedVersion != 1 || len(published.Comments) != 1 {
+		t.Fatalf("published draft = %+v", published)
+	}
+	if cfg.Version != 1 || cfg.UpdatedBy != "jane" || cfg.Reason != "new 750 box" || !reflect.DeepEqual(cfg.PackSizes, []int64{500, 750, 1000}) {
+		t.Fatalf("published config = %+v", cfg)
+	}
+
+	result, err = calc.Calculate(ctx, CalculateInput{Amount: 700})
+	if err != nil || result.ConfigVersion != 1 || shippedTotal(result.Packs) != 750 {
+		t.Fatalf("calculate after publish = %+v, %v", result, err)
+	}
+}
+
+func TestDraftService_PublishStaleDraft(t *testing.T) {
+	stored, _ := domain.NewPackConfig([]int64{250, 500})
+	configs := writableConfigRepo{&stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: stored}}}
+	drafts := &stubDraftRepo{drafts: map[int64]domain.PackConfigDraft{}}
+	configSvc := NewPackConfigService(configs, nil, slog.Default(), domain.PackSizeLimits{}, false)
+	svc := NewDraftService(drafts, configSvc, slog.Default())
+	ctx := context.Background()
+
+	draft, err := svc.CreateDraft(ctx, ReplacePackSizesInput{PackSizes: []int64{250, 750}, Actor: "jane"})
+	if err != nil {
+		t.Fatalf("create draft: %v", err)
+	}
+	if _, err := svc.Approve(ctx, "", draft.ID, "sam"); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if _, err := configSvc.ReplacePackSizes(ctx, ReplacePackSizesInput{PackSizes: []int64{250, 1000}}); err != nil {
+		t.Fatalf("direct write: %v", err)
+	}
+
+	if _, _, err := svc.Publish(ctx, "", draft.ID, "sam"); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Fatalf("stale publish: error = %v, want ErrVersionMismatch", err)
+	}
+	if drafts.drafts[draft.ID].Config.Status != domain.StatusApproved {
+		t.Fatalf("stale draft status = %q, want approved", drafts.drafts[draft.ID].Config.Status)
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go-packing/internal/domain"
+)
+
+// maxEditAttempts caps how often a single-size edit is retried after losing a
+// write race against another writer.
+const maxEditAttempts = 5
+
+// EditPackSizeInput describes adding or removing one pack size.
+type EditPackSizeInput struct {
+	// SKU selects the product configuration; empty means the default profile.
+	SKU string
+	// Pack is the size to add with its metadata; a removal only reads Size.
+	Pack domain.PackSize
+	// ExpectedVersion, when set, must match the stored version; the edit is then
+	// never retried, since the caller asked for that exact version.
+	// domain.AnyVersion only requires a stored config and keeps the retries.
+	ExpectedVersion *int64
+	// Actor and Reason are recorded in the configuration history; an empty
+	// reason names the edit.
+	Actor  string
+	Reason string
+}
+
+// AddPackSize adds one size to the stored set. A SKU without a configuration
+// gets a new one holding only that size.
+func (s *PackConfigService) AddPackSize(ctx context.Context, in EditPackSizeInput) (*domain.PackConfig, error) {
+	if in.Reason == "" {
+		in.Reason = fmt.Sprintf("add pack size %d", in.Pack.Size)
+	}
+
+	return s.editPackSizes(ctx, in, true, func(cfg *domain.PackConfig) error {
+		if err := cfg.AddSize(in.Pack); err != nil {
+			return err
+		}
+		return domain.ValidatePackSizes(cfg.PackSizes, s.limits)
+	})
+}
+
+// RemovePackSize removes one size from the stored set, together with its
+// metadata, stock limit and cost.
+func (s *PackConfigService) RemovePackSize(ctx context.Context, in EditPackSizeInput) (*domain.PackConfig, error) {
+	if in.Reason == "" {
+		in.Reason = fmt.Sprintf("remove pack size %d", in.Pack.Size)
+	}
+
+	return s.editPackSizes(ctx, in, false, func(cfg *domain.PackConfig) error {
+		return cfg.RemoveSize(in.Pack.Size)
+	})
+}
+
+// editPackSizes applies edit to the latest stored version and writes the result.
+// Adding and removing different sizes commute, so when another writer wins the
+// race the edit is applied again to the version it wrote. An edit that no
+// longer applies, such as adding a size that was added meanwhile, fails with
+// the domain error instead. create allows a SKU without a configuration.
+func (s *PackConfigService) editPackSizes(ctx context.Context, in EditPackSizeInput, create bool, edit func(*domain.PackConfig) error) (*domain.PackConfig, error) {
+	if s.requireReview {
+		return nil, domain.ErrReviewRequired
+	}
+
+	sku, err := domain.NormalizeSKU(in.SKU)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		packCfg, err := s.applyEdit(ctx, sku, in, create, edit)
+		if err == nil {
+			s.cache.Invalidate(sku)
+			return packCfg, nil
+		}
+		pinned := in.ExpectedVersion != nil && *in.ExpectedVersion != domain.AnyVersion
+		if !errors.Is(err, domain.ErrConcurrencyConflict) || pinned || attempt == maxEditAttempts {
+			return nil, err
+		}
+
+		s.logger.Info("pack size edit lost a concurrent write, retrying", "sku", sku, "attempt", attempt)
+	}
+}
+
+// applyEdit is one read-modify-write attempt of editPackSizes.
+func (s *PackConfigService) applyEdit(ctx context.Context, sku string, in EditPackSizeInput, create bool, edit func(*domain.PackConfig) error) (*domain.PackConfig, error) {
+	current, err := s.repo.Get(ctx, sku)
+	if err != nil {
+		return nil, err
+	}
+
+	if current == nil {
+		if in.ExpectedVersion != nil {
+			return nil, domain.ErrVersionMismatch
+		}
+		if !create {
+			return nil, domain.ErrPackSizeNotFound
+		}
+
+		// The edit fills an empty config; a new config starts at version 0.
+		packCfg := &domain.PackConfig{SKU: sku, Status: domain.StatusPublished}
+		if err := edit(packCfg); err != nil {
+			return nil, err
+		}
+		packCfg.Version = 0
+		packCfg.UpdatedBy, packCfg.Reason = in.Actor, in.Reason
+
+		s.logger.Info("pack config not found, creating new one", "sku", sku)
+		if err := s.repo.Create(ctx, *packCfg); err != nil {
+			return nil, err
+		}
+		return packCfg, nil
+	}
+
+	if !domain.MatchesVersion(in.ExpectedVersion, current.Version) {
+		return nil, domain.ErrVersionMismatch
+	}
+
+	// The domain edits never write to maps they share with current.
+	next := *current
+	if err := edit(&next); err != nil {
+		return nil, err
+	}
+	// Like a replacement, an edit applies at once and inherits no schedule.
+	if err := next.SetSchedule(nil, nil); err != nil {
+		return nil, err
+	}
+	next.UpdatedBy, next.Reason = in.Actor, in.Reason
+
+	s.logger.Info("editing pack sizes", "sku", sku, "version", next.Version)
+	if err := s.repo.Update(ctx, next); err != nil {
+		return nil, err
+	}
+
+	return &next, nil
+}
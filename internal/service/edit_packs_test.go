@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"go-packing/internal/domain"
+)
+
+// racingConfigRepo lets another writer store a version right before the first
+// Update, so that Update loses the race.
+type racingConfigRepo struct {
+	writableConfigRepo
+	rival   func(stored *domain.PackConfig)
+	updates int
+}
+
+func (r *racingConfigRepo) Update(ctx context.Context, cfg domain.PackConfig) error {
+	r.updates++
+	if r.rival != nil {
+		r.mu.Lock()
+		rival := *r.configs[cfg.SKU]
+		r.rival(&rival)
+		r.configs[cfg.SKU] = &rival
+		r.mu.Unlock()
+		r.rival = nil
+	}
+
+	return r.writableConfigRepo.Update(ctx, cfg)
+}
+
+func TestAddPackSize_RetriesCommutingEdits(t *testing.T) {
+	stored, _ := domain.NewPackConfig([]int64{250, 500})
+	stored.StockLimits = map[int64]int{500: 2}
+	repo := &racingConfigRepo{writableConfigRepo: writableConfigRepo{&stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: stored}}}}
+	repo.rival = func(cfg *domain.PackConfig) {
+		if err := cfg.AddSize(domain.NewPackSize(750)); err != nil {
+			t.Fatalf("rival add: %v", err)
+		}
+	}
+	svc := NewPackConfigService(repo, NewSolverCache(1<<20), slog.Default(), domain.PackSizeLimits{}, false)
+
+	box := domain.PackSize{Size: 1000, Label: "Large box", Active: true}
+	cfg, err := svc.AddPackSize(context.Background(), EditPackSizeInput{Pack: box, Actor: "jane"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.updates != 2 || cfg.Version != 2 {
+		t.Fatalf("updates = %d, version = %d, want a retry onto version 1", repo.updates, cfg.Version)
+	}
+	if !reflect.DeepEqual(cfg.PackSizes, []int64{250, 500, 750, 1000}) || cfg.Pack(1000) != box || cfg.StockLimits[500] != 2 {
+		t.Fatalf("config = %+v", cfg)
+	}
+	if cfg.UpdatedBy != "jane" || cfg.Reason != "add pack size 1000" {
+		t.Fatalf("history = %q, %q", cfg.UpdatedBy, cfg.Reason)
+	}
+
+	cfg, err = svc.RemovePackSize(context.Background(), EditPackSizeInput{Pack: domain.NewPackSize(500)})
+	if err != nil {
+		t.Fatalf("remove: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.PackSizes, []int64{250, 750, 1000}) || len(cfg.StockLimits) != 0 || cfg.Version != 3 {
+		t.Fatalf("config after remove = %+v", cfg)
+	}
+}
+
+func TestEditPackSize_Rejects(t *testing.T) {
+	version := int64(0)
+	tests := []struct {
+		name  string
+		rival func(*domain.PackConfig)
+		run   func(*PackConfigService) error
+		want  error
+	}{
+		{
+			name: "size added meanwhile",
+			rival: func(cfg *domain.PackConfig) {
+				_ = cfg.AddSize(domain.NewPackSize(1000))
+			},
+			run: func(svc *PackConfigService) error {
+				_, err := svc.AddPackSize(context.Background(), EditPackSizeInput{Pack: domain.NewPackSize(1000)})
+				return err
+			},
+			want: domain.ErrPackSizeExists,
+		},
+		{
+			name: "size removed meanwhile",
+			rival: func(cfg *domain.PackConfig) {
+				_ = cfg.RemoveSize(250)
+			},
+			run: func(svc *PackConfigService) error {
+				_, err := svc.RemovePackSize(context.Background(), EditPackSizeInput{Pack: domain.NewPackSize(250)})
+				return err
+			},
+			want: domain.ErrPackSizeNotFound,
+		},
+		{
+			name: "expected version is not retried",
+			rival: func(cfg *domain.PackConfig) {
+				_ = cfg.AddSize(domain.NewPackSize(750))
+			},
+			run: func(svc *PackConfigService) error {
+				_, err := svc.AddPackSize(context.Background(), EditPackSizeInput{Pack: domain.NewPackSize(1000), ExpectedVersion: &version})
+				return err
+			},
+			want: domain.ErrConcurrencyConflict,
+		},
+		{
+			name: "last active size",
+			run: func(svc *PackConfigService) error {
+				if _, err := svc.RemovePackSize(context.Background(), EditPackSizeInput{Pack: domain.NewPackSize(250)}); err != nil {
+					return err
+				}
+				_, err := svc.RemovePackSize(context.Background(), EditPackSizeInput{Pack: domain.NewPackSize(500)})
+				return err
+			},
+			want: domain.ErrNoActivePackSizes,
+		},
+		{
+			name: "size count limit",
+			run: func(svc *PackConfigService) error {
+				svc.limits = domain.PackSizeLimits{MaxCount: 2}
+				_, err := svc.AddPackSize(context.Background(), EditPackSizeInput{Pack: domain.NewPackSize(1000)})
+				return err
+			},
+			want: domain.ErrInvalidPackSizes,
+		},
+		{
+			name: "replacement above the size limit",
+			run: func(svc *PackConfigService) error {
+				svc.limits = domain.PackSizeLimits{MaxSize: 5000}
+				_, err := svc.ReplacePackSizes(context.Background(), ReplacePackSizesInput{PackSizes: []int64{250, 10000}})
+				return err
+			},
+			want: domain.ErrInvalidPackSizes,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored, _ := domain.NewPackConfig([]int64{250, 500})
+			repo := &racingConfigRepo{writableConfigRepo: writableConfigRepo{&stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: stored}}}, rival: tt.rival}
+			svc := NewPackConfigService(repo, nil, slog.Default(), domain.PackSizeLimits{}, false)
+
+			if err := tt.run(svc); !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
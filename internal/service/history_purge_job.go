@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+// HistoryPurgeJob deletes stored calculations once they are older than the
+// retention period.
+type HistoryPurgeJob struct {
+	repo      domain.CalculationsRepository
+	logger    *slog.Logger
+	interval  time.Duration
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewHistoryPurgeJob creates a job that purges calculations older than
+// retention every interval.
+func NewHistoryPurgeJob(repo domain.CalculationsRepository, logger *slog.Logger, interval, retention time.Duration) *HistoryPurgeJob {
+	return &HistoryPurgeJob{
+		repo:      repo,
+		logger:    logger,
+		interval:  interval,
+		retention: retention,
+		now:       func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Run purges once at start and then every interval until ctx is done. A failed
+// purge is retried on the next tick.
+func (j *HistoryPurgeJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.purge(ctx); err != nil {
+			j.logger.Error("failed to purge calculation history", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge deletes the calculations created before the retention period.
+func (j *HistoryPurgeJob) purge(ctx context.Context) error {
+	before := j.now().Add(-j.retention)
+	deleted, err := j.repo.PurgeCalculations(ctx, before)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		j.logger.Info("calculation history purged", "deleted", deleted, "before", before)
+	}
+
+	return nil
+}
@@ -2,24 +2,93 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"maps"
+	"time"
 
 	"go-packing/internal/domain"
 )
 
+// Page sizes of a configuration history listing.
+const (
+	DefaultHistoryPageSize = 50
+	MaxHistoryPageSize     = 500
+)
+
+// VersionPage is one page of the configuration history of a SKU, newest first.
+type VersionPage struct {
+	Versions []domain.PackConfig
+	// NextBeforeVersion continues the listing with the next page; 0 on the last page.
+	NextBeforeVersion int64
+}
+
 type PackConfigService struct {
-	repo   domain.PackConfigsRepository
-	logger *slog.Logger
+	repo          domain.PackConfigsRepository
+	cache         *SolverCache
+	logger        *slog.Logger
+	limits        domain.PackSizeLimits
+	requireReview bool
+}
+
+// ReplacePackSizesInput describes a pack-size replacement.
+type ReplacePackSizesInput struct {
+	// SKU selects the product configuration; empty means the default profile.
+	SKU       string
+	PackSizes []int64
+	// The settings below are optional: nil keeps what the stored version has for
+	// the sizes that remain, and an empty value clears it.
+	//
+	// Packs describes sizes of PackSizes; sizes left out are active without metadata.
+	Packs []domain.PackSize
+	// StockLimits caps how many packs of a size are available; sizes without a
+	// limit are unlimited.
+	StockLimits map[int64]int
+	// PackCosts and OverfillItemCost price breakdowns for the min_cost objective.
+	PackCosts        map[int64]domain.PackCost
+	OverfillItemCost *int64
+	// Rules is the default rule chain of the config; empty means domain.DefaultRules.
+	Rules []domain.Rule
+	// EffectiveFrom queues the new version to apply from a future time; nil
+	// applies it at once. EffectiveTo retires it; nil keeps it until a later
+	// version starts. Neither is inherited from the replaced version.
+	EffectiveFrom *time.Time
+	EffectiveTo   *time.Time
+	// ExpectedVersion, when set, must match the stored version or the write is
+	// rejected; domain.AnyVersion only requires a stored config.
+	ExpectedVersion *int64
+	// Actor and Reason are recorded in the configuration history.
+	Actor  string
+	Reason string
+}
+
+// RollbackInput describes a rollback to a previously stored version.
+type RollbackInput struct {
+	SKU             string
+	Version         int64
+	ExpectedVersion *int64
+	Actor           string
+	Reason          string
 }
 
 // NewPackConfigService creates a service for pack-size configuration lifecycle.
-func NewPackConfigService(repo domain.PackConfigsRepository, logger *slog.Logger) *PackConfigService {
-	return &PackConfigService{repo: repo, logger: logger}
+// Successful writes invalidate the SKU in cache, which may be nil. Every write
+// must keep the sizes within limits. With requireReview, replace, edit,
+// rollback and delete fail with domain.ErrReviewRequired, and configs only
+// change when DraftService publishes an approved draft.
+func NewPackConfigService(repo domain.PackConfigsRepository, cache *SolverCache, logger *slog.Logger, limits domain.PackSizeLimits, requireReview bool) *PackConfigService {
+	return &PackConfigService{repo: repo, cache: cache, logger: logger, limits: limits, requireReview: requireReview}
 }
 
-// GetCurrent returns the current persisted pack configuration, if any.
-func (s *PackConfigService) GetCurrent(ctx context.Context) (*domain.PackConfig, error) {
-	cfg, err := s.repo.Get(ctx)
+// GetCurrent returns the latest written pack configuration of a SKU, if any,
+// which may be scheduled to apply later.
+func (s *PackConfigService) GetCurrent(ctx context.Context, sku string) (*domain.PackConfig, error) {
+	sku, err := domain.NormalizeSKU(sku)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := s.repo.Get(ctx, sku)
 	if err != nil {
 		return nil, err
 	}
@@ -27,35 +96,323 @@ func (s *PackConfigService) GetCurrent(ctx context.Context) (*domain.PackConfig,
 	return cfg, nil
 }
 
+// GetActive returns the configuration version of a SKU that applies at a time;
+// zero means now.
+func (s *PackConfigService) GetActive(ctx context.Context, sku string, at time.Time) (*domain.PackConfig, error) {
+	sku, err := domain.NormalizeSKU(sku)
+	if err != nil {
+		return nil, err
+	}
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	cfg, err := s.repo.GetActive(ctx, sku, at)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return nil, domain.ErrPackSizesNotConfigured
+	}
+
+	return cfg, nil
+}
+
+// List returns the current configuration of every SKU.
+func (s *PackConfigService) List(ctx context.Context) ([]domain.PackConfig, error) {
+	return s.repo.List(ctx)
+}
+
 // ReplacePackSizes updates pack sizes via read-modify-write with optimistic concurrency.
-func (s *PackConfigService) ReplacePackSizes(ctx context.Context, packSizes []int64) (*domain.PackConfig, error) {
-	packCfg, err := s.repo.Get(ctx)
+// A SKU without a configuration gets a new one.
+func (s *PackConfigService) ReplacePackSizes(ctx context.Context, in ReplacePackSizesInput) (*domain.PackConfig, error) {
+	if s.requireReview {
+		return nil, domain.ErrReviewRequired
+	}
+
+	return s.replace(ctx, in)
+}
+
+// replace is ReplacePackSizes without the review check; publishing a reviewed
+// draft writes through it.
+func (s *PackConfigService) replace(ctx context.Context, in ReplacePackSizesInput) (*domain.PackConfig, error) {
+	current, packCfg, err := s.prepareReplace(ctx, in)
 	if err != nil {
 		return nil, err
 	}
-	if packCfg == nil {
-		s.logger.Info("pack config not found, creating new one")
 
-		packCfg, err = domain.NewPackConfig(packSizes)
-		if err != nil {
+	if current == nil {
+		s.logger.Info("pack config not found, creating new one", "sku", packCfg.SKU)
+		if err := s.repo.Create(ctx, *packCfg); err != nil {
 			return nil, err
 		}
-
-		if err := s.repo.Create(ctx, *packCfg); err != nil {
+	} else {
+		s.logger.Info("pack config found, updating existing one", "sku", packCfg.SKU)
+		if err := s.repo.Update(ctx, *packCfg); err != nil {
 			return nil, err
 		}
-		return packCfg, nil
 	}
+	s.cache.Invalidate(packCfg.SKU)
+	if packCfg.IsScheduled(packCfg.UpdatedAt) {
+		s.logger.Info("pack config scheduled", "sku", packCfg.SKU, "version", packCfg.Version, "effective_from", *packCfg.EffectiveFrom)
+	}
+
+	return packCfg, nil
+}
+
+// prepareReplace loads the config of a SKU and returns it with the replacement
+// applied to a copy, without storing anything. current is nil for a SKU without
+// a configuration.
+func (s *PackConfigService) prepareReplace(ctx context.Context, in ReplacePackSizesInput) (*domain.PackConfig, *domain.PackConfig, error) {
+	sku, err := domain.NormalizeSKU(in.SKU)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := domain.ValidatePackSizes(in.PackSizes, s.limits); err != nil {
+		return nil, nil, err
+	}
+
+	current, err := s.repo.Get(ctx, sku)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var packCfg *domain.PackConfig
+	if current == nil {
+		// A client cannot have read a version of a config that does not exist.
+		if in.ExpectedVersion != nil {
+			return nil, nil, domain.ErrVersionMismatch
+		}
+
+		packCfg, err = domain.NewPackConfig(in.PackSizes)
+		if err != nil {
+			return nil, nil, err
+		}
+		packCfg.SKU = sku
+	} else {
+		if !domain.MatchesVersion(in.ExpectedVersion, current.Version) {
+			return nil, nil, domain.ErrVersionMismatch
+		}
+
+		// Replace prunes the metadata, limit and cost maps, which the copy must not share.
+		next := *current
+		next.Packs = maps.Clone(current.Packs)
+		next.StockLimits, next.PackCosts = maps.Clone(current.StockLimits), maps.Clone(current.PackCosts)
+		packCfg = &next
+		// Domain Replace mutates sizes and bumps version in one place.
+		if err := packCfg.Replace(in.PackSizes); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Settings left out carry over; Replace already pruned them to the new sizes.
+	packs, stockLimits, packCosts, overfillItemCost, rules := in.Packs, in.StockLimits, in.PackCosts, packCfg.OverfillItemCost, in.Rules
+	if packs == nil {
+		packs = make([]domain.PackSize, 0, len(packCfg.Packs))
+		for _, size := range packCfg.PackSizes {
+			if pack, ok := packCfg.Packs[size]; ok {
+				packs = append(packs, pack)
+			}
+		}
+	}
+	if stockLimits == nil {
+		stockLimits = packCfg.StockLimits
+	}
+	if packCosts == nil {
+		packCosts = packCfg.PackCosts
+	}
+	if in.OverfillItemCost != nil {
+		overfillItemCost = *in.OverfillItemCost
+	}
+	if rules == nil {
+		rules = packCfg.Rules
+	}
+
+	if err := packCfg.SetPacks(packs); err != nil {
+		return nil, nil, err
+	}
+	if err := packCfg.SetStockLimits(stockLimits); err != nil {
+		return nil, nil, err
+	}
+	if err := packCfg.SetCosts(packCosts, overfillItemCost); err != nil {
+		return nil, nil, err
+	}
+	if err := packCfg.SetRules(rules); err != nil {
+		return nil, nil, err
+	}
+	if err := packCfg.SetSchedule(in.EffectiveFrom, in.EffectiveTo); err != nil {
+		return nil, nil, err
+	}
+	packCfg.UpdatedBy, packCfg.Reason = in.Actor, in.Reason
 
-	s.logger.Info("pack config found, updating existing one")
-	// Domain Replace mutates sizes and bumps version in one place.
-	if err := packCfg.Replace(packSizes); err != nil {
+	return current, packCfg, nil
+}
+
+// Analyze reports what the current pack sizes of a SKU can ship, solving every
+// amount up to upTo; zero or less picks a default bound.
+func (s *PackConfigService) Analyze(ctx context.Context, sku string, upTo int) (*PackAnalysis, error) {
+	cfg, err := s.GetCurrent(ctx, sku)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil || len(cfg.ActiveSizes()) == 0 {
+		return nil, domain.ErrPackSizesNotConfigured
+	}
+
+	analysis := s.AnalyzeConfig(cfg, upTo)
+	return &analysis, nil
+}
+
+// AnalyzeConfig is Analyze for a config that is already loaded.
+func (s *PackConfigService) AnalyzeConfig(cfg *domain.PackConfig, upTo int) PackAnalysis {
+	if upTo <= 0 {
+		upTo = defaultAnalysisBound(cfg.ActiveSizes())
+	}
+
+	return analyzePackSizes(s.cache.table(cfg), min(upTo, MaxAnalysisBound))
+}
+
+// Recommend searches for the pack sizes that serve a demand histogram with the
+// least expected overfill, then the fewest expected packs, and scores the
+// current sizes of the SKU for comparison.
+func (s *PackConfigService) Recommend(ctx context.Context, in RecommendInput) (*PackSizeRecommendation, error) {
+	cfg, err := s.GetCurrent(ctx, in.SKU)
+	if err != nil {
+		return nil, err
+	}
+
+	var current []int64
+	if cfg != nil {
+		current = cfg.ActiveSizes()
+	}
+
+	return recommendPackSizes(ctx, in, current)
+}
+
+// Delete removes the configuration of a SKU together with its history.
+func (s *PackConfigService) Delete(ctx context.Context, sku string) error {
+	if s.requireReview {
+		return domain.ErrReviewRequired
+	}
+
+	sku, err := domain.NormalizeSKU(sku)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("deleting pack config", "sku", sku)
+	if err := s.repo.Delete(ctx, sku); err != nil {
+		return err
+	}
+	s.cache.Invalidate(sku)
+
+	return nil
+}
+
+// ListVersions returns the configuration history of a SKU, newest version first.
+func (s *PackConfigService) ListVersions(ctx context.Context, sku string) ([]domain.PackConfig, error) {
+	sku, err := domain.NormalizeSKU(sku)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListVersions(ctx, sku)
+}
+
+// ListVersionsPage returns up to limit versions of a SKU below beforeVersion,
+// newest first. A zero beforeVersion starts at the newest version and a zero
+// limit means DefaultHistoryPageSize.
+func (s *PackConfigService) ListVersionsPage(ctx context.Context, sku string, beforeVersion int64, limit int) (*VersionPage, error) {
+	if limit == 0 {
+		limit = DefaultHistoryPageSize
+	}
+	if limit < 0 || limit > MaxHistoryPageSize || beforeVersion < 0 {
+		return nil, domain.ErrInvalidHistoryPage
+	}
+
+	versions, err := s.ListVersions(ctx, sku)
+	if err != nil {
+		return nil, err
+	}
+
+	if beforeVersion > 0 {
+		start := len(versions)
+		for i, v := range versions {
+			if v.Version < beforeVersion {
+				start = i
+				break
+			}
+		}
+		versions = versions[start:]
+	}
+
+	page := &VersionPage{Versions: versions}
+	if len(versions) > limit {
+		page.Versions = versions[:limit]
+		page.NextBeforeVersion = versions[limit-1].Version
+	}
+
+	return page, nil
+}
+
+// GetVersion returns one stored configuration version of a SKU.
+func (s *PackConfigService) GetVersion(ctx context.Context, sku string, version int64) (*domain.PackConfig, error) {
+	sku, err := domain.NormalizeSKU(sku)
+	if err != nil {
 		return nil, err
 	}
 
-	if err := s.repo.Update(ctx, *packCfg); err != nil {
+	packCfg, err := s.repo.GetVersion(ctx, sku, version)
+	if err != nil {
 		return nil, err
 	}
+	if packCfg == nil {
+		return nil, domain.ErrVersionNotFound
+	}
 
 	return packCfg, nil
 }
+
+// Rollback writes a new version that restores the pack sizes and their metadata, stock limits, costs and rules of an older one.
+// History is never rewritten, so a rollback can itself be rolled back. It applies at once: the schedule
+// of the older version is not restored.
+func (s *PackConfigService) Rollback(ctx context.Context, in RollbackInput) (*domain.PackConfig, error) {
+	if s.requireReview {
+		return nil, domain.ErrReviewRequired
+	}
+
+	target, err := s.GetVersion(ctx, in.SKU, in.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := in.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("rollback to version %d", in.Version)
+	}
+
+	s.logger.Info("rolling back pack config", "sku", target.SKU, "target_version", in.Version)
+	restore := replaceInputOf(target)
+	restore.ExpectedVersion, restore.Actor, restore.Reason = in.ExpectedVersion, in.Actor, reason
+	return s.replace(ctx, restore)
+}
+
+// replaceInputOf is the replacement that writes the sizes and settings of cfg
+// as they are: settings cfg does not have are cleared, not kept. The schedule,
+// expected version and audit fields are left for the caller.
+func replaceInputOf(cfg *domain.PackConfig) ReplacePackSizesInput {
+	overfillItemCost := cfg.OverfillItemCost
+	in := ReplacePackSizesInput{
+		SKU:              cfg.SKU,
+		PackSizes:        cfg.PackSizes,
+		Packs:            cfg.ListPacks(),
+		StockLimits:      make(map[int64]int, len(cfg.StockLimits)),
+		PackCosts:        make(map[int64]domain.PackCost, len(cfg.PackCosts)),
+		OverfillItemCost: &overfillItemCost,
+		Rules:            append([]domain.Rule{}, cfg.Rules...),
+	}
+	maps.Copy(in.StockLimits, cfg.StockLimits)
+	maps.Copy(in.PackCosts, cfg.PackCosts)
+
+	return in
+}
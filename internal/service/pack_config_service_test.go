@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"go-packing/internal/domain"
+)
+
+// historyConfigRepo is writableConfigRepo that also keeps every written version.
+type historyConfigRepo struct {
+	writableConfigRepo
+	// rival, when set, stores a version of its own right before the next Update.
+	rival func(stored *domain.PackConfig)
+}
+
+func newHistoryConfigRepo() *historyConfigRepo {
+	return &historyConfigRepo{writableConfigRepo: writableConfigRepo{&stubConfigRepo{
+		configs:  make(map[string]*domain.PackConfig),
+		versions: make(map[string][]domain.PackConfig),
+	}}}
+}
+
+func (r *historyConfigRepo) Create(ctx context.Context, cfg domain.PackConfig) error {
+	if err := r.writableConfigRepo.Create(ctx, cfg); err != nil {
+		return err
+	}
+	r.keep(cfg)
+	return nil
+}
+
+func (r *historyConfigRepo) Update(ctx context.Context, cfg domain.PackConfig) error {
+	if rival := r.rival; rival != nil {
+		r.rival = nil
+		next := *r.configs[cfg.SKU]
+		rival(&next)
+		next.Version++
+		if err := r.Update(ctx, next); err != nil {
+			return err
+		}
+	}
+
+	if err := r.writableConfigRepo.Update(ctx, cfg); err != nil {
+		return err
+	}
+	r.keep(cfg)
+	return nil
+}
+
+func (r *historyConfigRepo) ListVersions(_ context.Context, sku string) ([]domain.PackConfig, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions := make([]domain.PackConfig, 0, len(r.versions[sku]))
+	for i := len(r.versions[sku]) - 1; i >= 0; i-- {
+		versions = append(versions, r.versions[sku][i])
+	}
+	return versions, nil
+}
+
+func (r *historyConfigRepo) GetVersion(_ context.Context, sku string, version int64) (*domain.PackConfig, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, v := range r.versions[sku] {
+		if v.Version == version {
+			return &v, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *historyConfigRepo) keep(cfg domain.PackConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.versions[cfg.SKU] = append(r.versions[cfg.SKU], cfg)
+}
+
+// writeVersions stores one version per size set, starting at version 0.
+func writeVersions(t *testing.T, svc *PackConfigService, sizeSets ...[]int64) {
+	t.Helper()
+	for _, sizes := range sizeSets {
+		if _, err := svc.ReplacePackSizes(context.Background(), ReplacePackSizesInput{PackSizes: sizes}); err != nil {
+			t.Fatalf("replace with %v: %v", sizes, err)
+		}
+	}
+}
+
+func TestReplacePackSizes_KeepsOmittedSettings(t *testing.T) {
+	svc := NewPackConfigService(newHistoryConfigRepo(), nil, slog.Default(), domain.PackSizeLimits{}, false)
+	ctx := context.Background()
+	overfillItemCost := int64(2)
+	rules := []domain.Rule{{Criterion: domain.CriterionPackCount}}
+	box := domain.PackSize{Size: 500, Label: "Medium box", Active: true}
+	_, err := svc.ReplacePackSizes(ctx, ReplacePackSizesInput{
+		PackSizes:        []int64{250, 500, 1000},
+		Packs:            []domain.PackSize{box},
+		StockLimits:      map[int64]int{500: 3, 1000: 1},
+		PackCosts:        map[int64]domain.PackCost{250: {UnitCost: 3}, 500: {UnitCost: 5}, 1000: {UnitCost: 9}},
+		OverfillItemCost: &overfillItemCost,
+		Rules:            rules,
+	})
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	// Only sizes: the settings of the remaining sizes stay, those of 1000 go.
+	cfg, err := svc.ReplacePackSizes(ctx, ReplacePackSizesInput{PackSizes: []int64{250, 500, 2000}})
+	if err != nil {
+		t.Fatalf("sizes only: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Packs, map[int64]domain.PackSize{500: box}) ||
+		!reflect.DeepEqual(cfg.StockLimits, map[int64]int{500: 3}) ||
+		!reflect.DeepEqual(cfg.PackCosts, map[int64]domain.PackCost{250: {UnitCost: 3}, 500: {UnitCost: 5}}) ||
+		cfg.OverfillItemCost != 2 || !reflect.DeepEqual(cfg.Rules, rules) {
+		t.Fatalf("sizes only dropped settings: %+v", cfg)
+	}
+
+	// Empty settings clear them.
+	noCost := int64(0)
+	cfg, err = svc.ReplacePackSizes(ctx, ReplacePackSizesInput{
+		PackSizes:        []int64{250, 500},
+		Packs:            []domain.PackSize{},
+		StockLimits:      map[int64]int{},
+		PackCosts:        map[int64]domain.PackCost{},
+		OverfillItemCost: &noCost,
+		Rules:            []domain.Rule{},
+	})
+	if err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	if len(cfg.Packs) != 0 || len(cfg.StockLimits) != 0 || len(cfg.PackCosts) != 0 || cfg.OverfillItemCost != 0 || len(cfg.Rules) != 0 {
+		t.Fatalf("empty settings were kept: %+v", cfg)
+	}
+
+	// A rollback restores the version exactly, including what it did not have.
+	cfg, err = svc.Rollback(ctx, RollbackInput{Version: 0})
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.StockLimits, map[int64]int{500: 3, 1000: 1}) || len(cfg.PackCosts) != 3 || !reflect.DeepEqual(cfg.Rules, rules) {
+		t.Fatalf("rollback to version 0 = %+v", cfg)
+	}
+	cfg, err = svc.Rollback(ctx, RollbackInput{Version: 2})
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if len(cfg.StockLimits) != 0 || len(cfg.PackCosts) != 0 || cfg.OverfillItemCost != 0 || len(cfg.Rules) != 0 {
+		t.Fatalf("rollback to version 2 kept settings: %+v", cfg)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	repo := newHistoryConfigRepo()
+	svc := NewPackConfigService(repo, nil, slog.Default(), domain.PackSizeLimits{}, false)
+	ctx := context.Background()
+	writeVersions(t, svc, []int64{250}, []int64{250, 500})
+
+	cfg, err := svc.Rollback(ctx, RollbackInput{Version: 0, Actor: "jane"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Version != 2 || !reflect.DeepEqual(cfg.PackSizes, []int64{250}) {
+		t.Fatalf("rolled back config = %+v", cfg)
+	}
+	if cfg.UpdatedBy != "jane" || cfg.Reason != "rollback to version 0" {
+		t.Fatalf("history = %q, %q", cfg.UpdatedBy, cfg.Reason)
+	}
+
+	if _, err := svc.Rollback(ctx, RollbackInput{Version: 7}); !errors.Is(err, domain.ErrVersionNotFound) {
+		t.Fatalf("rollback to a missing version: expected ErrVersionNotFound, got %v", err)
+	}
+	if _, err := svc.Rollback(ctx, RollbackInput{SKU: "other", Version: 0}); !errors.Is(err, domain.ErrVersionNotFound) {
+		t.Fatalf("rollback of a SKU without config: expected ErrVersionNotFound, got %v", err)
+	}
+	if versions, _ := repo.ListVersions(ctx, domain.DefaultSKU); len(versions) != 3 {
+		t.Fatalf("failed rollbacks wrote versions: %d", len(versions))
+	}
+}
+
+func TestRollback_ConcurrentWrite(t *testing.T) {
+	repo := newHistoryConfigRepo()
+	svc := NewPackConfigService(repo, nil, slog.Default(), domain.PackSizeLimits{}, false)
+	ctx := context.Background()
+	writeVersions(t, svc, []int64{250}, []int64{250, 500})
+
+	// The client read version 0, but version 1 was written since.
+	stale := int64(0)
+	if _, err := svc.Rollback(ctx, RollbackInput{Version: 0, ExpectedVersion: &stale}); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Fatalf("stale rollback: expected ErrVersionMismatch, got %v", err)
+	}
+
+	// Another writer lands between the read and the write of the rollback,
+	// and the version CAS keeps its write.
+	repo.rival = func(cfg *domain.PackConfig) { cfg.PackSizes = []int64{1000} }
+	if _, err := svc.Rollback(ctx, RollbackInput{Version: 0}); !errors.Is(err, domain.ErrConcurrencyConflict) {
+		t.Fatalf("racing rollback: expected ErrConcurrencyConflict, got %v", err)
+	}
+
+	current, _ := svc.GetCurrent(ctx, "")
+	if current.Version != 2 || !reflect.DeepEqual(current.PackSizes, []int64{1000}) {
+		t.Fatalf("current config = %+v, want the rival write", current)
+	}
+	if versions, _ := repo.ListVersions(ctx, domain.DefaultSKU); len(versions) != 3 {
+		t.Fatalf("versions = %d, want 3", len(versions))
+	}
+}
+
+func TestListVersionsPage(t *testing.T) {
+	svc := NewPackConfigService(newHistoryConfigRepo(), nil, slog.Default(), domain.PackSizeLimits{}, false)
+	ctx := context.Background()
+	writeVersions(t, svc, []int64{1}, []int64{2}, []int64{3}, []int64{4}, []int64{5})
+
+	var (
+		got    []int64
+		cursor int64
+	)
+	for pages := 0; ; pages++ {
+		page, err := svc.ListVersionsPage(ctx, "", cursor, 2)
+		if err != nil {
+			t.Fatalf("page %d: unexpected error: %v", pages, err)
+		}
+		if len(page.Versions) > 2 || pages > 3 {
+			t.Fatalf("page %d = %+v", pages, page)
+		}
+		for _, v := range page.Versions {
+			got = append(got, v.Version)
+		}
+		if page.NextBeforeVersion == 0 {
+			break
+		}
+		cursor = page.NextBeforeVersion
+	}
+	if !reflect.DeepEqual(got, []int64{4, 3, 2, 1, 0}) {
+		t.Fatalf("versions = %v, want newest first without gaps", got)
+	}
+
+	page, err := svc.ListVersionsPage(ctx, "", 0, 0)
+	if err != nil || len(page.Versions) != 5 || page.NextBeforeVersion != 0 {
+		t.Fatalf("default page = %+v, %v", page, err)
+	}
+	if page, err := svc.ListVersionsPage(ctx, "", 2, 5); err != nil || len(page.Versions) != 2 || page.Versions[0].Version != 1 {
+		t.Fatalf("page before version 2 = %+v, %v", page, err)
+	}
+
+	for _, tt := range []struct {
+		cursor int64
+		limit  int
+	}{{cursor: -1}, {limit: -1}, {limit: MaxHistoryPageSize + 1}} {
+		if _, err := svc.ListVersionsPage(ctx, "", tt.cursor, tt.limit); !errors.Is(err, domain.ErrInvalidHistoryPage) {
+			t.Fatalf("cursor %d, limit %d: expected ErrInvalidHistoryPage, got %v", tt.cursor, tt.limit, err)
+		}
+	}
+}
+
+func TestPackConfigService_RequireReview(t *testing.T) {
+	repo := newHistoryConfigRepo()
+	writeVersions(t, NewPackConfigService(repo, nil, slog.Default(), domain.PackSizeLimits{}, false), []int64{250}, []int64{250, 500})
+	svc := NewPackConfigService(repo, nil, slog.Default(), domain.PackSizeLimits{}, true)
+	ctx := context.Background()
+
+	writes := map[string]func() error{
+		"replace": func() error {
+			_, err := svc.ReplacePackSizes(ctx, ReplacePackSizesInput{PackSizes: []int64{1000}})
+			return err
+		},
+		"add": func() error {
+			_, err := svc.AddPackSize(ctx, EditPackSizeInput{Pack: domain.NewPackSize(1000)})
+			return err
+		},
+		"remove": func() error {
+			_, err := svc.RemovePackSize(ctx, EditPackSizeInput{Pack: domain.NewPackSize(250)})
+			return err
+		},
+		"rollback": func() error {
+			_, err := svc.Rollback(ctx, RollbackInput{Version: 0})
+			return err
+		},
+		"delete": func() error { return svc.Delete(ctx, "") },
+	}
+	for name, write := range writes {
+		if err := write(); !errors.Is(err, domain.ErrReviewRequired) {
+			t.Fatalf("%s: expected ErrReviewRequired, got %v", name, err)
+		}
+	}
+
+	// Previews store nothing and stay allowed.
+	if _, err := svc.PreviewReplace(ctx, ReplacePackSizesInput{PackSizes: []int64{1000}}, []int{1}); err != nil {
+		t.Fatalf("preview: unexpected error: %v", err)
+	}
+	current, _ := svc.GetCurrent(ctx, "")
+	if current.Version != 1 || !reflect.DeepEqual(current.PackSizes, []int64{250, 500}) {
+		t.Fatalf("current config = %+v, want it untouched", current)
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"go-packing/internal/domain"
+)
+
+// MaxPreviewAmounts caps how many sample amounts one preview solves.
+const MaxPreviewAmounts = 100
+
+// ReplacePreview is what a replacement would change, computed without storing it.
+type ReplacePreview struct {
+	// Current is the stored config, nil for a SKU without one.
+	Current  *domain.PackConfig
+	Proposed *domain.PackConfig
+	Added    []int64
+	Removed  []int64
+	// Warnings are the analysis warnings of the proposed sizes.
+	Warnings []string
+	Changes  []BreakdownChange
+}
+
+// BreakdownChange compares how one amount ships before and after a replacement.
+// A side is nil when it has no config or cannot ship the amount.
+type BreakdownChange struct {
+	Amount   int
+	Current  *Alternative
+	Proposed *Alternative
+	Changed  bool
+}
+
+// PreviewReplace validates a replacement exactly like ReplacePackSizes and
+// reports its impact on the sample amounts, leaving storage and cache untouched.
+func (s *PackConfigService) PreviewReplace(ctx context.Context, in ReplacePackSizesInput, amounts []int) (*ReplacePreview, error) {
+	if len(amounts) > MaxPreviewAmounts {
+		return nil, domain.ErrInvalidAmount
+	}
+	for _, amount := range amounts {
+		if err := domain.ValidateAmount(amount); err != nil {
+			return nil, err
+		}
+	}
+
+	current, proposed, err := s.prepareReplace(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &ReplacePreview{Current: current, Proposed: proposed}
+	var currentSizes []int64
+	if current != nil {
+		currentSizes = current.PackSizes
+	}
+	for _, size := range proposed.PackSizes {
+		if !slices.Contains(currentSizes, size) {
+			preview.Added = append(preview.Added, size)
+		}
+	}
+	for _, size := range currentSizes {
+		if !proposed.HasSize(size) {
+			preview.Removed = append(preview.Removed, size)
+		}
+	}
+
+	// The proposed table must stay out of the cache: its version may never be stored.
+	proposedTable := newPackTable(normalizePackSizes(proposed.ActiveSizes()))
+	preview.Warnings = analyzePackSizes(proposedTable, defaultAnalysisBound(proposed.ActiveSizes())).Warnings
+
+	for _, amount := range amounts {
+		change := BreakdownChange{Amount: amount}
+		if current != nil && len(current.PackSizes) > 0 {
+			change.Current = previewBreakdown(current, amount, s.cache.table)
+		}
+		change.Proposed = previewBreakdown(proposed, amount, func(*domain.PackConfig) *packTable { return proposedTable })
+		if change.Current == nil || change.Proposed == nil {
+			change.Changed = change.Current != change.Proposed
+		} else {
+			change.Changed = !slices.Equal(change.Current.Packs, change.Proposed.Packs)
+		}
+		preview.Changes = append(preview.Changes, change)
+	}
+
+	return preview, nil
+}
+
+// previewBreakdown is what calculate returns for amount, or nil when it fails.
+func previewBreakdown(cfg *domain.PackConfig, amount int, table func(*domain.PackConfig) *packTable) *Alternative {
+	result, err := solveConfig(cfg, CalculateInput{Amount: amount, Objective: domain.ObjectiveMinOverfill}, table)
+	if err != nil {
+		return nil
+	}
+
+	alt := newAlternative(result.Packs, amount, "")
+	return &alt
+}
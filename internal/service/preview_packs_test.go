@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"go-packing/internal/domain"
+)
+
+func TestPreviewReplace(t *testing.T) {
+	stored, _ := domain.NewPackConfig([]int64{250, 500, 1000, 2000, 5000})
+	stored.Version = 4
+	stored.StockLimits = map[int64]int{5000: 3}
+	stored.PackCosts = map[int64]domain.PackCost{5000: {UnitCost: 10}}
+	// The stub panics on Create and Update, so a preview that writes fails the test.
+	repo := &stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: stored}}
+	svc := NewPackConfigService(repo, NewSolverCache(1<<20), slog.Default(), domain.PackSizeLimits{}, false)
+
+	version := int64(4)
+	preview, err := svc.PreviewReplace(context.Background(), ReplacePackSizesInput{
+		PackSizes:       []int64{300, 250, 500, 1000},
+		ExpectedVersion: &version,
+	}, []int{250, 251, 12001})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(preview.Added, []int64{300}) || !reflect.DeepEqual(preview.Removed, []int64{2000, 5000}) {
+		t.Fatalf("added = %v, removed = %v", preview.Added, preview.Removed)
+	}
+	if preview.Proposed.Version != 5 || len(preview.Warnings) == 0 {
+		t.Fatalf("proposed version = %d, warnings = %v", preview.Proposed.Version, preview.Warnings)
+	}
+
+	if len(preview.Changes) != 3 {
+		t.Fatalf("changes = %+v", preview.Changes)
+	}
+	if same := preview.Changes[0]; same.Changed || same.Proposed.Total != 250 {
+		t.Fatalf("250 change = %+v, want unchanged", same)
+	}
+	if small := preview.Changes[1]; !small.Changed || small.Current.Total != 500 || small.Proposed.Total != 300 {
+		t.Fatalf("251 change = %+v, want 500 then 300", small)
+	}
+	if large := preview.Changes[2]; !large.Changed || large.Current.PackCount != 4 || large.Proposed.Total != 12050 {
+		t.Fatalf("12001 change = %+v", large)
+	}
+
+	if !reflect.DeepEqual(stored.PackSizes, []int64{250, 500, 1000, 2000, 5000}) || stored.Version != 4 ||
+		stored.StockLimits[5000] != 3 || len(stored.PackCosts) != 1 {
+		t.Fatalf("stored config was modified: %+v", stored)
+	}
+}
+
+func TestPreviewReplace_Rejects(t *testing.T) {
+	stored, _ := domain.NewPackConfig([]int64{250, 500})
+	repo := &stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: stored}}
+	svc := NewPackConfigService(repo, nil, slog.Default(), domain.PackSizeLimits{}, false)
+
+	stale := int64(7)
+	tests := []struct {
+		name    string
+		in      ReplacePackSizesInput
+		amounts []int
+		want    error
+	}{
+		{name: "stale version", in: ReplacePackSizesInput{PackSizes: []int64{250}, ExpectedVersion: &stale}, want: domain.ErrVersionMismatch},
+		{name: "stock of unknown size", in: ReplacePackSizesInput{PackSizes: []int64{250}, StockLimits: map[int64]int{500: 1}}, want: domain.ErrInvalidStockLimits},
+		{name: "zero amount", in: ReplacePackSizesInput{PackSizes: []int64{250}}, amounts: []int{0}, want: domain.ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.PreviewReplace(context.Background(), tt.in, tt.amounts); !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go-packing/internal/domain"
+)
+
+const (
+	// MaxDemandBuckets caps how many distinct amounts a histogram may list.
+	MaxDemandBuckets = 1000
+	// MaxRecommendedSizes caps how many pack sizes a recommendation may pick.
+	MaxRecommendedSizes = 10
+	// MaxRecommendations caps how many ranked sets are returned.
+	MaxRecommendations = 10
+	// MaxRecommendedPackSize keeps every searched table small.
+	MaxRecommendedPackSize = 100_000
+	// MaxCandidateSizes caps the sizes the search picks from.
+	MaxCandidateSizes = 100
+
+	defaultRecommendations = 5
+	// maxSetEvaluations bounds the search; smaller spaces are searched exhaustively.
+	maxSetEvaluations = 1000
+)
+
+// DemandBucket is how many historical orders asked for one amount.
+type DemandBucket struct {
+	Amount int
+	Count  int
+}
+
+// RecommendInput describes a pack-size recommendation.
+type RecommendInput struct {
+	// SKU selects the configuration the candidates are compared to.
+	SKU       string
+	Histogram []DemandBucket
+	// SizeCount is how many pack sizes a candidate set has, FixedSizes included.
+	SizeCount  int
+	FixedSizes []int64
+	// CandidateSizes are the sizes to pick from; empty means the most demanded
+	// amounts. The current sizes of the SKU are always candidates.
+	CandidateSizes []int64
+	// Top is how many sets to return; zero picks a default.
+	Top int
+}
+
+// PackSizeRecommendation ranks pack-size sets by expected overfill, then by
+// expected pack count, over the demand of a histogram.
+type PackSizeRecommendation struct {
+	Candidates []RecommendedPackSizes
+	// Current scores the configured sizes of the SKU, nil when none are configured.
+	Current *RecommendedPackSizes
+	// Evaluated is how many sets were scored; Exhaustive is set when that was all of them.
+	Evaluated  int
+	Exhaustive bool
+}
+
+// RecommendedPackSizes is one set of pack sizes with its expected metrics per order.
+type RecommendedPackSizes struct {
+	PackSizes         []int64
+	ExpectedOverfill  float64
+	ExpectedPackCount float64
+	// OverfillPct is the total overfill as a percentage of the total demand.
+	OverfillPct float64
+	// ExactShare is the share of orders shipped without overfill.
+	ExactShare float64
+}
+
+// demandScore sums the outcome of every order in a histogram; lower is better.
+type demandScore struct {
+	overfill int64
+	packs    int64
+	exact    int64
+}
+
+func (a demandScore) compare(b demandScore) int {
+	return cmp.Or(cmp.Compare(a.overfill, b.overfill), cmp.Compare(a.packs, b.packs))
+}
+
+// scoreDemand answers every order of the histogram with calculate's rules.
+func scoreDemand(sizes []int, demand []DemandBucket) demandScore {
+	t := newPackTable(sizes)
+	amounts := make([]int, len(demand))
+	for i, b := range demand {
+		amounts[i] = b.Amount
+	}
+	totals := t.minimalTotals(amounts)
+	counter := newPackCounter(t, slices.Max(totals))
+
+	var score demandScore
+	for i, b := range demand {
+		total := totals[i]
+		packs := 0
+		for _, n := range counter.counts(total) {
+			packs += n
+		}
+
+		score.overfill += int64(total-b.Amount) * int64(b.Count)
+		score.packs += int64(packs) * int64(b.Count)
+		if total == b.Amount {
+			score.exact += int64(b.Count)
+		}
+	}
+
+	return score
+}
+
+// validateRecommendInput rejects histograms and size counts the search cannot serve.
+func validateRecommendInput(in RecommendInput) error {
+	if len(in.Histogram) == 0 || len(in.Histogram) > MaxDemandBuckets {
+		return domain.ErrInvalidRecommendation
+	}
+	for _, b := range in.Histogram {
+		if domain.ValidateAmount(b.Amount) != nil || b.Count <= 0 {
+			return domain.ErrInvalidRecommendation
+		}
+	}
+	if in.SizeCount < 1 || in.SizeCount > MaxRecommendedSizes || in.Top < 0 || in.Top > MaxRecommendations {
+		return domain.ErrInvalidRecommendation
+	}
+	if len(in.FixedSizes) > in.SizeCount || len(in.CandidateSizes) > MaxCandidateSizes {
+		return domain.ErrInvalidRecommendation
+	}
+	for _, sizes := range [][]int64{in.FixedSizes, in.CandidateSizes} {
+		for _, size := range sizes {
+			if size <= 0 || size > MaxRecommendedPackSize {
+				return domain.ErrInvalidRecommendation
+			}
+		}
+	}
+	if len(normalizePackSizes(in.FixedSizes)) != len(in.FixedSizes) {
+		return domain.ErrInvalidRecommendation
+	}
+
+	return nil
+}
+
+// candidatePool returns the sizes the search may add next to the fixed ones.
+func candidatePool(in RecommendInput, current []int64) []int {
+	pool := slices.Clone(in.CandidateSizes)
+	if len(pool) == 0 {
+		// Sizes equal to frequent amounts ship those orders exactly.
+		buckets := slices.Clone(in.Histogram)
+		slices.SortFunc(buckets, func(a, b DemandBucket) int {
+			return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Amount, b.Amount))
+		})
+		for _, b := range buckets {
+			if len(pool) == MaxCandidateSizes {
+				break
+			}
+			if b.Amount <= MaxRecommendedPackSize {
+				pool = append(pool, int64(b.Amount))
+			}
+		}
+	}
+	pool = append(pool, current...)
+
+	return slices.DeleteFunc(normalizePackSizes(pool), func(size int) bool {
+		return slices.Contains(in.FixedSizes, int64(size))
+	})
+}
+
+// packSizeSearch scores pack-size sets once each and keeps every full set it scored.
+type packSizeSearch struct {
+	ctx    context.Context
+	demand []DemandBucket
+	size   int
+	scored map[string]demandScore
+	full   map[string][]int
+}
+
+func (s *packSizeSearch) score(sizes []int) (demandScore, error) {
+	sizes = slices.Clone(sizes)
+	slices.Sort(sizes)
+	key := setKey(sizes)
+	if score, ok := s.scored[key]; ok {
+		return score, nil
+	}
+	if err := s.ctx.Err(); err != nil {
+		return demandScore{}, err
+	}
+
+	score := scoreDemand(sizes, s.demand)
+	s.scored[key] = score
+	if len(sizes) == s.size {
+		s.full[key] = sizes
+	}
+
+	return score, nil
+}
+
+func (s *packSizeSearch) exhausted() bool {
+	return len(s.scored) >= maxSetEvaluations
+}
+
+// exhaustive scores every way to add free sizes from pool to fixed.
+func (s *packSizeSearch) exhaustive(fixed, pool []int, free int) error {
+	chosen := slices.Clone(fixed)
+	var walk func(from, left int) error
+	walk = func(from, left int) error {
+		if left == 0 {
+			_, err := s.score(chosen)
+			return err
+		}
+		for i := from; i <= len(pool)-left; i++ {
+			chosen = append(chosen, pool[i])
+			if err := walk(i+1, left-1); err != nil {
+				return err
+			}
+			chosen = chosen[:len(chosen)-1]
+		}
+
+		return nil
+	}
+
+	return walk(0, free)
+}
+
+// local adds the best size greedily until the set is full, then swaps single
+// sizes for better ones until nothing improves or the budget runs out.
+func (s *packSizeSearch) local(fixed, pool []int, free int) error {
+	chosen := slices.Clone(fixed)
+	var best demandScore
+	for len(chosen) < len(fixed)+free {
+		next := -1
+		for _, size := range pool {
+			if slices.Contains(chosen, size) {
+				continue
+			}
+			score, err := s.score(append(chosen, size))
+			if err != nil {
+				return err
+			}
+			if next < 0 || score.compare(best) < 0 {
+				next, best = size, score
+			}
+		}
+		chosen = append(chosen, next)
+	}
+
+	for improved := true; improved && !s.exhausted(); {
+		improved = false
+		for i := len(fixed); i < len(chosen) && !s.exhausted(); i++ {
+			for _, size := range pool {
+				if slices.Contains(chosen, size) {
+					continue
+				}
+				swapped := slices.Clone(chosen)
+				swapped[i] = size
+				score, err := s.score(swapped)
+				if err != nil {
+					return err
+				}
+				if score.compare(best) < 0 {
+					chosen, best, improved = swapped, score, true
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ranked returns the best full sets, ties broken by the smaller sizes.
+func (s *packSizeSearch) ranked(top int) []RecommendedPackSizes {
+	sets := make([][]int, 0, len(s.full))
+	for _, sizes := range s.full {
+		sets = append(sets, sizes)
+	}
+	slices.SortFunc(sets, func(a, b []int) int {
+		return cmp.Or(s.scored[setKey(a)].compare(s.scored[setKey(b)]), slices.Compare(a, b))
+	})
+
+	result := make([]RecommendedPackSizes, 0, min(top, len(sets)))
+	for _, sizes := range sets[:min(top, len(sets))] {
+		packSizes := make([]int64, len(sizes))
+		for i, size := range sizes {
+			packSizes[i] = int64(size)
+		}
+		result = append(result, newRecommendedPackSizes(packSizes, s.scored[setKey(sizes)], s.demand))
+	}
+
+	return result
+}
+
+func newRecommendedPackSizes(packSizes []int64, score demandScore, demand []DemandBucket) RecommendedPackSizes {
+	var orders, items int64
+	for _, b := range demand {
+		orders += int64(b.Count)
+		items += int64(b.Amount) * int64(b.Count)
+	}
+
+	return RecommendedPackSizes{
+		PackSizes:         packSizes,
+		ExpectedOverfill:  float64(score.overfill) / float64(orders),
+		ExpectedPackCount: float64(score.packs) / float64(orders),
+		OverfillPct:       float64(score.overfill) * 100 / float64(items),
+		ExactShare:        float64(score.exact) / float64(orders),
+	}
+}
+
+// recommendPackSizes searches for the SizeCount sizes that serve the demand best.
+// current are the configured sizes of the SKU, possibly nil.
+func recommendPackSizes(ctx context.Context, in RecommendInput, current []int64) (*PackSizeRecommendation, error) {
+	if err := validateRecommendInput(in); err != nil {
+		return nil, err
+	}
+
+	fixed := normalizePackSizes(in.FixedSizes)
+	pool := candidatePool(in, current)
+	free := in.SizeCount - len(fixed)
+	if free > len(pool) {
+		return nil, domain.ErrInvalidRecommendation
+	}
+
+	search := &packSizeSearch{
+		ctx:    ctx,
+		demand: in.Histogram,
+		size:   in.SizeCount,
+		scored: make(map[string]demandScore),
+		full:   make(map[string][]int),
+	}
+	exhaustive := binomialAtMost(len(pool), free, maxSetEvaluations)
+	var err error
+	if exhaustive {
+		err = search.exhaustive(fixed, pool, free)
+	} else {
+		err = search.local(fixed, pool, free)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	top := in.Top
+	if top == 0 {
+		top = defaultRecommendations
+	}
+	result := &PackSizeRecommendation{
+		Candidates: search.ranked(top),
+		Evaluated:  len(search.scored),
+		Exhaustive: exhaustive,
+	}
+	if sizes := normalizePackSizes(current); len(sizes) > 0 {
+		currentSet := newRecommendedPackSizes(current, scoreDemand(sizes, in.Histogram), in.Histogram)
+		result.Current = &currentSet
+	}
+
+	return result, nil
+}
+
+// binomialAtMost reports whether n choose k is at most limit.
+func binomialAtMost(n, k, limit int) bool {
+	k = min(k, n-k)
+	c := 1
+	for i := 1; i <= k; i++ {
+		c = c * (n - k + i) / i
+		if c > limit {
+			return false
+		}
+	}
+
+	return true
+}
+
+func setKey(sizes []int) string {
+	var b strings.Builder
+	for i, size := range sizes {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Itoa(size))
+	}
+
+	return b.String()
+}
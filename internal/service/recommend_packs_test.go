@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go-packing/internal/domain"
+)
+
+func TestRecommendPackSizes_Exhaustive(t *testing.T) {
+	in := RecommendInput{
+		Histogram: []DemandBucket{{Amount: 250, Count: 10}, {Amount: 500, Count: 5}, {Amount: 750, Count: 3}},
+		SizeCount: 2,
+	}
+
+	got, err := recommendPackSizes(context.Background(), in, []int64{500, 750})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.Exhaustive || got.Evaluated != 3 {
+		t.Fatalf("exhaustive = %v, evaluated = %d, want true and 3", got.Exhaustive, got.Evaluated)
+	}
+
+	best := got.Candidates[0]
+	if !reflect.DeepEqual(best.PackSizes, []int64{250, 500}) {
+		t.Fatalf("best = %v, want [250 500]", best.PackSizes)
+	}
+	// 250 and 500 ship in one pack each, 750 in two.
+	if best.ExpectedOverfill != 0 || best.ExpectedPackCount != 21.0/18 || best.ExactShare != 1 {
+		t.Fatalf("best metrics = %+v", best)
+	}
+
+	if got.Current == nil || !reflect.DeepEqual(got.Current.PackSizes, []int64{500, 750}) {
+		t.Fatalf("current = %+v, want [500 750]", got.Current)
+	}
+	// 250 orders ship 500.
+	if got.Current.ExpectedOverfill != 2500.0/18 {
+		t.Fatalf("current overfill = %v, want %v", got.Current.ExpectedOverfill, 2500.0/18)
+	}
+}
+
+func TestRecommendPackSizes_FixedSizesStay(t *testing.T) {
+	in := RecommendInput{
+		Histogram:  []DemandBucket{{Amount: 120, Count: 50}, {Amount: 240, Count: 20}, {Amount: 1000, Count: 1}},
+		SizeCount:  2,
+		FixedSizes: []int64{1000},
+		Top:        1,
+	}
+
+	got, err := recommendPackSizes(context.Background(), in, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Candidates) != 1 || !reflect.DeepEqual(got.Candidates[0].PackSizes, []int64{120, 1000}) {
+		t.Fatalf("candidates = %+v, want [120 1000]", got.Candidates)
+	}
+	if got.Current != nil {
+		t.Fatalf("current = %+v, want nil", got.Current)
+	}
+}
+
+func TestRecommendPackSizes_LocalSearch(t *testing.T) {
+	histogram := make([]DemandBucket, 0, 60)
+	for i := 1; i <= 60; i++ {
+		histogram = append(histogram, DemandBucket{Amount: i * 37, Count: 61 - i})
+	}
+	current := []int64{250, 500, 1000}
+	in := RecommendInput{Histogram: histogram, SizeCount: 4}
+
+	got, err := recommendPackSizes(context.Background(), in, current)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Exhaustive || got.Evaluated > maxSetEvaluations+len(histogram)+len(current) {
+		t.Fatalf("exhaustive = %v, evaluated = %d", got.Exhaustive, got.Evaluated)
+	}
+	// 37 divides every amount, so the search should ship everything exactly.
+	best := got.Candidates[0]
+	if len(best.PackSizes) != 4 || best.ExpectedOverfill != 0 {
+		t.Fatalf("best = %+v", best)
+	}
+	if best.ExpectedOverfill > got.Current.ExpectedOverfill {
+		t.Fatalf("best overfill %v is worse than current %v", best.ExpectedOverfill, got.Current.ExpectedOverfill)
+	}
+}
+
+func TestRecommendPackSizes_InvalidInput(t *testing.T) {
+	valid := []DemandBucket{{Amount: 10, Count: 1}}
+	tests := []struct {
+		name string
+		in   RecommendInput
+	}{
+		{name: "empty histogram", in: RecommendInput{SizeCount: 1}},
+		{name: "zero count", in: RecommendInput{Histogram: []DemandBucket{{Amount: 10}}, SizeCount: 1}},
+		{name: "no sizes", in: RecommendInput{Histogram: valid}},
+		{name: "too many sizes", in: RecommendInput{Histogram: valid, SizeCount: MaxRecommendedSizes + 1}},
+		{name: "more fixed than sizes", in: RecommendInput{Histogram: valid, SizeCount: 1, FixedSizes: []int64{5, 10}}},
+		{name: "duplicate fixed", in: RecommendInput{Histogram: valid, SizeCount: 3, FixedSizes: []int64{5, 5}}},
+		{name: "not enough candidates", in: RecommendInput{Histogram: valid, SizeCount: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := recommendPackSizes(context.Background(), tt.in, nil)
+			if !errors.Is(err, domain.ErrInvalidRecommendation) {
+				t.Fatalf("error = %v, want %v", err, domain.ErrInvalidRecommendation)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+// ReplayInput selects the calculations to replay and the config to replay them on.
+type ReplayInput struct {
+	// Filter selects stored calculations; Limit is ignored. It is not used when
+	// Calculations is set.
+	Filter domain.CalculationFilter
+	// Calculations replays an export instead of the stored history.
+	Calculations []domain.Calculation
+	// Version replays every calculation on that version of its SKU; nil uses
+	// the version active now.
+	Version *int64
+	// MaxCalculations caps how many calculations one replay reads; 0 means no cap.
+	MaxCalculations int
+}
+
+// ReplayReport lists the calculations whose answer would now be different.
+type ReplayReport struct {
+	Replayed  int
+	Unchanged int
+	Changed   []ReplayChange
+	Failed    []ReplayFailure
+	// OverfillDelta and PackCountDelta sum the deltas of every changed answer.
+	OverfillDelta  int64
+	PackCountDelta int64
+}
+
+// ReplayChange is a calculation whose breakdown changed. The deltas are the
+// replayed answer minus the stored one.
+type ReplayChange struct {
+	CalculationID  int64
+	SKU            string
+	Amount         int
+	StoredVersion  int64
+	ReplayVersion  int64
+	Before         []domain.PackBreakdown
+	After          []domain.PackBreakdown
+	OverfillDelta  int
+	PackCountDelta int
+}
+
+// ReplayFailure is a calculation that could not be replayed, e.g. because its
+// SKU has no such version or its amount can no longer be packed.
+type ReplayFailure struct {
+	CalculationID int64
+	SKU           string
+	Amount        int
+	Err           error
+}
+
+// replayPageSize is how many stored calculations a replay reads per query.
+const replayPageSize = MaxCalculationPageSize
+
+// Replay solves past calculations again, with their stored options, on the
+// current or the chosen config version of their SKU and reports every answer
+// that changed. progress, which may be nil, is called after each calculation.
+// Replayed answers are not stored in history.
+func (s *CalculateService) Replay(ctx context.Context, in ReplayInput, progress func(done, total int)) (*ReplayReport, error) {
+	calcs := in.Calculations
+	if calcs == nil {
+		var err error
+		if calcs, err = s.storedCalculations(ctx, in.Filter, in.MaxCalculations); err != nil {
+			return nil, err
+		}
+	}
+	if in.MaxCalculations > 0 && len(calcs) > in.MaxCalculations {
+		return nil, domain.ErrReplayTooLarge
+	}
+
+	report := &ReplayReport{Changed: make([]ReplayChange, 0), Failed: make([]ReplayFailure, 0)}
+	configs := make(map[string]*domain.PackConfig)
+	configErrs := make(map[string]error)
+	now := time.Now().UTC()
+
+	for i, calc := range calcs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Exports may leave the default SKU out; report it by name.
+		if sku, err := domain.NormalizeSKU(calc.SKU); err == nil {
+			calc.SKU = sku
+		}
+
+		after, version, err := s.replayOne(ctx, calc, in.Version, now, configs, configErrs)
+		if err != nil {
+			report.Failed = append(report.Failed, ReplayFailure{CalculationID: calc.ID, SKU: calc.SKU, Amount: calc.Amount, Err: err})
+		} else {
+			report.Replayed++
+			if sameBreakdown(calc.Packs, after) {
+				report.Unchanged++
+			} else {
+				change := ReplayChange{
+					CalculationID:  calc.ID,
+					SKU:            calc.SKU,
+					Amount:         calc.Amount,
+					StoredVersion:  calc.ConfigVersion,
+					ReplayVersion:  version,
+					Before:         calc.Packs,
+					After:          after,
+					OverfillDelta:  shippedTotal(after) - shippedTotal(calc.Packs),
+					PackCountDelta: packCount(after) - packCount(calc.Packs),
+				}
+				report.Changed = append(report.Changed, change)
+				report.OverfillDelta += int64(change.OverfillDelta)
+				report.PackCountDelta += int64(change.PackCountDelta)
+			}
+		}
+
+		if progress != nil {
+			progress(i+1, len(calcs))
+		}
+	}
+
+	return report, nil
+}
+
+// replayOne solves one calculation on its replay config, which is loaded once per SKU.
+func (s *CalculateService) replayOne(ctx context.Context, calc domain.Calculation, version *int64, now time.Time, configs map[string]*domain.PackConfig, configErrs map[string]error) ([]domain.PackBreakdown, int64, error) {
+	in, err := normalizeCalculateInput(CalculateInput{
+		Amount:      calc.Amount,
+		SKU:         calc.SKU,
+		StockLimits: calc.Options.StockLimits,
+		Objective:   calc.Options.Objective,
+		Rules:       calc.Options.Rules,
+		AsOf:        now,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if _, loaded := configErrs[in.SKU]; !loaded {
+		configs[in.SKU], configErrs[in.SKU] = s.replayConfig(ctx, in.SKU, version, now)
+	}
+	if err := configErrs[in.SKU]; err != nil {
+		return nil, 0, err
+	}
+
+	cfg := configs[in.SKU]
+	result, err := s.solveRecovered(cfg, in)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result.Packs, cfg.Version, nil
+}
+
+// replayConfig loads the chosen version of a SKU, or the one active at now.
+func (s *CalculateService) replayConfig(ctx context.Context, sku string, version *int64, now time.Time) (*domain.PackConfig, error) {
+	if version == nil {
+		return s.loadConfig(ctx, sku, now)
+	}
+
+	cfg, err := s.repo.GetVersion(ctx, sku, *version)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return nil, domain.ErrVersionNotFound
+	}
+	if len(cfg.ActiveSizes()) == 0 {
+		return nil, domain.ErrPackSizesNotConfigured
+	}
+
+	return cfg, nil
+}
+
+// storedCalculations reads every stored calculation that matches filter,
+// oldest first, failing with ErrReplayTooLarge past limit.
+func (s *CalculateService) storedCalculations(ctx context.Context, filter domain.CalculationFilter, limit int) ([]domain.Calculation, error) {
+	calcs := make([]domain.Calculation, 0)
+	filter.BeforeID, filter.Limit = 0, replayPageSize
+	for {
+		page, err := s.ListCalculations(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		calcs = append(calcs, page.Calculations...)
+		if limit > 0 && len(calcs) > limit {
+			return nil, domain.ErrReplayTooLarge
+		}
+		if page.NextBeforeID == 0 {
+			break
+		}
+		filter.BeforeID = page.NextBeforeID
+	}
+	slices.Reverse(calcs)
+
+	return calcs, nil
+}
+
+// sameBreakdown reports whether two breakdowns ship the same packs, whatever
+// the order of their lines.
+func sameBreakdown(a, b []domain.PackBreakdown) bool {
+	counts := make(map[int]int, len(a))
+	for _, line := range a {
+		counts[line.Size] += line.Count
+	}
+	for _, line := range b {
+		counts[line.Size] -= line.Count
+	}
+	for _, n := range counts {
+		if n != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func packCount(packs []domain.PackBreakdown) int {
+	count := 0
+	for _, p := range packs {
+		count += p.Count
+	}
+
+	return count
+}
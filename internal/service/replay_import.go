@@ -0,0 +1,175 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go-packing/internal/domain"
+)
+
+// Formats of a calculation export.
+const (
+	ExportNDJSON = "ndjson"
+	ExportCSV    = "csv"
+)
+
+// exportedCalculation is one NDJSON line of an export, shaped like a
+// calculation of GET /api/v1/calculations. Other fields are ignored.
+type exportedCalculation struct {
+	ID            int64                     `json:"id"`
+	SKU           string                    `json:"sku"`
+	Amount        int                       `json:"amount"`
+	Options       domain.CalculationOptions `json:"options"`
+	ConfigVersion int64                     `json:"config_version"`
+	Packs         []domain.PackBreakdown    `json:"packs"`
+}
+
+// ReadCalculations decodes an export in format, one calculation per NDJSON
+// line or CSV row, and fails with ErrReplayTooLarge past limit; 0 means no cap.
+//
+// A CSV export needs a header row naming its columns: amount and packs are
+// required, id, sku, objective and config_version are optional. packs lists
+// size:count pairs separated by ";", e.g. "500:1;250:1".
+func ReadCalculations(r io.Reader, format string, limit int) ([]domain.Calculation, error) {
+	switch format {
+	case ExportNDJSON:
+		return readCalculationsNDJSON(r, limit)
+	case ExportCSV:
+		return readCalculationsCSV(r, limit)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", domain.ErrInvalidExport, format)
+	}
+}
+
+func readCalculationsNDJSON(r io.Reader, limit int) ([]domain.Calculation, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	calcs := make([]domain.Calculation, 0)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var rec exportedCalculation
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", domain.ErrInvalidExport, line, err)
+		}
+		calcs = append(calcs, domain.Calculation{
+			ID:            rec.ID,
+			SKU:           rec.SKU,
+			Amount:        rec.Amount,
+			Options:       rec.Options,
+			ConfigVersion: rec.ConfigVersion,
+			Packs:         rec.Packs,
+		})
+		if limit > 0 && len(calcs) > limit {
+			return nil, domain.ErrReplayTooLarge
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidExport, err)
+	}
+
+	return calcs, nil
+}
+
+func readCalculationsCSV(r io.Reader, limit int) ([]domain.Calculation, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: header row: %v", domain.ErrInvalidExport, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"amount", "packs"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: column %q is missing", domain.ErrInvalidExport, required)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	calcs := make([]domain.Calculation, 0)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return calcs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidExport, err)
+		}
+
+		calc := domain.Calculation{
+			SKU:     field(record, "sku"),
+			Options: domain.CalculationOptions{Objective: domain.Objective(field(record, "objective"))},
+		}
+		if calc.Amount, err = strconv.Atoi(field(record, "amount")); err != nil {
+			return nil, fmt.Errorf("%w: line %d: amount is not a number", domain.ErrInvalidExport, line)
+		}
+		if raw := field(record, "id"); raw != "" {
+			if calc.ID, err = strconv.ParseInt(raw, 10, 64); err != nil {
+				return nil, fmt.Errorf("%w: line %d: id is not a number", domain.ErrInvalidExport, line)
+			}
+		}
+		if raw := field(record, "config_version"); raw != "" {
+			if calc.ConfigVersion, err = strconv.ParseInt(raw, 10, 64); err != nil {
+				return nil, fmt.Errorf("%w: line %d: config_version is not a number", domain.ErrInvalidExport, line)
+			}
+		}
+		if calc.Packs, err = parsePackLines(field(record, "packs")); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", domain.ErrInvalidExport, line, err)
+		}
+
+		calcs = append(calcs, calc)
+		if limit > 0 && len(calcs) > limit {
+			return nil, domain.ErrReplayTooLarge
+		}
+	}
+}
+
+// parsePackLines reads "size:count" pairs separated by ";".
+func parsePackLines(raw string) ([]domain.PackBreakdown, error) {
+	packs := make([]domain.PackBreakdown, 0)
+	for _, pair := range strings.Split(raw, ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		sizeText, countText, ok := strings.Cut(pair, ":")
+		size, sizeErr := strconv.Atoi(strings.TrimSpace(sizeText))
+		count, countErr := strconv.Atoi(strings.TrimSpace(countText))
+		if !ok || sizeErr != nil || countErr != nil || size <= 0 || count <= 0 {
+			return nil, fmt.Errorf("packs entry %q is not size:count", pair)
+		}
+		packs = append(packs, domain.PackBreakdown{Size: size, Count: count})
+	}
+
+	return packs, nil
+}
+
+// ExportFormatOf guesses the format of an export from its file name: CSV for
+// a .csv file, NDJSON otherwise.
+func ExportFormatOf(name string) string {
+	if strings.EqualFold(filepath.Ext(name), ".csv") {
+		return ExportCSV
+	}
+
+	return ExportNDJSON
+}
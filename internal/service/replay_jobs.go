@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+// Statuses of a replay job.
+const (
+	ReplayRunning = "running"
+	ReplayDone    = "done"
+	ReplayFailed  = "failed"
+)
+
+// maxFinishedReplays is how many finished replay jobs are kept for polling.
+const maxFinishedReplays = 100
+
+// ReplayJob is a snapshot of an asynchronous replay.
+type ReplayJob struct {
+	ID     int64
+	Status string
+	// Done and Total count replayed calculations; Total is 0 until the
+	// calculations are loaded.
+	Done       int
+	Total      int
+	Report     *ReplayReport
+	Err        error
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// ReplayJobs runs replays in the background and keeps their progress in
+// memory, so it is lost on restart and not shared between instances.
+type ReplayJobs struct {
+	calc            *CalculateService
+	logger          *slog.Logger
+	maxCalculations int
+	maxRunning      int
+
+	ctx      context.Context
+	mu       sync.Mutex
+	nextID   int64
+	running  int
+	jobs     map[int64]*ReplayJob
+	finished []int64
+}
+
+// NewReplayJobs creates a job runner whose replays stop when ctx is done.
+// maxCalculations caps each replay and maxRunning how many replays run at
+// once; 0 means no cap.
+func NewReplayJobs(ctx context.Context, calc *CalculateService, logger *slog.Logger, maxCalculations, maxRunning int) *ReplayJobs {
+	return &ReplayJobs{
+		calc:            calc,
+		logger:          logger,
+		maxCalculations: maxCalculations,
+		maxRunning:      maxRunning,
+		ctx:             ctx,
+		jobs:            make(map[int64]*ReplayJob),
+	}
+}
+
+// Start validates in and starts replaying it in the background. It fails with
+// ErrReplayBusy while maxRunning replays are still running.
+func (j *ReplayJobs) Start(in ReplayInput) (ReplayJob, error) {
+	if in.Calculations == nil {
+		filter, err := normalizeCalculationFilter(in.Filter)
+		if err != nil {
+			return ReplayJob{}, err
+		}
+		in.Filter = filter
+	}
+	in.MaxCalculations = j.maxCalculations
+	if in.MaxCalculations > 0 && len(in.Calculations) > in.MaxCalculations {
+		return ReplayJob{}, domain.ErrReplayTooLarge
+	}
+
+	j.mu.Lock()
+	if j.maxRunning > 0 && j.running >= j.maxRunning {
+		j.mu.Unlock()
+		return ReplayJob{}, domain.ErrReplayBusy
+	}
+	j.running++
+	j.nextID++
+	job := &ReplayJob{ID: j.nextID, Status: ReplayRunning, Total: len(in.Calculations), CreatedAt: time.Now().UTC()}
+	j.jobs[job.ID] = job
+	snapshot := *job
+	j.mu.Unlock()
+
+	go j.run(job.ID, in)
+
+	return snapshot, nil
+}
+
+// Get returns a snapshot of a job, or ErrReplayNotFound.
+func (j *ReplayJobs) Get(id int64) (ReplayJob, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job, ok := j.jobs[id]
+	if !ok {
+		return ReplayJob{}, domain.ErrReplayNotFound
+	}
+
+	return *job, nil
+}
+
+// run replays in and records the outcome on job id. It runs outside any
+// request, so a panic is recovered here and fails the job instead of the process.
+func (j *ReplayJobs) run(id int64, in ReplayInput) {
+	var (
+		report *ReplayReport
+		err    error
+	)
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				report, err = nil, fmt.Errorf("replay panicked: %v", r)
+				j.logger.Error("replay panicked", "replay_id", id, "panic", r, "stack", string(debug.Stack()))
+			}
+		}()
+		report, err = j.calc.Replay(j.ctx, in, func(done, total int) {
+			j.mu.Lock()
+			j.jobs[id].Done, j.jobs[id].Total = done, total
+			j.mu.Unlock()
+		})
+	}()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.running--
+	job := j.jobs[id]
+	job.FinishedAt = time.Now().UTC()
+	if err != nil {
+		job.Status, job.Err = ReplayFailed, err
+		j.logger.Error("replay failed", "error", err, "replay_id", id)
+	} else {
+		job.Status, job.Report = ReplayDone, report
+		j.logger.Info("replay finished", "replay_id", id, "replayed", report.Replayed, "changed", len(report.Changed), "failed", len(report.Failed))
+	}
+
+	// Forget the oldest finished jobs.
+	j.finished = append(j.finished, id)
+	for len(j.finished) > maxFinishedReplays {
+		delete(j.jobs, j.finished[0])
+		j.finished = j.finished[1:]
+	}
+}
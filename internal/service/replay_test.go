@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+// versionedConfigRepo also serves GetVersion from versions.
+type versionedConfigRepo struct {
+	*stubConfigRepo
+}
+
+func (r versionedConfigRepo) GetVersion(_ context.Context, sku string, version int64) (*domain.PackConfig, error) {
+	for _, v := range r.versions[sku] {
+		if v.Version == version {
+			return &v, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestReplay(t *testing.T) {
+	v1, _ := domain.NewPackConfig([]int64{250, 500})
+	v1.Version = 1
+	v2, _ := domain.NewPackConfig([]int64{250, 500, 1000})
+	v2.Version = 2
+	repo := versionedConfigRepo{&stubConfigRepo{versions: map[string][]domain.PackConfig{domain.DefaultSKU: {*v1, *v2}}}}
+
+	history := &stubHistoryRepo{}
+	_, _ = history.CreateCalculations(context.Background(), []domain.Calculation{
+		{SKU: domain.DefaultSKU, Amount: 1000, ConfigVersion: 1, Packs: []domain.PackBreakdown{{Size: 500, Count: 2}}},
+		{SKU: domain.DefaultSKU, Amount: 250, ConfigVersion: 1, Packs: []domain.PackBreakdown{{Size: 250, Count: 1}}},
+	})
+	svc := NewCalculateService(repo, nil, 1, domain.PackSizeLimits{}, history)
+
+	var progress []int
+	report, err := svc.Replay(context.Background(), ReplayInput{}, func(done, total int) {
+		if total != 2 {
+			t.Fatalf("total = %d, want 2", total)
+		}
+		progress = append(progress, done)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Replayed != 2 || report.Unchanged != 1 || len(report.Changed) != 1 || len(progress) != 2 {
+		t.Fatalf("report = %+v, progress = %v", report, progress)
+	}
+	change := report.Changed[0]
+	if change.CalculationID != 1 || change.ReplayVersion != 2 || change.PackCountDelta != -1 || change.OverfillDelta != 0 ||
+		report.PackCountDelta != -1 {
+		t.Fatalf("change = %+v", change)
+	}
+
+	// On the stored version nothing changes.
+	version := int64(1)
+	report, err = svc.Replay(context.Background(), ReplayInput{Version: &version}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Unchanged != 2 || len(report.Changed) != 0 {
+		t.Fatalf("report on version 1 = %+v", report)
+	}
+
+	version = 7
+	report, err = svc.Replay(context.Background(), ReplayInput{Version: &version}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Failed) != 2 || !errors.Is(report.Failed[0].Err, domain.ErrVersionNotFound) {
+		t.Fatalf("report on version 7 = %+v", report)
+	}
+
+	if _, err := svc.Replay(context.Background(), ReplayInput{MaxCalculations: 1}, nil); !errors.Is(err, domain.ErrReplayTooLarge) {
+		t.Fatalf("expected ErrReplayTooLarge, got %v", err)
+	}
+}
+
+func TestReadCalculations(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		limit   int
+		want    int
+		wantErr error
+	}{
+		{
+			name:   "ndjson",
+			format: ExportNDJSON,
+			input:  `{"id":4,"sku":"default","amount":700,"config_version":2,"packs":[{"size":500,"count":1},{"size":250,"count":1}]}` + "\n\n" + `{"amount":250,"packs":[{"size":250,"count":1}]}`,
+			want:   2,
+		},
+		{
+			name:   "csv",
+			format: ExportCSV,
+			input:  "id,amount,packs\n4,700,500:1;250:1\n5,250,250:1\n",
+			want:   2,
+		},
+		{name: "csv without packs", format: ExportCSV, input: "amount\n700\n", wantErr: domain.ErrInvalidExport},
+		{name: "bad packs", format: ExportCSV, input: "amount,packs\n700,500x1\n", wantErr: domain.ErrInvalidExport},
+		{name: "bad json", format: ExportNDJSON, input: "{", wantErr: domain.ErrInvalidExport},
+		{name: "unknown format", format: "xml", input: "", wantErr: domain.ErrInvalidExport},
+		{name: "too many", format: ExportCSV, input: "amount,packs\n1,1:1\n2,1:2\n", limit: 1, wantErr: domain.ErrReplayTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calcs, err := ReadCalculations(strings.NewReader(tt.input), tt.format, tt.limit)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(calcs) != tt.want || calcs[0].Amount != 700 || len(calcs[0].Packs) != 2 || calcs[0].ID != 4 {
+				t.Fatalf("calculations = %+v", calcs)
+			}
+		})
+	}
+}
+
+// blockingHistoryRepo holds ListCalculations until release is closed, then panics.
+type blockingHistoryRepo struct {
+	stubHistoryRepo
+	release chan struct{}
+}
+
+func (r *blockingHistoryRepo) ListCalculations(context.Context, domain.CalculationFilter) ([]domain.Calculation, error) {
+	<-r.release
+	panic("history backend exploded")
+}
+
+func TestReplayJobs_PanicsAndLimit(t *testing.T) {
+	cfg, _ := domain.NewPackConfig([]int64{250, 500})
+	broken := &domain.PackConfig{SKU: "broken", PackSizes: []int64{-1}, Status: domain.StatusPublished}
+	repo := &stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: cfg, "broken": broken}}
+	history := &blockingHistoryRepo{release: make(chan struct{})}
+	jobs := NewReplayJobs(context.Background(), NewCalculateService(repo, nil, 1, domain.PackSizeLimits{}, history), slog.New(slog.NewTextHandler(io.Discard, nil)), 0, 1)
+
+	stored, err := jobs.Start(ReplayInput{})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if _, err := jobs.Start(ReplayInput{}); !errors.Is(err, domain.ErrReplayBusy) {
+		t.Fatalf("second replay: expected ErrReplayBusy, got %v", err)
+	}
+
+	close(history.release)
+	job := waitForReplay(t, jobs, stored.ID)
+	if job.Status != ReplayFailed || !strings.Contains(job.Err.Error(), "history backend exploded") {
+		t.Fatalf("panicking replay = %+v", job)
+	}
+
+	// A calculation that panics while solving only fails itself.
+	exported := []domain.Calculation{{ID: 1, SKU: "broken", Amount: 10}, {ID: 2, Amount: 250, Packs: []domain.PackBreakdown{{Size: 250, Count: 1}}}}
+	started, err := jobs.Start(ReplayInput{Calculations: exported})
+	if err != nil {
+		t.Fatalf("start after the panic: %v", err)
+	}
+	job = waitForReplay(t, jobs, started.ID)
+	if job.Status != ReplayDone || job.Report.Replayed != 1 || len(job.Report.Failed) != 1 || !strings.Contains(job.Report.Failed[0].Err.Error(), "panic") {
+		t.Fatalf("replay with a panicking calculation = %+v, report %+v", job, job.Report)
+	}
+}
+
+// waitForReplay polls a job until it has finished.
+func waitForReplay(t *testing.T, jobs *ReplayJobs, id int64) ReplayJob {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		job, err := jobs.Get(id)
+		if err != nil {
+			t.Fatalf("get replay %d: %v", id, err)
+		}
+		if job.Status != ReplayRunning {
+			return job
+		}
+	}
+	t.Fatalf("replay %d did not finish", id)
+	return ReplayJob{}
+}
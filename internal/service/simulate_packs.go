@@ -0,0 +1,202 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"go-packing/internal/domain"
+)
+
+// maxWorseOrders caps how many worsened amounts a simulation lists.
+const maxWorseOrders = 50
+
+// maxSimulationNodes bounds the candidate search of a whole simulation. Each
+// distinct amount is solved twice, and every search gets an equal share, at
+// most maxCandidateNodes, so many orders get shallower searches instead of
+// multiplying the work.
+const maxSimulationNodes = 20_000_000
+
+// SimulateInput describes a what-if run of candidate pack sizes over past orders.
+type SimulateInput struct {
+	// SKU selects the current configuration; empty means the default profile.
+	SKU       string
+	PackSizes []int64
+	// Orders are the historical order amounts; repeated amounts are solved once.
+	Orders []int
+}
+
+// Simulation compares the current and the candidate pack sizes over the same orders.
+type Simulation struct {
+	SKU            string
+	ConfigVersion  int64
+	CurrentSizes   []int64
+	CandidateSizes []int64
+	Orders         int
+	Current        SimulationTotals
+	Candidate      SimulationTotals
+	SizeUsage      []SizeUsage
+	// BetterOrders and WorseOrders count orders whose breakdown changed for the
+	// better or the worse: more overfill, or as much overfill in more packs.
+	BetterOrders int
+	WorseOrders  int
+	// Worse lists the worsened amounts, the largest overfill increase first.
+	Worse []SimulatedOrder
+	// TruncatedOrders counts orders whose candidate search stopped at its share
+	// of maxSimulationNodes on either side, so a custom rule chain may prefer
+	// a breakdown that was missed.
+	TruncatedOrders int
+}
+
+// SimulationTotals sums the breakdowns of every simulated order.
+type SimulationTotals struct {
+	ShippedTotal int64
+	Overfill     int64
+	PackCount    int64
+	ExactOrders  int
+}
+
+// SizeUsage is how many packs of a size each side ships over all orders.
+type SizeUsage struct {
+	Size      int64
+	Current   int64
+	Candidate int64
+}
+
+// SimulatedOrder is one amount with both breakdowns; Orders is how often it was ordered.
+type SimulatedOrder struct {
+	Amount    int
+	Orders    int
+	Current   Alternative
+	Candidate Alternative
+}
+
+// Simulate solves every order against the active config of a SKU and against
+// a copy that carries the candidate sizes. Both use the stored rule chain and
+// ignore stock limits, since today's stock says nothing about past orders.
+// Nothing is written and the cached tables of the SKU are left alone.
+func (s *CalculateService) Simulate(ctx context.Context, in SimulateInput) (*Simulation, error) {
+	if err := domain.ValidatePackSizes(in.PackSizes, s.limits); err != nil {
+		return nil, err
+	}
+	sizes := normalizePackSizes(in.PackSizes)
+	demand := make(map[int]int)
+	for _, amount := range in.Orders {
+		if err := domain.ValidateAmount(amount); err != nil {
+			return nil, err
+		}
+		demand[amount]++
+	}
+
+	sku, err := domain.NormalizeSKU(in.SKU)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.loadConfig(ctx, sku, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	current := *stored
+	current.StockLimits, current.PackCosts = nil, nil
+	candidate := current
+	// Every candidate size is meant to be used, even one that is inactive today.
+	// Dropping the map also keeps Replace from pruning the one current shares.
+	candidate.Packs = nil
+	if err := candidate.Replace(in.PackSizes); err != nil {
+		return nil, err
+	}
+	candidateTable := newPackTable(sizes)
+
+	sim := &Simulation{
+		SKU:            sku,
+		ConfigVersion:  stored.Version,
+		CurrentSizes:   current.ActiveSizes(),
+		CandidateSizes: candidate.PackSizes,
+		Orders:         len(in.Orders),
+	}
+	usage := make(map[int64]*SizeUsage)
+	for _, size := range append(slices.Clone(current.ActiveSizes()), candidate.PackSizes...) {
+		usage[size] = &SizeUsage{Size: size}
+	}
+
+	amounts := make([]int, 0, len(demand))
+	for amount := range demand {
+		amounts = append(amounts, amount)
+	}
+	slices.Sort(amounts)
+	budget := max(1, maxSimulationNodes/(2*max(1, len(amounts))))
+
+	for _, amount := range amounts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		calc := CalculateInput{Amount: amount, Objective: domain.ObjectiveMinOverfill, CandidateNodes: budget}
+		before, err := s.solve(&current, calc)
+		if err != nil {
+			return nil, err
+		}
+		after, err := solveConfig(&candidate, calc, func(*domain.PackConfig) *packTable { return candidateTable })
+		if err != nil {
+			return nil, err
+		}
+
+		order := SimulatedOrder{
+			Amount:    amount,
+			Orders:    demand[amount],
+			Current:   newAlternative(before.Packs, amount, ""),
+			Candidate: newAlternative(after.Packs, amount, ""),
+		}
+		if before.CandidatesTruncated || after.CandidatesTruncated {
+			sim.TruncatedOrders += order.Orders
+		}
+		sim.Current.add(order.Current, order.Orders)
+		sim.Candidate.add(order.Candidate, order.Orders)
+		for _, p := range order.Current.Packs {
+			usage[int64(p.Size)].Current += int64(p.Count * order.Orders)
+		}
+		for _, p := range order.Candidate.Packs {
+			usage[int64(p.Size)].Candidate += int64(p.Count * order.Orders)
+		}
+
+		switch compareOutcome(order.Candidate, order.Current) {
+		case -1:
+			sim.BetterOrders += order.Orders
+		case 1:
+			sim.WorseOrders += order.Orders
+			sim.Worse = append(sim.Worse, order)
+		}
+	}
+
+	for _, u := range usage {
+		sim.SizeUsage = append(sim.SizeUsage, *u)
+	}
+	slices.SortFunc(sim.SizeUsage, func(a, b SizeUsage) int { return cmp.Compare(a.Size, b.Size) })
+
+	slices.SortStableFunc(sim.Worse, func(a, b SimulatedOrder) int {
+		return cmp.Or(
+			cmp.Compare(b.Candidate.Overfill-b.Current.Overfill, a.Candidate.Overfill-a.Current.Overfill),
+			cmp.Compare(b.Candidate.PackCount-b.Current.PackCount, a.Candidate.PackCount-a.Current.PackCount),
+		)
+	})
+	sim.Worse = sim.Worse[:min(len(sim.Worse), maxWorseOrders)]
+
+	return sim, nil
+}
+
+// add counts one breakdown for each of orders identical orders.
+func (t *SimulationTotals) add(alt Alternative, orders int) {
+	t.ShippedTotal += int64(alt.Total * orders)
+	t.Overfill += int64(alt.Overfill * orders)
+	t.PackCount += int64(alt.PackCount * orders)
+	if alt.Overfill == 0 {
+		t.ExactOrders += orders
+	}
+}
+
+// compareOutcome orders two breakdowns of one amount by overfill, then pack count.
+func compareOutcome(a, b Alternative) int {
+	return cmp.Or(cmp.Compare(a.Overfill, b.Overfill), cmp.Compare(a.PackCount, b.PackCount))
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go-packing/internal/domain"
+)
+
+func TestSimulate(t *testing.T) {
+	stored, _ := domain.NewPackConfig([]int64{250, 500, 1000, 2000, 5000})
+	stored.StockLimits = map[int64]int{5000: 0}
+	repo := &stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: stored}}
+	svc := NewCalculateService(repo, NewSolverCache(1<<20), 1, domain.PackSizeLimits{}, nil)
+
+	sim, err := svc.Simulate(context.Background(), SimulateInput{
+		PackSizes: []int64{2000, 250, 500, 1000},
+		Orders:    []int{251, 251, 12001, 5000},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Stock limits are ignored, so the current side still ships 5000 packs.
+	wantCurrent := SimulationTotals{ShippedTotal: 500 + 500 + 12250 + 5000, Overfill: 249 + 249 + 249, PackCount: 1 + 1 + 4 + 1, ExactOrders: 1}
+	if sim.Current != wantCurrent {
+		t.Fatalf("current = %+v, want %+v", sim.Current, wantCurrent)
+	}
+	wantCandidate := SimulationTotals{ShippedTotal: 500 + 500 + 12250 + 5000, Overfill: 249 + 249 + 249, PackCount: 1 + 1 + 7 + 3, ExactOrders: 1}
+	if sim.Candidate != wantCandidate {
+		t.Fatalf("candidate = %+v, want %+v", sim.Candidate, wantCandidate)
+	}
+
+	if sim.Orders != 4 || sim.WorseOrders != 2 || sim.BetterOrders != 0 {
+		t.Fatalf("orders = %d, worse = %d, better = %d", sim.Orders, sim.WorseOrders, sim.BetterOrders)
+	}
+	if len(sim.Worse) != 2 || sim.Worse[0].Amount != 12001 || sim.Worse[1].Amount != 5000 {
+		t.Fatalf("worse = %+v, want 12001 then 5000", sim.Worse)
+	}
+
+	wantUsage := []SizeUsage{
+		{Size: 250, Current: 1, Candidate: 1},
+		{Size: 500, Current: 2, Candidate: 2},
+		{Size: 1000, Current: 0, Candidate: 1},
+		{Size: 2000, Current: 1, Candidate: 8},
+		{Size: 5000, Current: 3, Candidate: 0},
+	}
+	if !reflect.DeepEqual(sim.SizeUsage, wantUsage) {
+		t.Fatalf("usage = %+v, want %+v", sim.SizeUsage, wantUsage)
+	}
+
+	if !reflect.DeepEqual(stored.PackSizes, []int64{250, 500, 1000, 2000, 5000}) || stored.Version != 0 || stored.StockLimits[5000] != 0 {
+		t.Fatalf("stored config was modified: %+v", stored)
+	}
+}
+
+func TestSimulate_InvalidInput(t *testing.T) {
+	stored, _ := domain.NewPackConfig([]int64{250, 500})
+	repo := &stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: stored}}
+	svc := NewCalculateService(repo, nil, 1, domain.PackSizeLimits{}, nil)
+
+	tests := []struct {
+		name string
+		in   SimulateInput
+		want error
+	}{
+		{name: "no sizes", in: SimulateInput{Orders: []int{1}}, want: domain.ErrInvalidPackSizes},
+		{name: "duplicate size", in: SimulateInput{PackSizes: []int64{5, 5}, Orders: []int{1}}, want: domain.ErrInvalidPackSizes},
+		{name: "zero amount", in: SimulateInput{PackSizes: []int64{5}, Orders: []int{1, 0}}, want: domain.ErrInvalidAmount},
+		{name: "not configured", in: SimulateInput{SKU: "widget", PackSizes: []int64{5}, Orders: []int{1}}, want: domain.ErrPackSizesNotConfigured},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.Simulate(context.Background(), tt.in); !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimulate_BoundsCandidateSearch(t *testing.T) {
+	stored, _ := domain.NewPackConfig([]int64{3, 7, 11, 13, 17, 19, 23})
+	stored.Rules = []domain.Rule{{Criterion: domain.CriterionPackCount}}
+	repo := &stubConfigRepo{configs: map[string]*domain.PackConfig{domain.DefaultSKU: stored}}
+	svc := NewCalculateService(repo, NewSolverCache(1<<20), 1, domain.PackSizeLimits{}, nil)
+
+	// One small order gets the full search.
+	sim, err := svc.Simulate(context.Background(), SimulateInput{PackSizes: []int64{3, 7, 11}, Orders: []int{50}})
+	if err != nil || sim.TruncatedOrders != 0 {
+		t.Fatalf("small simulation = %+v, %v; want no truncated orders", sim, err)
+	}
+
+	// Many large orders share the budget, so their searches are cut short.
+	orders := make([]int, 20_000)
+	for i := range orders {
+		orders[i] = 100_000 + i
+	}
+	sim, err = svc.Simulate(context.Background(), SimulateInput{PackSizes: []int64{3, 7, 11}, Orders: orders})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sim.TruncatedOrders != len(orders) {
+		t.Fatalf("truncated orders = %d, want %d", sim.TruncatedOrders, len(orders))
+	}
+}
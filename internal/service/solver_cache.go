@@ -0,0 +1,125 @@
+package service
+
+import (
+	"container/list"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go-packing/internal/domain"
+)
+
+// SolverCache keeps packTables by SKU, config version and active sizes, so
+// repeated calculations skip the residue searches. A SKU may hold tables of
+// several versions, and a table is only reused for the exact sizes it was built
+// from, so a SKU re-created at an old version number never gets a stale table.
+// Tables are evicted least recently used first once their estimated size
+// exceeds the budget. A nil cache or a zero budget builds a fresh table on
+// every call.
+type SolverCache struct {
+	mu      sync.Mutex
+	budget  int64
+	used    int64
+	entries map[solverCacheKey]*list.Element
+	lru     *list.List // front is the most recently used
+}
+
+// solverCacheKey identifies a table; sizes lists the active sizes it was built from.
+type solverCacheKey struct {
+	sku     string
+	version int64
+	sizes   string
+}
+
+type solverCacheEntry struct {
+	key   solverCacheKey
+	table *packTable
+}
+
+// NewSolverCache creates a cache holding at most budgetBytes of tables.
+func NewSolverCache(budgetBytes int64) *SolverCache {
+	return &SolverCache{
+		budget:  budgetBytes,
+		entries: make(map[solverCacheKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// table returns the packTable of a config, building and caching it when none
+// was cached for its SKU, version and active sizes.
+func (c *SolverCache) table(cfg *domain.PackConfig) *packTable {
+	sizes := normalizePackSizes(cfg.ActiveSizes())
+	if c == nil || c.budget <= 0 {
+		return newPackTable(sizes)
+	}
+
+	key := solverCacheKey{sku: cfg.SKU, version: cfg.Version, sizes: sizesKey(sizes)}
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		c.mu.Unlock()
+		return el.Value.(*solverCacheEntry).table
+	}
+	c.mu.Unlock()
+
+	// Built outside the lock; concurrent misses may build twice, the last one wins.
+	t := newPackTable(sizes)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(key)
+	if t.bytes() > c.budget {
+		return t
+	}
+	c.entries[key] = c.lru.PushFront(&solverCacheEntry{key: key, table: t})
+	c.used += t.bytes()
+	for c.used > c.budget {
+		c.remove(c.lru.Back().Value.(*solverCacheEntry).key)
+	}
+
+	return t
+}
+
+// Invalidate drops every table of a SKU after its config changed or was
+// deleted. It only affects this process; other replicas never reuse a table
+// for changed sizes because the sizes are part of the key.
+func (c *SolverCache) Invalidate(sku string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if key.sku == sku {
+			c.remove(key)
+		}
+	}
+}
+
+// remove drops an entry; the caller holds the lock.
+func (c *SolverCache) remove(key solverCacheKey) {
+	el, ok := c.entries[key]
+	if !ok {
+		return
+	}
+
+	c.used -= el.Value.(*solverCacheEntry).table.bytes()
+	c.lru.Remove(el)
+	delete(c.entries, key)
+}
+
+// sizesKey encodes sorted sizes as a comma-separated list.
+func sizesKey(sizes []int) string {
+	var b strings.Builder
+	for i, s := range sizes {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Itoa(s))
+	}
+
+	return b.String()
+}
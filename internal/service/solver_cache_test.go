@@ -0,0 +1,94 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"go-packing/internal/domain"
+)
+
+func TestSolverCache(t *testing.T) {
+	cfg := &domain.PackConfig{SKU: "widget", Version: 1, PackSizes: []int64{23, 31, 53}}
+	cache := NewSolverCache(1 << 20)
+
+	first := cache.table(cfg)
+	if cache.table(cfg) != first {
+		t.Fatal("expected the cached table for the same version")
+	}
+
+	cfg.Version = 2
+	second := cache.table(cfg)
+	if second == first {
+		t.Fatal("expected a new table for a new version")
+	}
+
+	cache.Invalidate("widget")
+	if cache.table(cfg) == second {
+		t.Fatal("expected a new table after invalidation")
+	}
+}
+
+func TestSolverCache_Versions(t *testing.T) {
+	v1 := &domain.PackConfig{SKU: "widget", Version: 1, PackSizes: []int64{23, 31}}
+	v2 := &domain.PackConfig{SKU: "widget", Version: 2, PackSizes: []int64{23, 31, 53}}
+	cache := NewSolverCache(1 << 20)
+
+	// Calculations pinned to different versions keep both tables.
+	first, second := cache.table(v1), cache.table(v2)
+	if cache.table(v1) != first || cache.table(v2) != second {
+		t.Fatal("expected a cached table per version")
+	}
+
+	// Deleted and re-created elsewhere, the SKU restarts at a used version
+	// number with other sizes, and this cache was never invalidated.
+	recreated := &domain.PackConfig{SKU: "widget", Version: 1, PackSizes: []int64{250, 500}}
+	got, err := cache.table(recreated).calculate(251)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []domain.PackBreakdown{{Size: 500, Count: 1}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("re-created config = %v, want %v", got, want)
+	}
+	if cache.table(v1) != first {
+		t.Fatal("expected the table of the old sizes to stay cached")
+	}
+
+	cache.Invalidate("widget")
+	if len(cache.entries) != 0 || cache.used != 0 {
+		t.Fatalf("after invalidation: %d entries, %d bytes", len(cache.entries), cache.used)
+	}
+}
+
+func TestSolverCache_Budget(t *testing.T) {
+	small := &domain.PackConfig{SKU: "small", PackSizes: []int64{3, 5}}
+	other := &domain.PackConfig{SKU: "other", PackSizes: []int64{4, 7}}
+	large := &domain.PackConfig{SKU: "large", PackSizes: []int64{250, 5000}}
+
+	// Room for the two small tables, not for the large one.
+	budget := newPackTable([]int{3, 5}).bytes() + newPackTable([]int{4, 7}).bytes()
+	cache := NewSolverCache(budget)
+
+	smallTable := cache.table(small)
+	otherTable := cache.table(other)
+	if cache.table(large) == cache.table(large) {
+		t.Fatal("a table larger than the budget must not be cached")
+	}
+	if cache.table(small) != smallTable || cache.table(other) != otherTable {
+		t.Fatal("expected both small tables to stay cached")
+	}
+
+	// other was used last, so a third table evicts small.
+	cache.table(&domain.PackConfig{SKU: "third", PackSizes: []int64{2, 5}})
+	if cache.used > cache.budget {
+		t.Fatalf("cache holds %d bytes, budget is %d", cache.used, cache.budget)
+	}
+	if cache.table(other) != otherTable {
+		t.Fatal("expected the most recently used table to survive")
+	}
+	if cache.table(small) == smallTable {
+		t.Fatal("expected the least recently used table to be evicted")
+	}
+	if cache.used > cache.budget {
+		t.Fatalf("cache holds %d bytes, budget is %d", cache.used, cache.budget)
+	}
+}
@@ -9,14 +9,22 @@ type ErrorResponse struct {
 type ErrorBody struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
+	// Details lists the invalid fields of a rejected request, if known.
+	Details []ErrorDetail `json:"details,omitempty"`
 }
 
-func NewErrorResponse(code, message string) ErrorResponse {
+// ErrorDetail names one invalid field and why it was rejected.
+type ErrorDetail struct {
+	Field  string `json:"field" example:"pack_sizes[2]"`
+	Reason string `json:"reason" example:"duplicate of 500"`
+}
+
+func NewErrorResponse(code, message string, details ...ErrorDetail) ErrorResponse {
 	return ErrorResponse{
-		Error: ErrorBody{Code: code, Message: message},
+		Error: ErrorBody{Code: code, Message: message, Details: details},
 	}
 }
 
-func WriteError(c *gin.Context, status int, code, message string) {
-	c.JSON(status, NewErrorResponse(code, message))
+func WriteError(c *gin.Context, status int, code, message string, details ...ErrorDetail) {
+	c.JSON(status, NewErrorResponse(code, message, details...))
 }
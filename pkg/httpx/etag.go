@@ -0,0 +1,37 @@
+package httpx
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidIfMatch = errors.New("If-Match must be \"*\" or a single version ETag")
+
+// VersionETag formats a version number as a strong ETag.
+func VersionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseIfMatch reads a version ETag from an If-Match header value.
+// It returns nil when the header is empty or "*", meaning no particular version;
+// callers that must tell a missing resource apart check for "*" first.
+func ParseIfMatch(header string) (*int64, error) {
+	value := strings.TrimSpace(header)
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+
+	// Weak validators are accepted because the version is the only validator.
+	value = strings.TrimPrefix(value, "W/")
+	if len(value) < 2 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return nil, ErrInvalidIfMatch
+	}
+
+	version, err := strconv.ParseInt(value[1:len(value)-1], 10, 64)
+	if err != nil || version < 0 {
+		return nil, ErrInvalidIfMatch
+	}
+
+	return &version, nil
+}
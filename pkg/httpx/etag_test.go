@@ -0,0 +1,60 @@
+package httpx
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    int64
+		none    bool
+		wantErr bool
+	}{
+		{header: "", none: true},
+		{header: "*", none: true},
+		{header: `"3"`, want: 3},
+		{header: ` "0" `, want: 0},
+		{header: `W/"7"`, want: 7},
+		{header: `3`, wantErr: true},
+		{header: `"3`, wantErr: true},
+		{header: `"-1"`, wantErr: true},
+		{header: `"abc"`, wantErr: true},
+		{header: `"1", "2"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			got, err := ParseIfMatch(tt.header)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidIfMatch) {
+					t.Fatalf("expected ErrInvalidIfMatch, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.none {
+				if got != nil {
+					t.Fatalf("version = %d, want none", *got)
+				}
+				return
+			}
+			if got == nil || *got != tt.want {
+				t.Fatalf("version = %v, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionETag(t *testing.T) {
+	etag := VersionETag(12)
+	if etag != `"12"` {
+		t.Fatalf("ETag = %s", etag)
+	}
+	if version, err := ParseIfMatch(etag); err != nil || *version != 12 {
+		t.Fatalf("ParseIfMatch(%s) = %v, %v", etag, version, err)
+	}
+}
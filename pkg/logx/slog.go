@@ -1,14 +1,21 @@
 package logx
 
 import (
+	"io"
 	"log/slog"
 	"os"
 	"strings"
 )
 
 func NewJSONLogger(level string) *slog.Logger {
+	return NewJSONLoggerTo(os.Stdout, level)
+}
+
+// NewJSONLoggerTo is NewJSONLogger writing to w, e.g. stderr for a command
+// whose output goes to stdout.
+func NewJSONLoggerTo(w io.Writer, level string) *slog.Logger {
 	logLevel := parseLogLevel(level)
-	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
 		Level: logLevel,
 	})
 	logger := slog.New(handler)